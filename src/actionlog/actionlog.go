@@ -0,0 +1,245 @@
+// Package actionlog builds the log/slog logger shared by the action binaries.
+//
+// Three formats are supported:
+//   - text: plain lines; warnings and errors are prefixed with "Warning:" and "Error:".
+//   - github: like text, but debug, warning and error records become workflow
+//     commands (::debug::, ::warning::, ::error::) and show up as annotations.
+//   - json: one JSON object per record, for log processing.
+//
+// Attributes are appended to the message as key=value pairs in the text formats.
+package actionlog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Supported LOG_FORMAT values.
+const (
+	FormatText   = "text"
+	FormatGitHub = "github"
+	FormatJSON   = "json"
+)
+
+// groupKey marks records that open or close a collapsible group of log lines.
+const (
+	groupKey   = "log_group"
+	groupStart = "start"
+	groupEnd   = "end"
+)
+
+// Options configures New.
+type Options struct {
+	Format string     // one of the Format* constants, FormatText when empty
+	Level  slog.Level // minimum level to log
+}
+
+// New returns a logger writing records to w in the given format.
+func New(w io.Writer, opts Options) *slog.Logger {
+	if opts.Format == FormatJSON {
+		return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: opts.Level}))
+	}
+
+	return slog.New(&lineHandler{
+		mu:     &sync.Mutex{},
+		w:      w,
+		level:  opts.Level,
+		github: opts.Format == FormatGitHub,
+	})
+}
+
+// Setup configures the default slog logger from the environment and returns it:
+//   - LOG_FORMAT: text, github or json. Defaults to github inside GitHub Actions and text elsewhere.
+//   - LOG_LEVEL: debug, info, warn or error. Defaults to info, or debug when the runner has debug logging on.
+//
+// Malformed values fall back to the defaults with a warning.
+func Setup(w io.Writer) *slog.Logger {
+	opts, problems := optionsFromEnv()
+
+	logger := New(w, opts)
+	slog.SetDefault(logger)
+
+	for _, problem := range problems {
+		logger.Warn(problem)
+	}
+
+	return logger
+}
+
+func optionsFromEnv() (Options, []string) {
+	var problems []string
+
+	opts := Options{Format: FormatText, Level: slog.LevelInfo}
+	if os.Getenv("GITHUB_ACTIONS") == "true" {
+		opts.Format = FormatGitHub
+	}
+	if os.Getenv("RUNNER_DEBUG") == "1" {
+		opts.Level = slog.LevelDebug
+	}
+
+	switch format := strings.ToLower(strings.TrimSpace(os.Getenv("LOG_FORMAT"))); format {
+	case "":
+	case FormatText, FormatGitHub, FormatJSON:
+		opts.Format = format
+	default:
+		problems = append(problems, fmt.Sprintf("LOG_FORMAT has incorrect value %q, expected text, github or json (using %s)", format, opts.Format))
+	}
+
+	if raw := strings.TrimSpace(os.Getenv("LOG_LEVEL")); raw != "" {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(raw)); err != nil {
+			problems = append(problems, fmt.Sprintf("LOG_LEVEL has incorrect value %q, expected debug, info, warn or error (using %s)", raw, opts.Level))
+		} else {
+			opts.Level = level
+		}
+	}
+
+	return opts, problems
+}
+
+// StartGroup opens a collapsible group titled title. In the github format it
+// is rendered as ::group::; groups do not nest, so close one before opening the next.
+func StartGroup(l *slog.Logger, title string) {
+	l.Info(title, slog.String(groupKey, groupStart))
+}
+
+// EndGroup closes the group opened by StartGroup.
+func EndGroup(l *slog.Logger) {
+	l.Info("", slog.String(groupKey, groupEnd))
+}
+
+// lineHandler renders records as single human-readable lines.
+type lineHandler struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	level  slog.Level
+	github bool
+	attrs  string // preformatted attributes added with WithAttrs
+	prefix string // key prefix added with WithGroup
+}
+
+func (h *lineHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *lineHandler) Handle(_ context.Context, r slog.Record) error {
+	var sb strings.Builder
+	group := ""
+
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == groupKey {
+			group = a.Value.String()
+			return true
+		}
+		appendAttr(&sb, h.prefix, a)
+		return true
+	})
+
+	var line string
+	switch group {
+	case groupStart:
+		if h.github {
+			line = "::group::" + r.Message
+		} else {
+			line = r.Message
+		}
+	case groupEnd:
+		if !h.github {
+			return nil
+		}
+		line = "::endgroup::"
+	default:
+		line = h.levelPrefix(r.Level) + h.escape(r.Message+h.attrs+sb.String())
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, line+"\n")
+	return err
+}
+
+func (h *lineHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var sb strings.Builder
+	for _, a := range attrs {
+		appendAttr(&sb, h.prefix, a)
+	}
+
+	h2 := *h
+	h2.attrs += sb.String()
+	return &h2
+}
+
+func (h *lineHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	h2 := *h
+	h2.prefix += name + "."
+	return &h2
+}
+
+func (h *lineHandler) levelPrefix(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return h.pick("::error::", "Error: ")
+	case level >= slog.LevelWarn:
+		return h.pick("::warning::", "Warning: ")
+	case level < slog.LevelInfo:
+		return h.pick("::debug::", "Debug: ")
+	default:
+		return ""
+	}
+}
+
+func (h *lineHandler) pick(github, text string) string {
+	if h.github {
+		return github
+	}
+	return text
+}
+
+// escape keeps a workflow command on one line, as required by the runner.
+func (h *lineHandler) escape(s string) string {
+	if !h.github {
+		return s
+	}
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// appendAttr writes " key=value", flattening groups into dotted keys.
+func appendAttr(sb *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			appendAttr(sb, prefix, ga)
+		}
+		return
+	}
+
+	sb.WriteByte(' ')
+	sb.WriteString(prefix + a.Key)
+	sb.WriteByte('=')
+	sb.WriteString(quoteIfNeeded(a.Value.String()))
+}
+
+// quoteIfNeeded quotes values that would otherwise be ambiguous in key=value output.
+func quoteIfNeeded(s string) string {
+	if s == "" || strings.ContainsAny(s, " =\"\t\r\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
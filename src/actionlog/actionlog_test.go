@@ -0,0 +1,97 @@
+package actionlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNew_GitHubFormat(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	l := New(&buf, Options{Format: FormatGitHub, Level: slog.LevelDebug})
+
+	StartGroup(l, "Async export pid-1")
+	l.Info("async_export", "event", "poll", "elapsed", 1500*time.Millisecond)
+	EndGroup(l)
+	l.Warn("failed to set upstream", "branch", "lok_1", "error", errors.New("exit status 1"))
+	l.Error("push failed\nremote: denied", "error_kind", "push_rejected")
+	l.Debug("raw", "pct", "100%")
+
+	want := "::group::Async export pid-1\n" +
+		"async_export event=poll elapsed=1.5s\n" +
+		"::endgroup::\n" +
+		"::warning::failed to set upstream branch=lok_1 error=\"exit status 1\"\n" +
+		"::error::push failed%0Aremote: denied error_kind=push_rejected\n" +
+		"::debug::raw pct=100%25\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestNew_TextFormat(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	l := New(&buf, Options{Format: FormatText}).With("project", "p1").WithGroup("cache")
+
+	StartGroup(l, "Downloading")
+	l.Info("hit", "age", time.Minute, slog.Group("entry", "key", ""))
+	EndGroup(l)
+	l.Warn("stale")
+	l.Debug("hidden")
+
+	want := "Downloading\n" +
+		"hit project=p1 cache.age=1m0s cache.entry.key=\"\"\n" +
+		"Warning: stale project=p1\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestNew_JSONFormat(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	l := New(&buf, Options{Format: FormatJSON})
+	l.Warn("cannot read lockfile", "path", "lokalise.lock")
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+	if rec["level"] != "WARN" || rec["msg"] != "cannot read lockfile" || rec["path"] != "lokalise.lock" {
+		t.Fatalf("unexpected record: %v", rec)
+	}
+}
+
+func TestOptionsFromEnv(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("RUNNER_DEBUG", "")
+	t.Setenv("LOG_FORMAT", "")
+	t.Setenv("LOG_LEVEL", "")
+
+	opts, problems := optionsFromEnv()
+	if opts.Format != FormatGitHub || opts.Level != slog.LevelInfo || len(problems) != 0 {
+		t.Fatalf("unexpected defaults: %+v %v", opts, problems)
+	}
+
+	t.Setenv("RUNNER_DEBUG", "1")
+	t.Setenv("LOG_FORMAT", " JSON ")
+	if opts, _ := optionsFromEnv(); opts.Format != FormatJSON || opts.Level != slog.LevelDebug {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+
+	t.Setenv("GITHUB_ACTIONS", "")
+	t.Setenv("LOG_FORMAT", "xml")
+	t.Setenv("LOG_LEVEL", "loud")
+	opts, problems = optionsFromEnv()
+	if opts.Format != FormatText || len(problems) != 2 || !strings.Contains(problems[0], "LOG_FORMAT") {
+		t.Fatalf("unexpected result: %+v %v", opts, problems)
+	}
+}
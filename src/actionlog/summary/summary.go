@@ -0,0 +1,104 @@
+// Package summary composes markdown for the GitHub Actions job summary.
+package summary
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// WriteToStepSummary appends markdown to the file pointed to by the
+// GITHUB_STEP_SUMMARY environment variable. Like githuboutput.WriteToGitHubOutput
+// it returns false when the variable is unset or the file cannot be written.
+func WriteToStepSummary(markdown string) bool {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return false
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		slog.Warn("cannot open GITHUB_STEP_SUMMARY file", "path", path, "error", err)
+		return false
+	}
+	defer func() { _ = file.Close() }()
+
+	if _, err := file.WriteString(markdown); err != nil {
+		slog.Warn("cannot write job summary", "path", path, "error", err)
+		return false
+	}
+	return true
+}
+
+// Markdown builds a summary section. Every block ends with a blank line so
+// sections written by different steps do not run into each other.
+type Markdown struct {
+	sb strings.Builder
+}
+
+// Heading adds a level 3 heading; steps share the page, so sections stay small.
+func (m *Markdown) Heading(text string) *Markdown {
+	fmt.Fprintf(&m.sb, "### %s\n\n", text)
+	return m
+}
+
+// Paragraph adds a line of text.
+func (m *Markdown) Paragraph(text string) *Markdown {
+	fmt.Fprintf(&m.sb, "%s\n\n", text)
+	return m
+}
+
+// Table adds a table; cells are escaped so they cannot break the layout.
+func (m *Markdown) Table(header []string, rows [][]string) *Markdown {
+	if len(rows) == 0 {
+		return m
+	}
+
+	m.row(header)
+	m.sb.WriteString("|" + strings.Repeat(" --- |", len(header)) + "\n")
+	for _, r := range rows {
+		m.row(r)
+	}
+	m.sb.WriteString("\n")
+	return m
+}
+
+func (m *Markdown) row(cells []string) {
+	m.sb.WriteString("|")
+	for _, c := range cells {
+		m.sb.WriteString(" " + Cell(c) + " |")
+	}
+	m.sb.WriteString("\n")
+}
+
+// Details adds a collapsible block with a fenced code body.
+func (m *Markdown) Details(title, lang, body string) *Markdown {
+	fence := "```"
+	for strings.Contains(body, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(&m.sb, "<details><summary>%s</summary>\n\n%s%s\n%s\n%s\n\n</details>\n\n",
+		title, fence, lang, strings.TrimRight(body, "\n"), fence)
+	return m
+}
+
+// String returns the markdown built so far.
+func (m *Markdown) String() string {
+	return m.sb.String()
+}
+
+// Code formats s as inline code, or returns an empty cell marker for empty s.
+func Code(s string) string {
+	if s == "" {
+		return "–"
+	}
+	return "`" + strings.ReplaceAll(s, "`", "'") + "`"
+}
+
+// Cell escapes s for use in a table cell.
+func Cell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\r", "")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
@@ -0,0 +1,56 @@
+package summary
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMarkdown(t *testing.T) {
+	t.Parallel()
+
+	var m Markdown
+	m.Heading("Lokalise download").
+		Table([]string{"Setting", "Value"}, [][]string{
+			{"Project", Code("123.abc")},
+			{"Error", "a | b\nc"},
+			{"Branch", Code("")},
+		}).
+		Table([]string{"Skipped"}, nil).
+		Details("Params", "json", "{\"format\": \"json\"}\n")
+
+	want := "### Lokalise download\n\n" +
+		"| Setting | Value |\n" +
+		"| --- | --- |\n" +
+		"| Project | `123.abc` |\n" +
+		"| Error | a \\| b<br>c |\n" +
+		"| Branch | – |\n\n" +
+		"<details><summary>Params</summary>\n\n```json\n{\"format\": \"json\"}\n```\n\n</details>\n\n"
+	if m.String() != want {
+		t.Fatalf("unexpected markdown:\n%s", m.String())
+	}
+}
+
+func TestWriteToStepSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.md")
+
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+	if WriteToStepSummary("x") {
+		t.Fatal("expected false without GITHUB_STEP_SUMMARY")
+	}
+
+	t.Setenv("GITHUB_STEP_SUMMARY", path)
+	if !WriteToStepSummary("one\n") || !WriteToStepSummary("two\n") {
+		t.Fatal("expected writes to succeed")
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil || string(got) != "one\ntwo\n" {
+		t.Fatalf("unexpected summary file: %q %v", got, err)
+	}
+
+	t.Setenv("GITHUB_STEP_SUMMARY", filepath.Join(path, "nested"))
+	if WriteToStepSummary("x") {
+		t.Fatal("expected false for an unwritable path")
+	}
+}
@@ -0,0 +1,132 @@
+// Package cienv maps the environment of the CI service running the action to
+// the values the steps need: the branch being built, the commit SHA, the user
+// who triggered the run and where step outputs go.
+//
+// GitHub Actions, GitLab CI and Bitbucket Pipelines are detected from the
+// variables they always set; anything else is a generic environment. The
+// GITHUB_* names double as the generic variables: set by hand or through the
+// CLI flags they take precedence on every provider, so a value can always be
+// overridden the same way.
+package cienv
+
+import (
+	"os"
+	"strings"
+)
+
+// Provider identifies a CI service.
+type Provider string
+
+// Supported providers.
+const (
+	GitHubActions      Provider = "github"
+	GitLabCI           Provider = "gitlab"
+	BitbucketPipelines Provider = "bitbucket"
+	Generic            Provider = "generic"
+)
+
+// Env describes the run as reported by the CI service.
+type Env struct {
+	Provider   Provider
+	RefName    string // branch or tag being built
+	HeadRef    string // source branch of the pull/merge request, empty outside of one
+	BaseRef    string // target branch of the pull/merge request, empty outside of one
+	SHA        string // commit being built
+	Actor      string // login of the user who triggered the run
+	UserName   string // display name of that user, when the provider exposes it
+	UserEmail  string // email of that user, when the provider exposes it
+	Repository string // path of the repository, e.g. owner/repo or group/subgroup/project
+	ServerURL  string // web URL of the forge, e.g. https://gitlab.example.com
+}
+
+// Detect reads the environment of the current process. CI_PROVIDER forces a
+// provider when detection picks the wrong one, e.g. a GitLab runner started
+// from inside a GitHub workflow.
+func Detect() Env {
+	env := Env{Provider: detectProvider()}
+
+	switch env.Provider {
+	case GitLabCI:
+		env.RefName = getenv("CI_COMMIT_REF_NAME")
+		env.HeadRef = getenv("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME")
+		env.BaseRef = getenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME")
+		env.SHA = getenv("CI_COMMIT_SHA")
+		env.Actor = getenv("GITLAB_USER_LOGIN")
+		env.UserName = getenv("GITLAB_USER_NAME")
+		env.UserEmail = getenv("GITLAB_USER_EMAIL")
+		env.Repository = getenv("CI_PROJECT_PATH")
+		env.ServerURL = getenv("CI_SERVER_URL")
+	case BitbucketPipelines:
+		env.RefName = firstNonEmpty(getenv("BITBUCKET_BRANCH"), getenv("BITBUCKET_TAG"))
+		if getenv("BITBUCKET_PR_ID") != "" {
+			env.HeadRef = getenv("BITBUCKET_BRANCH")
+			env.BaseRef = getenv("BITBUCKET_PR_DESTINATION_BRANCH")
+		}
+		env.SHA = getenv("BITBUCKET_COMMIT")
+		env.Repository = getenv("BITBUCKET_REPO_FULL_NAME")
+		if env.Repository != "" {
+			env.ServerURL = "https://bitbucket.org"
+		}
+	}
+
+	// GitHub Actions sets these itself; elsewhere they are explicit overrides.
+	override(&env.RefName, "GITHUB_REF_NAME")
+	override(&env.HeadRef, "GITHUB_HEAD_REF")
+	override(&env.BaseRef, "GITHUB_BASE_REF")
+	override(&env.SHA, "GITHUB_SHA")
+	override(&env.Actor, "GITHUB_ACTOR")
+	override(&env.Repository, "GITHUB_REPOSITORY")
+	override(&env.ServerURL, "GITHUB_SERVER_URL")
+
+	return env
+}
+
+// Branch returns the branch the changes belong to: the source branch of a
+// pull/merge request, the built ref otherwise. On pull request events
+// GITHUB_REF_NAME is "<number>/merge", hence the head ref goes first.
+func (e Env) Branch() string {
+	return firstNonEmpty(e.HeadRef, e.RefName)
+}
+
+func detectProvider() Provider {
+	switch Provider(strings.ToLower(getenv("CI_PROVIDER"))) {
+	case GitHubActions:
+		return GitHubActions
+	case GitLabCI:
+		return GitLabCI
+	case BitbucketPipelines:
+		return BitbucketPipelines
+	case Generic:
+		return Generic
+	}
+
+	switch {
+	case getenv("GITHUB_ACTIONS") == "true":
+		return GitHubActions
+	case getenv("GITLAB_CI") == "true":
+		return GitLabCI
+	case getenv("BITBUCKET_BUILD_NUMBER") != "":
+		return BitbucketPipelines
+	default:
+		return Generic
+	}
+}
+
+func override(field *string, key string) {
+	if v := getenv(key); v != "" {
+		*field = v
+	}
+}
+
+func getenv(key string) string {
+	return strings.TrimSpace(os.Getenv(key))
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,134 @@
+package cienv
+
+import "testing"
+
+var knownVars = []string{
+	"CI_PROVIDER", "GITHUB_ACTIONS", "GITLAB_CI", "BITBUCKET_BUILD_NUMBER",
+	"GITHUB_REF_NAME", "GITHUB_HEAD_REF", "GITHUB_BASE_REF", "GITHUB_SHA", "GITHUB_ACTOR", "GITHUB_REPOSITORY", "GITHUB_SERVER_URL",
+	"CI_COMMIT_REF_NAME", "CI_MERGE_REQUEST_SOURCE_BRANCH_NAME", "CI_MERGE_REQUEST_TARGET_BRANCH_NAME", "CI_COMMIT_SHA",
+	"GITLAB_USER_LOGIN", "GITLAB_USER_NAME", "GITLAB_USER_EMAIL", "CI_PROJECT_PATH", "CI_SERVER_URL",
+	"BITBUCKET_BRANCH", "BITBUCKET_TAG", "BITBUCKET_PR_ID", "BITBUCKET_PR_DESTINATION_BRANCH", "BITBUCKET_COMMIT", "BITBUCKET_REPO_FULL_NAME",
+	"GITHUB_OUTPUT", "OUTPUT_FILE",
+}
+
+func setEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+	for _, key := range knownVars {
+		t.Setenv(key, env[key])
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want Env
+	}{
+		{
+			name: "github pull request",
+			env: map[string]string{
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_REF_NAME":   "42/merge",
+				"GITHUB_HEAD_REF":   "feature",
+				"GITHUB_BASE_REF":   "main",
+				"GITHUB_SHA":        "abc123",
+				"GITHUB_ACTOR":      "octocat",
+				"GITHUB_REPOSITORY": "owner/repo",
+				"GITHUB_SERVER_URL": "https://github.com",
+			},
+			want: Env{
+				Provider: GitHubActions, RefName: "42/merge", HeadRef: "feature", BaseRef: "main",
+				SHA: "abc123", Actor: "octocat", Repository: "owner/repo", ServerURL: "https://github.com",
+			},
+		},
+		{
+			name: "gitlab merge request",
+			env: map[string]string{
+				"GITLAB_CI":                           "true",
+				"CI_COMMIT_REF_NAME":                  "feature",
+				"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME": "feature",
+				"CI_MERGE_REQUEST_TARGET_BRANCH_NAME": "main",
+				"CI_COMMIT_SHA":                       "def456",
+				"GITLAB_USER_LOGIN":                   "jdoe",
+				"GITLAB_USER_NAME":                    "Jane Doe",
+				"GITLAB_USER_EMAIL":                   "jane@example.com",
+				"CI_PROJECT_PATH":                     "group/sub/project",
+				"CI_SERVER_URL":                       "https://gitlab.example.com",
+			},
+			want: Env{
+				Provider: GitLabCI, RefName: "feature", HeadRef: "feature", BaseRef: "main",
+				SHA: "def456", Actor: "jdoe", UserName: "Jane Doe", UserEmail: "jane@example.com",
+				Repository: "group/sub/project", ServerURL: "https://gitlab.example.com",
+			},
+		},
+		{
+			name: "bitbucket branch build",
+			env: map[string]string{
+				"BITBUCKET_BUILD_NUMBER":   "7",
+				"BITBUCKET_BRANCH":         "develop",
+				"BITBUCKET_COMMIT":         "789abc",
+				"BITBUCKET_REPO_FULL_NAME": "team/repo",
+			},
+			want: Env{
+				Provider: BitbucketPipelines, RefName: "develop",
+				SHA: "789abc", Repository: "team/repo", ServerURL: "https://bitbucket.org",
+			},
+		},
+		{
+			name: "bitbucket pull request",
+			env: map[string]string{
+				"BITBUCKET_BUILD_NUMBER":          "7",
+				"BITBUCKET_BRANCH":                "feature",
+				"BITBUCKET_PR_ID":                 "3",
+				"BITBUCKET_PR_DESTINATION_BRANCH": "main",
+			},
+			want: Env{Provider: BitbucketPipelines, RefName: "feature", HeadRef: "feature", BaseRef: "main"},
+		},
+		{
+			name: "bitbucket tag",
+			env:  map[string]string{"BITBUCKET_BUILD_NUMBER": "7", "BITBUCKET_TAG": "v1.2.0"},
+			want: Env{Provider: BitbucketPipelines, RefName: "v1.2.0"},
+		},
+		{
+			name: "generic",
+			env:  map[string]string{"GITHUB_REF_NAME": "main", "GITHUB_SHA": "abc", "GITHUB_ACTOR": "me"},
+			want: Env{Provider: Generic, RefName: "main", SHA: "abc", Actor: "me"},
+		},
+		{
+			name: "github names override the provider",
+			env: map[string]string{
+				"GITLAB_CI":          "true",
+				"CI_COMMIT_REF_NAME": "feature",
+				"CI_COMMIT_SHA":      "def456",
+				"GITHUB_REF_NAME":    "release",
+			},
+			want: Env{Provider: GitLabCI, RefName: "release", SHA: "def456"},
+		},
+		{
+			name: "forced provider",
+			env:  map[string]string{"CI_PROVIDER": "GitLab", "GITHUB_ACTIONS": "true", "CI_COMMIT_SHA": "def456"},
+			want: Env{Provider: GitLabCI, SHA: "def456"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, tt.env)
+
+			if got := Detect(); got != tt.want {
+				t.Fatalf("Detect() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnv_Branch(t *testing.T) {
+	t.Parallel()
+
+	if got := (Env{RefName: "42/merge", HeadRef: "feature"}).Branch(); got != "feature" {
+		t.Fatalf("Branch() = %q, want the head ref", got)
+	}
+	if got := (Env{RefName: "main"}).Branch(); got != "main" {
+		t.Fatalf("Branch() = %q, want the ref name", got)
+	}
+}
@@ -0,0 +1,7 @@
+module github.com/lokalise/lokalise-pull-action/src/cienv
+
+go 1.26
+
+toolchain go1.26.4
+
+require github.com/bodrovis/lokalise-actions-common/v2 v2.15.0
@@ -0,0 +1,54 @@
+package cienv
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/bodrovis/lokalise-actions-common/v2/githuboutput"
+)
+
+// OutputPath returns the file step outputs are appended to: GITHUB_OUTPUT on
+// GitHub Actions, OUTPUT_FILE elsewhere. The lines have the name=value form,
+// so on GitLab OUTPUT_FILE can be published as a dotenv report for later jobs.
+// It returns "" when outputs have nowhere to go.
+func OutputPath() string {
+	if Detect().Provider == GitHubActions {
+		return getenv("GITHUB_OUTPUT")
+	}
+	return getenv("OUTPUT_FILE")
+}
+
+// WriteOutput appends a single-line name=value output to OutputPath. Like
+// githuboutput.WriteToGitHubOutput, which it defers to on GitHub Actions, it
+// returns false when there is no output file or the output is malformed.
+func WriteOutput(name, value string) bool {
+	if Detect().Provider == GitHubActions {
+		return githuboutput.WriteToGitHubOutput(name, value)
+	}
+
+	path := getenv("OUTPUT_FILE")
+	if path == "" {
+		return false
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, "\r\n=") || strings.ContainsAny(value, "\r\n") {
+		slog.Warn("cannot write malformed output", "name", name)
+		return false
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		slog.Warn("cannot open OUTPUT_FILE", "path", path, "error", err)
+		return false
+	}
+	defer func() { _ = file.Close() }()
+
+	if _, err := fmt.Fprintf(file, "%s=%s\n", name, value); err != nil {
+		slog.Warn("cannot write output", "path", path, "error", err)
+		return false
+	}
+	return true
+}
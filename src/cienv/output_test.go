@@ -0,0 +1,73 @@
+package cienv
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		fileVar  string
+		wantPath bool
+	}{
+		{name: "github", env: map[string]string{"GITHUB_ACTIONS": "true"}, fileVar: "GITHUB_OUTPUT", wantPath: true},
+		{name: "github ignores OUTPUT_FILE", env: map[string]string{"GITHUB_ACTIONS": "true"}, fileVar: "OUTPUT_FILE"},
+		{name: "gitlab", env: map[string]string{"GITLAB_CI": "true"}, fileVar: "OUTPUT_FILE", wantPath: true},
+		{name: "generic", fileVar: "OUTPUT_FILE", wantPath: true},
+		{name: "generic ignores GITHUB_OUTPUT", fileVar: "GITHUB_OUTPUT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := maps.Clone(tt.env)
+			if env == nil {
+				env = map[string]string{}
+			}
+			// The runner creates GITHUB_OUTPUT before the step starts.
+			path := filepath.Join(t.TempDir(), "outputs.env")
+			if err := os.WriteFile(path, nil, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			env[tt.fileVar] = path
+			setEnv(t, env)
+
+			if got := OutputPath() != ""; got != tt.wantPath {
+				t.Fatalf("OutputPath() = %q, want set: %v", OutputPath(), tt.wantPath)
+			}
+
+			ok := WriteOutput("has_changes", "true") && WriteOutput("branch_name", "lok_abc")
+			if ok != tt.wantPath {
+				t.Fatalf("WriteOutput() = %v, want %v", ok, tt.wantPath)
+			}
+			if !tt.wantPath {
+				return
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "has_changes=true\nbranch_name=lok_abc\n"; string(data) != want {
+				t.Fatalf("unexpected output file:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestWriteOutput_RejectsMalformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outputs.env")
+	setEnv(t, map[string]string{"OUTPUT_FILE": path})
+
+	for _, tc := range [][2]string{{"", "v"}, {"a=b", "v"}, {"name", "two\nlines"}} {
+		if WriteOutput(tc[0], tc[1]) {
+			t.Errorf("WriteOutput(%q, %q) = true, want false", tc[0], tc[1])
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("malformed outputs must not create the file, stat error: %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+// Package command runs the external programs (git) the action binaries need,
+// behind small interfaces so tests can inject a fake runner.
+package command
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// Capturer runs a command and returns its output.
+type Capturer interface {
+	Capture(name string, args ...string) (string, error)
+}
+
+// Outputter runs a command and returns its stdout alone, byte for byte.
+type Outputter interface {
+	Output(name string, args ...string) ([]byte, error)
+}
+
+// Runner also runs commands whose output goes straight to the log.
+type Runner interface {
+	Capturer
+	Outputter
+	Run(name string, args ...string) error
+}
+
+// Default runs commands with os/exec.
+type Default struct{}
+
+// Run pipes stdout/stderr to the current process for visibility. Stderr is
+// also kept on the returned error (see Stderr), so a failure can be classified
+// by what the command printed.
+func (Default) Run(name string, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	if err := cmd.Run(); err != nil {
+		return &Error{Err: err, Stderr: stderr.String()}
+	}
+	return nil
+}
+
+// Capture returns combined stdout+stderr as a string, useful for parsing or error messages.
+func (Default) Capture(name string, args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	return out.String(), err
+}
+
+// Output returns stdout only, for content that must not be mixed with
+// diagnostics, such as git blobs. Stderr is kept on the returned error, as with Run.
+func (Default) Output(name string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return out, &Error{Err: err, Stderr: stderr.String()}
+	}
+	return out, nil
+}
+
+// Error is a failed Run or Output together with the stderr of the command.
+type Error struct {
+	Err    error
+	Stderr string
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+func (e *Error) Unwrap() error { return e.Err }
+
+// Stderr returns the stderr kept by Run and Output, or "" for other errors.
+func Stderr(err error) string {
+	if ce, ok := errors.AsType[*Error](err); ok {
+		return ce.Stderr
+	}
+	return ""
+}
+
+// IsExitCode checks whether err has the given exit code.
+// Supports both *exec.ExitError and any custom error type implementing ExitCode() int.
+func IsExitCode(err error, code int) bool {
+	type exitCoderError interface {
+		error
+		ExitCode() int
+	}
+
+	if ec, ok := errors.AsType[exitCoderError](err); ok {
+		return ec.ExitCode() == code
+	}
+	return false
+}
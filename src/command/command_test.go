@@ -0,0 +1,82 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+)
+
+type exitError struct{ code int }
+
+func (e *exitError) Error() string { return fmt.Sprintf("exit status %d", e.code) }
+func (e *exitError) ExitCode() int { return e.code }
+
+func TestIsExitCode(t *testing.T) {
+	t.Parallel()
+
+	wrapped := fmt.Errorf("push: %w", &Error{Err: &exitError{code: 128}})
+	if !IsExitCode(wrapped, 128) {
+		t.Fatal("expected exit code 128 through the wrapping")
+	}
+	if IsExitCode(wrapped, 1) {
+		t.Fatal("exit code 1 should not match")
+	}
+	if IsExitCode(errors.New("boom"), 1) {
+		t.Fatal("plain errors have no exit code")
+	}
+}
+
+func TestDefault_Run_KeepsStderr(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	err := Default{}.Run("sh", "-c", "echo out; echo fatal: nope >&2; exit 3")
+	if !IsExitCode(err, 3) {
+		t.Fatalf("expected exit code 3, got %v", err)
+	}
+	if got := Stderr(err); got != "fatal: nope\n" {
+		t.Fatalf("unexpected stderr %q", got)
+	}
+	if got := Stderr(fmt.Errorf("wrapped: %w", err)); got != "fatal: nope\n" {
+		t.Fatalf("stderr lost through wrapping: %q", got)
+	}
+
+	if err := (Default{}).Run("sh", "-c", "exit 0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDefault_Capture(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	out, err := Default{}.Capture("sh", "-c", "echo out; echo err >&2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "out\nerr\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
+
+func TestDefault_Output_KeepsStdoutApart(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	out, err := Default{}.Output("sh", "-c", "printf 'a\\000b'; echo warning >&2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != "a\x00b" {
+		t.Fatalf("stdout mixed or altered: %q", out)
+	}
+
+	_, err = Default{}.Output("sh", "-c", "echo fatal: bad object >&2; exit 128")
+	if !IsExitCode(err, 128) || Stderr(err) != "fatal: bad object\n" {
+		t.Fatalf("expected exit 128 with stderr, got %v (%q)", err, Stderr(err))
+	}
+}
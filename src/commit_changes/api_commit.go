@@ -0,0 +1,362 @@
+package commitchanges
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lokalise/lokalise-pull-action/src/command"
+	"github.com/lokalise/lokalise-pull-action/src/errkind"
+)
+
+// gitHubAPIError is a non-2xx response of the GitHub REST API.
+type gitHubAPIError struct {
+	Status  int
+	Message string
+}
+
+func (e *gitHubAPIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("GitHub API error: HTTP %d", e.Status)
+	}
+	return fmt.Sprintf("GitHub API error: HTTP %d: %s", e.Status, e.Message)
+}
+
+// apiErrorKind classifies a failed API call like a failed push: missing
+// permissions are auth errors, everything else an API error.
+func apiErrorKind(err error) errkind.Kind {
+	apiErr, ok := errors.AsType[*gitHubAPIError](err)
+	if !ok {
+		return errkind.API
+	}
+	switch apiErr.Status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return errkind.Auth
+	case http.StatusTooManyRequests:
+		return errkind.RateLimited
+	default:
+		return errkind.API
+	}
+}
+
+func isAPIStatus(err error, status int) bool {
+	apiErr, ok := errors.AsType[*gitHubAPIError](err)
+	return ok && apiErr.Status == status
+}
+
+// gitHubClient talks to the Git Data API of one repository.
+type gitHubClient struct {
+	http    *http.Client
+	repoURL string // {api}/repos/{owner}/{repo}
+	token   string
+}
+
+func newGitHubClient(config *Config) *gitHubClient {
+	return &gitHubClient{
+		http:    &http.Client{Timeout: 30 * time.Second},
+		repoURL: config.GitHubAPIURL + "/repos/" + config.GitHubRepository,
+		token:   config.GitHubToken,
+	}
+}
+
+// do sends in as JSON to the repository endpoint and decodes the response into out.
+func (c *gitHubClient) do(ctx context.Context, method, endpoint string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.repoURL+endpoint, body)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var msg struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(data, &msg)
+		return &gitHubAPIError{Status: resp.StatusCode, Message: msg.Message}
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// treeEntry is an entry of POST /git/trees. A nil SHA deletes the path.
+type treeEntry struct {
+	Path string  `json:"path"`
+	Mode string  `json:"mode"`
+	Type string  `json:"type"`
+	SHA  *string `json:"sha"`
+}
+
+type apiCommit struct {
+	SHA          string `json:"sha"`
+	Verification struct {
+		Verified bool   `json:"verified"`
+		Reason   string `json:"reason"`
+	} `json:"verification"`
+}
+
+func (c *gitHubClient) createBlob(ctx context.Context, content []byte) (string, error) {
+	var blob struct {
+		SHA string `json:"sha"`
+	}
+	in := map[string]string{
+		"content":  base64.StdEncoding.EncodeToString(content),
+		"encoding": "base64",
+	}
+	if err := c.do(ctx, http.MethodPost, "/git/blobs", in, &blob); err != nil {
+		return "", err
+	}
+	return blob.SHA, nil
+}
+
+func (c *gitHubClient) createTree(ctx context.Context, baseTree string, entries []treeEntry) (string, error) {
+	var tree struct {
+		SHA string `json:"sha"`
+	}
+	in := map[string]any{"base_tree": baseTree, "tree": entries}
+	if err := c.do(ctx, http.MethodPost, "/git/trees", in, &tree); err != nil {
+		return "", err
+	}
+	return tree.SHA, nil
+}
+
+// createCommit leaves out author and committer: GitHub only signs commits it
+// authors itself, on behalf of the token owner.
+func (c *gitHubClient) createCommit(ctx context.Context, message, tree, parent string) (apiCommit, error) {
+	var commit apiCommit
+	in := map[string]any{"message": message, "tree": tree, "parents": []string{parent}}
+	if err := c.do(ctx, http.MethodPost, "/git/commits", in, &commit); err != nil {
+		return apiCommit{}, err
+	}
+	return commit, nil
+}
+
+// updateBranch points the branch at sha, creating it when it does not exist.
+// Without force GitHub only accepts a fast-forward, like a plain push.
+func (c *gitHubClient) updateBranch(ctx context.Context, branch, sha string, force bool) error {
+	err := c.do(ctx, http.MethodGet, "/git/ref/heads/"+escapeRefPath(branch), nil, nil)
+	if isAPIStatus(err, http.StatusNotFound) {
+		in := map[string]string{"ref": "refs/heads/" + branch, "sha": sha}
+		return c.do(ctx, http.MethodPost, "/git/refs", in, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	in := map[string]any{"sha": sha, "force": force}
+	return c.do(ctx, http.MethodPatch, "/git/refs/heads/"+escapeRefPath(branch), in, nil)
+}
+
+// escapeRefPath escapes each segment of a branch name, keeping the slashes
+// the API expects between them.
+func escapeRefPath(ref string) string {
+	segments := strings.Split(ref, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// stagedChange is a path of the index that differs from HEAD.
+type stagedChange struct {
+	Path    string
+	Mode    string
+	SHA     string // blob SHA in the index
+	Deleted bool
+}
+
+// readStagedChanges lists the staged changes with `git diff --cached --raw -z`:
+// ":<old mode> <new mode> <old sha> <new sha> <status>\0<path>\0" per path.
+func readStagedChanges(runner command.Runner) ([]stagedChange, error) {
+	out, err := runner.Capture("git", "diff", "--cached", "--raw", "-z", "--no-renames", "--no-abbrev")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list staged changes: %w\nOutput: %s", err, out)
+	}
+	return parseRawDiff(out)
+}
+
+func parseRawDiff(out string) ([]stagedChange, error) {
+	fields := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	if len(fields) == 1 && fields[0] == "" {
+		return nil, nil
+	}
+	if len(fields)%2 != 0 {
+		return nil, fmt.Errorf("unexpected raw diff output %q", out)
+	}
+
+	var changes []stagedChange
+	for i := 0; i < len(fields); i += 2 {
+		meta := strings.Fields(strings.TrimPrefix(fields[i], ":"))
+		if len(meta) != 5 || fields[i+1] == "" {
+			return nil, fmt.Errorf("unexpected raw diff entry %q", fields[i])
+		}
+		changes = append(changes, stagedChange{
+			Path:    fields[i+1],
+			Mode:    meta[1],
+			SHA:     meta[3],
+			Deleted: meta[4] == "D",
+		})
+	}
+	return changes, nil
+}
+
+// commitViaAPI turns the staged changes into a commit made by the GitHub Git
+// Data API: it uploads the changed blobs, builds a tree on top of HEAD, creates
+// the commit and moves the branch to it. GitHub signs such commits, so they
+// show up as verified without any key in the workflow. HEAD must already exist
+// on GitHub, which checkoutBranch ensures by basing the branch on a remote ref.
+// Afterwards the local branch is moved to the new commit so the stats and
+// outputs describe it; when that fails, stats.LocalBehind is set.
+func commitViaAPI(branchName string, runner command.Runner, config *Config, client *gitHubClient) (commitStats, error) {
+	ctx := context.Background()
+
+	out, err := runner.Capture("git", "rev-parse", "HEAD", "HEAD^{tree}")
+	heads := splitNonEmptyLines(out)
+	if err != nil || len(heads) != 2 {
+		return commitStats{}, fmt.Errorf("failed to resolve HEAD: %v\nOutput: %s", err, out)
+	}
+	parent, baseTree := heads[0], heads[1]
+
+	changes, err := readStagedChanges(runner)
+	if err != nil {
+		return commitStats{}, err
+	}
+	if len(changes) == 0 {
+		return commitStats{}, ErrNoChanges
+	}
+
+	entries, err := uploadChanges(ctx, runner, client, changes)
+	if err != nil {
+		return commitStats{}, err
+	}
+
+	tree, err := client.createTree(ctx, baseTree, entries)
+	if err != nil {
+		return commitStats{}, errkind.With(apiErrorKind(err), fmt.Errorf("failed to create tree: %w", err))
+	}
+
+	commit, err := client.createCommit(ctx, commitMessage(config), tree, parent)
+	if err != nil {
+		return commitStats{}, errkind.With(apiErrorKind(err), fmt.Errorf("failed to create commit: %w", err))
+	}
+	if commit.Verification.Verified {
+		slog.Info("Created a verified commit through the GitHub API", "sha", commit.SHA)
+	} else {
+		slog.Warn("GitHub did not mark the commit as verified", "sha", commit.SHA, "reason", commit.Verification.Reason)
+	}
+
+	if err := client.updateBranch(ctx, branchName, commit.SHA, config.ForcePush); err != nil {
+		kind := apiErrorKind(err)
+		if isAPIStatus(err, http.StatusUnprocessableEntity) {
+			kind = errkind.PushRejected
+		}
+		return commitStats{SHA: commit.SHA, ParentSHA: parent},
+			errkind.With(kind, fmt.Errorf("failed to update branch %q: %w", branchName, err))
+	}
+
+	if err := syncLocalBranch(runner, config, branchName, commit.SHA); err != nil {
+		// The commit and the branch on GitHub are fine, so the pull request can
+		// still be opened; later steps learn from local_branch_synced=false
+		// that the checkout is still on the parent.
+		slog.Warn("the commit was created, but the local branch could not be updated", "error", err)
+		stats := apiCommitStats(commit.SHA, parent, changes)
+		stats.LocalBehind = true
+		return stats, nil
+	}
+	return headCommitStats(runner), nil
+}
+
+// uploadChanges creates a blob per added or modified file and returns the
+// tree entries. Submodules are referenced by their commit.
+func uploadChanges(ctx context.Context, runner command.Runner, client *gitHubClient, changes []stagedChange) ([]treeEntry, error) {
+	entries := make([]treeEntry, 0, len(changes))
+
+	for _, change := range changes {
+		entry := treeEntry{Path: change.Path, Mode: change.Mode, Type: "blob"}
+
+		switch {
+		case change.Deleted:
+			entry.Mode = "100644"
+		case change.Mode == "160000":
+			entry.Type = "commit"
+			entry.SHA = &change.SHA
+		default:
+			// Output keeps stderr out of the blob and returns its bytes untouched.
+			content, err := runner.Output("git", "cat-file", "blob", change.SHA)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read staged %s: %w\nOutput: %s", change.Path, err, command.Stderr(err))
+			}
+			sha, err := client.createBlob(ctx, content)
+			if err != nil {
+				return nil, errkind.With(apiErrorKind(err), fmt.Errorf("failed to upload %s: %w", change.Path, err))
+			}
+			entry.SHA = &sha
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// syncLocalBranch fetches the new commit and moves the branch to it. The
+// index already holds its tree, so a soft reset leaves a clean worktree.
+func syncLocalBranch(runner command.Runner, config *Config, branchName, sha string) error {
+	rem := newRemotes(config)
+	if err := fetchRemoteBranch(runner, rem, rem.fetch, rem.fetch, branchName); err != nil {
+		return err
+	}
+	if out, err := runner.Capture("git", "reset", "--soft", sha); err != nil {
+		return fmt.Errorf("git reset to %s failed: %w\nOutput: %s", sha, err, out)
+	}
+	return nil
+}
+
+// apiCommitStats describes the commit from the staged changes when it could
+// not be fetched back; line counts are unknown then.
+func apiCommitStats(sha, parent string, changes []stagedChange) commitStats {
+	stats := commitStats{SHA: sha, ParentSHA: parent}
+	for _, change := range changes {
+		stats.Files = append(stats.Files, change.Path)
+	}
+	return stats
+}
@@ -0,0 +1,291 @@
+package commitchanges
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lokalise/lokalise-pull-action/src/errkind"
+)
+
+const (
+	parentSHA = "1111111111111111111111111111111111111111"
+	baseTree  = "2222222222222222222222222222222222222222"
+	frBlob    = "3333333333333333333333333333333333333333"
+	newSHA    = "4444444444444444444444444444444444444444"
+)
+
+// fakeGitData records the Git Data API calls and answers them. refStatus is
+// returned for GET of the branch ref, patchStatus for its update.
+type fakeGitData struct {
+	mu          sync.Mutex
+	calls       []string
+	bodies      map[string]map[string]any
+	refStatus   int
+	patchStatus int
+	verified    bool
+}
+
+func (f *fakeGitData) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		if got := r.Header.Get("Authorization"); got != "Bearer ghtok" {
+			t.Errorf("Authorization = %q", got)
+		}
+
+		call := r.Method + " " + strings.TrimPrefix(r.URL.EscapedPath(), "/repos/acme/app")
+		f.calls = append(f.calls, call)
+
+		if r.Body != nil {
+			data, _ := io.ReadAll(r.Body)
+			if len(data) > 0 {
+				var body map[string]any
+				if err := json.Unmarshal(data, &body); err != nil {
+					t.Errorf("%s: invalid JSON: %v", call, err)
+				}
+				f.bodies[call] = body
+			}
+		}
+
+		switch call {
+		case "POST /git/blobs":
+			_, _ = io.WriteString(w, `{"sha":"`+frBlob+`"}`)
+		case "POST /git/trees":
+			_, _ = io.WriteString(w, `{"sha":"5555555555555555555555555555555555555555"}`)
+		case "POST /git/commits":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"sha":          newSHA,
+				"verification": map[string]any{"verified": f.verified, "reason": "valid"},
+			})
+		case "GET /git/ref/heads/lok/main":
+			w.WriteHeader(f.refStatus)
+			_, _ = io.WriteString(w, `{"message":"Not Found"}`)
+		case "PATCH /git/refs/heads/lok/main":
+			w.WriteHeader(f.patchStatus)
+			_, _ = io.WriteString(w, `{"message":"Update is not a fast forward"}`)
+		case "POST /git/refs":
+			w.WriteHeader(http.StatusCreated)
+		default:
+			t.Errorf("unexpected call %s", call)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+}
+
+func newFakeGitData(t *testing.T, refStatus, patchStatus int) (*fakeGitData, *Config) {
+	t.Helper()
+
+	fake := &fakeGitData{bodies: map[string]map[string]any{}, refStatus: refStatus, patchStatus: patchStatus, verified: true}
+	srv := httptest.NewServer(fake.handler(t))
+	t.Cleanup(srv.Close)
+
+	return fake, &Config{
+		GitCommitMessage: "Translations update",
+		CommitBackend:    commitBackendAPI,
+		GitHubToken:      "ghtok",
+		GitHubRepository: "acme/app",
+		GitHubAPIURL:     srv.URL,
+	}
+}
+
+// apiGitRunner answers the git commands of commitViaAPI: fr.json is modified
+// and de.json deleted in the index.
+func apiGitRunner(calls *[]string) *MockCommandRunner {
+	return &MockCommandRunner{
+		CaptureFunc: func(name string, args ...string) (string, error) {
+			*calls = append(*calls, strings.Join(args, " "))
+			cmd := args[0]
+			if cmd == "-c" {
+				cmd = args[2]
+			}
+			switch cmd {
+			case "rev-parse":
+				return parentSHA + "\n" + baseTree + "\n", nil
+			case "diff":
+				return ":100644 100644 aaaa " + frBlob + " M\x00locales/fr.json\x00" +
+					":100644 000000 bbbb 0000000000000000000000000000000000000000 D\x00locales/de.json\x00", nil
+			case "show":
+				return newSHA + " " + parentSHA + "\n1\t1\tlocales/fr.json\n0\t3\tlocales/de.json\n", nil
+			default:
+				return "", nil
+			}
+		},
+		OutputFunc: func(name string, args ...string) ([]byte, error) {
+			*calls = append(*calls, strings.Join(args, " "))
+			if args[0] == "cat-file" {
+				return []byte(`{"hello":"bonjour"}`), nil
+			}
+			return nil, fmt.Errorf("unexpected output call: %v", args)
+		},
+	}
+}
+
+func TestCommitViaAPI_UpdatesExistingBranch(t *testing.T) {
+	fake, config := newFakeGitData(t, http.StatusOK, http.StatusOK)
+	config.ForcePush = true
+
+	var gitCalls []string
+	stats, err := commitViaAPI("lok/main", apiGitRunner(&gitCalls), config, newGitHubClient(config))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantCalls := []string{
+		"POST /git/blobs",
+		"POST /git/trees",
+		"POST /git/commits",
+		"GET /git/ref/heads/lok/main",
+		"PATCH /git/refs/heads/lok/main",
+	}
+	if !slices.Equal(fake.calls, wantCalls) {
+		t.Fatalf("API calls mismatch:\ngot  %q\nwant %q", fake.calls, wantCalls)
+	}
+
+	blob := fake.bodies["POST /git/blobs"]
+	if content, _ := base64.StdEncoding.DecodeString(blob["content"].(string)); string(content) != `{"hello":"bonjour"}` {
+		t.Fatalf("unexpected blob content %q", content)
+	}
+
+	tree := fake.bodies["POST /git/trees"]
+	if tree["base_tree"] != baseTree {
+		t.Fatalf("base_tree = %v", tree["base_tree"])
+	}
+	entries := tree["tree"].([]any)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 tree entries, got %v", entries)
+	}
+	if fr := entries[0].(map[string]any); fr["path"] != "locales/fr.json" || fr["sha"] != frBlob || fr["mode"] != "100644" {
+		t.Fatalf("unexpected entry %v", fr)
+	}
+	if de := entries[1].(map[string]any); de["path"] != "locales/de.json" || de["sha"] != nil {
+		t.Fatalf("deleted file must have a null sha: %v", de)
+	}
+
+	commit := fake.bodies["POST /git/commits"]
+	if _, hasAuthor := commit["author"]; hasAuthor {
+		t.Fatal("author must be left to GitHub so the commit is signed")
+	}
+	if parents := commit["parents"].([]any); len(parents) != 1 || parents[0] != parentSHA {
+		t.Fatalf("unexpected parents %v", parents)
+	}
+
+	if patch := fake.bodies["PATCH /git/refs/heads/lok/main"]; patch["sha"] != newSHA || patch["force"] != true {
+		t.Fatalf("unexpected ref update %v", patch)
+	}
+
+	if !slices.Contains(gitCalls, "fetch --no-tags --prune origin +refs/heads/lok/main:refs/remotes/origin/lok/main") ||
+		!slices.Contains(gitCalls, "reset --soft "+newSHA) || stats.LocalBehind {
+		t.Fatalf("local branch not synced: %q", gitCalls)
+	}
+	if stats.SHA != newSHA || stats.ParentSHA != parentSHA || stats.Deletions != 4 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestCommitViaAPI_CreatesBranch(t *testing.T) {
+	fake, config := newFakeGitData(t, http.StatusNotFound, http.StatusOK)
+
+	var gitCalls []string
+	if _, err := commitViaAPI("lok/main", apiGitRunner(&gitCalls), config, newGitHubClient(config)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if slices.Contains(fake.calls, "PATCH /git/refs/heads/lok/main") {
+		t.Fatal("a missing branch must be created, not updated")
+	}
+	if ref := fake.bodies["POST /git/refs"]; ref["ref"] != "refs/heads/lok/main" || ref["sha"] != newSHA {
+		t.Fatalf("unexpected ref creation %v", ref)
+	}
+}
+
+func TestCommitViaAPI_RejectedUpdate(t *testing.T) {
+	_, config := newFakeGitData(t, http.StatusOK, http.StatusUnprocessableEntity)
+
+	var gitCalls []string
+	stats, err := commitViaAPI("lok/main", apiGitRunner(&gitCalls), config, newGitHubClient(config))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if got := errkind.Of(err); got != errkind.PushRejected {
+		t.Fatalf("error kind = %q, want push_rejected", got)
+	}
+	if stats.SHA != newSHA {
+		t.Fatalf("the created commit should still be reported, got %+v", stats)
+	}
+}
+
+func TestCommitViaAPI_LocalSyncFailureKeepsCommit(t *testing.T) {
+	_, config := newFakeGitData(t, http.StatusOK, http.StatusOK)
+
+	var gitCalls []string
+	runner := apiGitRunner(&gitCalls)
+	capture := runner.CaptureFunc
+	runner.CaptureFunc = func(name string, args ...string) (string, error) {
+		if args[0] == "fetch" {
+			return "fatal: unable to access", errors.New("exit status 128")
+		}
+		return capture(name, args...)
+	}
+
+	stats, err := commitViaAPI("lok/main", runner, config, newGitHubClient(config))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.SHA != newSHA || !slices.Equal(stats.Files, []string{"locales/fr.json", "locales/de.json"}) || !stats.LocalBehind {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestAPIErrorKind(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err  error
+		want errkind.Kind
+	}{
+		{&gitHubAPIError{Status: http.StatusUnauthorized}, errkind.Auth},
+		{&gitHubAPIError{Status: http.StatusForbidden, Message: "Resource not accessible by integration"}, errkind.Auth},
+		{&gitHubAPIError{Status: http.StatusTooManyRequests}, errkind.RateLimited},
+		{&gitHubAPIError{Status: http.StatusBadGateway}, errkind.API},
+		{errors.New("connection refused"), errkind.API},
+	}
+
+	for _, tt := range tests {
+		if got := apiErrorKind(tt.err); got != tt.want {
+			t.Errorf("apiErrorKind(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestParseRawDiff(t *testing.T) {
+	t.Parallel()
+
+	changes, err := parseRawDiff(":000000 100755 0000 abcd A\x00bin/tool\x00:160000 160000 1111 2222 M\x00vendor/sub\x00")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []stagedChange{
+		{Path: "bin/tool", Mode: "100755", SHA: "abcd"},
+		{Path: "vendor/sub", Mode: "160000", SHA: "2222"},
+	}
+	if !slices.Equal(changes, want) {
+		t.Fatalf("got %+v, want %+v", changes, want)
+	}
+
+	if changes, err := parseRawDiff(""); err != nil || changes != nil {
+		t.Fatalf("empty output: %v, %v", changes, err)
+	}
+	if _, err := parseRawDiff(":100644 100644 a b M\x00"); err == nil {
+		t.Fatal("expected an error for a truncated entry")
+	}
+}
@@ -1,9 +1,11 @@
-package main
+package commitchanges
 
 import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/lokalise/lokalise-pull-action/src/command"
 )
 
 // generateBranchName returns either the override branch (validated) or a temp branch
@@ -13,7 +15,7 @@ import (
 //     Instead we validate using `git check-ref-format --branch`.
 //   - For auto-generated names, we sanitize to keep them safe and predictable.
 //   - Length is capped to 255 to satisfy git ref constraints.
-func generateBranchName(config *Config, runner CommandRunner) (string, error) {
+func generateBranchName(config *Config, runner command.Runner) (string, error) {
 	if override, ok, err := resolveOverrideBranchName(config, runner); ok || err != nil {
 		return override, err
 	}
@@ -30,7 +32,7 @@ func generateBranchName(config *Config, runner CommandRunner) (string, error) {
 	return branchName, nil
 }
 
-func resolveOverrideBranchName(config *Config, runner CommandRunner) (string, bool, error) {
+func resolveOverrideBranchName(config *Config, runner command.Runner) (string, bool, error) {
 	if config.OverrideBranchName == "" {
 		return "", false, nil
 	}
@@ -50,7 +52,7 @@ func resolveOverrideBranchName(config *Config, runner CommandRunner) (string, bo
 func buildGeneratedBranchName(config *Config) (string, error) {
 	timestamp := time.Now().Unix()
 
-	shortSHA, err := shortGitHubSHA(config.GitHubSHA)
+	shortSHA, err := shortSHA(config.SHA)
 	if err != nil {
 		return "", err
 	}
@@ -76,7 +78,7 @@ func buildGeneratedBranchName(config *Config) (string, error) {
 	return branchName, nil
 }
 
-func shortGitHubSHA(sha string) (string, error) {
+func shortSHA(sha string) (string, error) {
 	sha = strings.TrimSpace(sha)
 
 	if len(sha) < 6 {
@@ -86,7 +88,7 @@ func shortGitHubSHA(sha string) (string, error) {
 	return sha[:6], nil
 }
 
-func validateBranchName(name string, runner CommandRunner) error {
+func validateBranchName(name string, runner command.Runner) error {
 	out, err := runner.Capture("git", "check-ref-format", "--branch", name)
 	if err != nil {
 		// `check-ref-format` usually prints why it failed; keep it for debugging.
@@ -99,7 +101,7 @@ func validateBranchName(name string, runner CommandRunner) error {
 	return nil
 }
 
-func generateBranchNameForBase(config *Config, realBase string, runner CommandRunner) (string, error) {
+func generateBranchNameForBase(config *Config, realBase string, runner command.Runner) (string, error) {
 	cfgForName := *config
 	cfgForName.BaseRef = realBase
 	return generateBranchName(&cfgForName, runner)
@@ -1,4 +1,4 @@
-package main
+package commitchanges
 
 import (
 	"errors"
@@ -18,7 +18,7 @@ func TestGenerateBranchName(t *testing.T) {
 		{
 			name: "Valid inputs",
 			config: &Config{
-				GitHubSHA:        "1234567890abcdef",
+				SHA:              "1234567890abcdef",
 				BaseRef:          "feature_branch",
 				TempBranchPrefix: "temp",
 			},
@@ -30,7 +30,7 @@ func TestGenerateBranchName(t *testing.T) {
 		{
 			name: "Override branch name is empty after trimming",
 			config: &Config{
-				GitHubSHA:          "1234567890abcdef",
+				SHA:                "1234567890abcdef",
 				BaseRef:            "main",
 				TempBranchPrefix:   "temp",
 				OverrideBranchName: "   \t   ",
@@ -41,7 +41,7 @@ func TestGenerateBranchName(t *testing.T) {
 		{
 			name: "Blank temp branch prefix falls back to lok",
 			config: &Config{
-				GitHubSHA:        "1234567890abcdef",
+				SHA:              "1234567890abcdef",
 				BaseRef:          "main",
 				TempBranchPrefix: "   ",
 			},
@@ -53,7 +53,7 @@ func TestGenerateBranchName(t *testing.T) {
 		{
 			name: "Sanitized empty base ref falls back to base",
 			config: &Config{
-				GitHubSHA:        "abcdef123456",
+				SHA:              "abcdef123456",
 				BaseRef:          "!@#",
 				TempBranchPrefix: "temp",
 			},
@@ -65,7 +65,7 @@ func TestGenerateBranchName(t *testing.T) {
 		{
 			name: "Valid inputs with branch override (simple)",
 			config: &Config{
-				GitHubSHA:          "1234567890abcdef",
+				SHA:                "1234567890abcdef",
 				BaseRef:            "feature_branch",
 				TempBranchPrefix:   "temp",
 				OverrideBranchName: "custom_branch",
@@ -78,7 +78,7 @@ func TestGenerateBranchName(t *testing.T) {
 		{
 			name: "Valid inputs with branch override (keeps + and other valid chars)",
 			config: &Config{
-				GitHubSHA:          "1234567890abcdef",
+				SHA:                "1234567890abcdef",
 				BaseRef:            "feature_branch",
 				TempBranchPrefix:   "temp",
 				OverrideBranchName: "feature/foo+bar",
@@ -91,7 +91,7 @@ func TestGenerateBranchName(t *testing.T) {
 		{
 			name: "Invalid override branch (space) should fail validation",
 			config: &Config{
-				GitHubSHA:          "1234567890abcdef",
+				SHA:                "1234567890abcdef",
 				BaseRef:            "feature_branch",
 				TempBranchPrefix:   "temp",
 				OverrideBranchName: "bad branch",
@@ -102,7 +102,7 @@ func TestGenerateBranchName(t *testing.T) {
 		{
 			name: "GITHUB_SHA too short",
 			config: &Config{
-				GitHubSHA:        "123",
+				SHA:              "123",
 				BaseRef:          "main",
 				TempBranchPrefix: "temp",
 			},
@@ -112,7 +112,7 @@ func TestGenerateBranchName(t *testing.T) {
 		{
 			name: "BASE_REF with invalid characters (sanitized in generated name)",
 			config: &Config{
-				GitHubSHA:        "abcdef123456",
+				SHA:              "abcdef123456",
 				BaseRef:          "feature/branch!@#",
 				TempBranchPrefix: "temp",
 			},
@@ -124,7 +124,7 @@ func TestGenerateBranchName(t *testing.T) {
 		{
 			name: "BASE_REF exceeding 50 characters",
 			config: &Config{
-				GitHubSHA:        "abcdef123456",
+				SHA:              "abcdef123456",
 				BaseRef:          strings.Repeat("a", 60),
 				TempBranchPrefix: "temp",
 			},
@@ -1,54 +1,59 @@
-package main
+package commitchanges
 
 import (
 	"fmt"
-	"os"
+	"log/slog"
 	"strings"
+
+	"github.com/lokalise/lokalise-pull-action/src/command"
 )
 
 // checkoutBranch bases the working branch off either the PR head (when updating an existing PR)
 // or the base branch. We fetch the exact remote ref to work with shallow clones reliably.
-func checkoutBranch(branchName, baseRef, headRef string, runner CommandRunner) error {
-	remoteExists, err := hasRemote(runner, branchName)
+// An existing branch is looked up where it is pushed to; PR heads and base branches come from rem.fetch.
+func checkoutBranch(branchName, baseRef, headRef string, rem remotes, runner command.Runner) error {
+	remoteExists, err := hasRemote(runner, rem, branchName)
 	if err != nil {
 		return err
 	}
 
 	if remoteExists {
-		return checkoutExistingRemoteBranch(branchName, runner)
+		return checkoutExistingRemoteBranch(branchName, rem, runner)
 	}
 
 	if shouldCheckoutPRHead(branchName, headRef) {
-		return checkoutPRHeadBranch(branchName, headRef, runner)
+		return checkoutPRHeadBranch(branchName, headRef, rem, runner)
 	}
 
-	return checkoutFromBaseBranch(branchName, baseRef, runner)
+	return checkoutFromBaseBranch(branchName, baseRef, rem, runner)
 }
 
 func shouldCheckoutPRHead(branchName, headRef string) bool {
 	return headRef != "" && branchName == headRef
 }
 
-func checkoutExistingRemoteBranch(branchName string, runner CommandRunner) error {
-	if err := fetchRemoteBranch(runner, branchName); err != nil {
+func checkoutExistingRemoteBranch(branchName string, rem remotes, runner command.Runner) error {
+	if err := fetchRemoteBranch(runner, rem, rem.push, rem.tracking, branchName); err != nil {
 		return err
 	}
 
-	if err := checkoutRemoteTrackingBranch(branchName, branchName, runner); err != nil {
+	if err := checkoutRemoteTrackingBranch(branchName, rem.tracking+"/"+branchName, runner); err != nil {
 		return err
 	}
 
-	setBranchUpstream(runner, branchName, branchName)
+	if rem.pushesToNamedRemote() {
+		setBranchUpstream(runner, branchName, rem.tracking+"/"+branchName)
+	}
 	return nil
 }
 
-func checkoutPRHeadBranch(branchName, headRef string, runner CommandRunner) error {
-	if err := fetchRemoteBranch(runner, headRef); err != nil {
+func checkoutPRHeadBranch(branchName, headRef string, rem remotes, runner command.Runner) error {
+	if err := fetchRemoteBranch(runner, rem, rem.fetch, rem.fetch, headRef); err != nil {
 		return err
 	}
 
-	if err := checkoutRemoteTrackingBranch(branchName, headRef, runner); err == nil {
-		setBranchUpstream(runner, branchName, headRef)
+	if err := checkoutRemoteTrackingBranch(branchName, rem.fetch+"/"+headRef, runner); err == nil {
+		setBranchUpstream(runner, branchName, rem.fetch+"/"+headRef)
 		return nil
 	}
 
@@ -59,17 +64,17 @@ func checkoutPRHeadBranch(branchName, headRef string, runner CommandRunner) erro
 	return runner.Run("git", "checkout", branchName)
 }
 
-func checkoutFromBaseBranch(branchName, baseRef string, runner CommandRunner) error {
-	if err := fetchRemoteBranch(runner, baseRef); err != nil {
+func checkoutFromBaseBranch(branchName, baseRef string, rem remotes, runner command.Runner) error {
+	if err := fetchRemoteBranch(runner, rem, rem.fetch, rem.fetch, baseRef); err != nil {
 		return err
 	}
 
-	if err := checkoutRemoteTrackingBranch(branchName, baseRef, runner); err == nil {
+	if err := checkoutRemoteTrackingBranch(branchName, rem.fetch+"/"+baseRef, runner); err == nil {
 		unsetBranchUpstream(runner, branchName)
 		return nil
 	}
 
-	logMissingFetchedRemoteRef(runner, baseRef)
+	logMissingFetchedRemoteRef(runner, rem.fetch+"/"+baseRef)
 
 	if err := runner.Run("git", "checkout", "-B", branchName, baseRef); err == nil {
 		return nil
@@ -77,56 +82,57 @@ func checkoutFromBaseBranch(branchName, baseRef string, runner CommandRunner) er
 	return runner.Run("git", "checkout", branchName)
 }
 
-func fetchRemoteBranch(runner CommandRunner, ref string) error {
+// fetchRemoteBranch fetches ref from source (a remote name or URL) into
+// refs/remotes/<tracking>/<ref>.
+func fetchRemoteBranch(runner command.Runner, rem remotes, source, tracking, ref string) error {
 	// "+A:B" syntax forces update of the local remote-tracking ref.
-	spec := fmt.Sprintf("+refs/heads/%[1]s:refs/remotes/origin/%[1]s", ref)
-	out, err := runner.Capture("git", "fetch", "--no-tags", "--prune", "origin", spec)
+	spec := fmt.Sprintf("+refs/heads/%[1]s:refs/remotes/%[2]s/%[1]s", ref, tracking)
+	out, err := runner.Capture("git", "fetch", "--no-tags", "--prune", source, spec)
 	if err != nil {
 		return fmt.Errorf("git fetch failed for %q (spec=%q): %w\nOutput: %s", ref, spec, err, strings.TrimSpace(out))
 	}
 	return nil
 }
 
-func checkoutRemoteTrackingBranch(branchName, remoteRef string, runner CommandRunner) error {
-	remote := "origin/" + remoteRef
-
-	if err := runner.Run("git", "checkout", "-B", branchName, remote); err == nil {
+// checkoutRemoteTrackingBranch points branchName at trackingRef, e.g. "origin/main".
+func checkoutRemoteTrackingBranch(branchName, trackingRef string, runner command.Runner) error {
+	if err := runner.Run("git", "checkout", "-B", branchName, trackingRef); err == nil {
 		return nil
 	} else {
-		if err2 := checkoutRemoteWithLocalChanges(branchName, remoteRef, runner, err); err2 != nil {
+		if err2 := checkoutRemoteWithLocalChanges(branchName, trackingRef, runner, err); err2 != nil {
 			return err2
 		}
 		return nil
 	}
 }
 
-func setBranchUpstream(runner CommandRunner, branchName, remoteRef string) {
-	if err := runner.Run("git", "branch", "--set-upstream-to=origin/"+remoteRef, branchName); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to set upstream for %q to origin/%s: %v\n", branchName, remoteRef, err)
+func setBranchUpstream(runner command.Runner, branchName, trackingRef string) {
+	if err := runner.Run("git", "branch", "--set-upstream-to="+trackingRef, branchName); err != nil {
+		slog.Warn("failed to set upstream", "branch", branchName, "upstream", trackingRef, "error", err)
 	}
 }
 
-func unsetBranchUpstream(runner CommandRunner, branchName string) {
+func unsetBranchUpstream(runner command.Runner, branchName string) {
 	if err := runner.Run("git", "branch", "--unset-upstream", branchName); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to unset upstream for %q: %v\n", branchName, err)
+		slog.Warn("failed to unset upstream", "branch", branchName, "error", err)
 	}
 }
 
-func logMissingFetchedRemoteRef(runner CommandRunner, baseRef string) {
-	_, refCheckErr := runner.Capture("git", "show-ref", "--verify", "--quiet", "refs/remotes/origin/"+baseRef)
+func logMissingFetchedRemoteRef(runner command.Runner, trackingRef string) {
+	_, refCheckErr := runner.Capture("git", "show-ref", "--verify", "--quiet", "refs/remotes/"+trackingRef)
 	if refCheckErr != nil {
-		fmt.Fprintf(os.Stderr, "Warning: origin/%s not found locally after fetch (show-ref failed): %v\n", baseRef, refCheckErr)
+		slog.Warn("remote ref not found locally after fetch", "ref", trackingRef, "error", refCheckErr)
 	}
 }
 
-// checkoutRemoteWithLocalChanges tries to switch to origin/<remoteRef> even when local changes block checkout.
-// Strategy: If local working tree already matches origin/<remoteRef>, force-checkout is safe.
+// checkoutRemoteWithLocalChanges tries to switch to the remote-tracking ref (e.g. origin/<ref>)
+// even when local changes block checkout.
+// Strategy: If local working tree already matches the remote ref, force-checkout is safe.
 // Otherwise:
 //
-//	stash -> checkout origin/<remoteRef> -> restore stashed files by overwriting them
+//	stash -> checkout <remote>/<ref> -> restore stashed files by overwriting them
 //	(checkout stash@{0} -- <file>, fallback to stash@{0}^3 for untracked) -> reset -> drop stash.
-func checkoutRemoteWithLocalChanges(branchName, remoteRef string, runner CommandRunner, cause error) error {
-	remote := "origin/" + remoteRef
+func checkoutRemoteWithLocalChanges(branchName, remote string, runner command.Runner, cause error) error {
 
 	status, hasUntracked, err := readWorktreeStatus(runner)
 	if err != nil {
@@ -173,15 +179,16 @@ func checkoutRemoteWithLocalChanges(branchName, remoteRef string, runner Command
 	return nil
 }
 
-func hasRemote(runner CommandRunner, ref string) (bool, error) {
-	out, err := runner.Capture("git", "ls-remote", "--exit-code", "--heads", "origin", ref)
+// hasRemote reports whether ref exists where the branch is pushed to.
+func hasRemote(runner command.Runner, rem remotes, ref string) (bool, error) {
+	out, err := runner.Capture("git", "ls-remote", "--exit-code", "--heads", rem.push, ref)
 	if err == nil {
 		return true, nil
 	}
 
-	// `ls-remote --exit-code --heads origin <ref>` returns exit code 2 when no matches found.
+	// `ls-remote --exit-code --heads <remote> <ref>` returns exit code 2 when no matches found.
 	// Other exit codes usually mean auth/network/remote problems.
-	if isExitCode(err, 2) {
+	if command.IsExitCode(err, 2) {
 		return false, nil
 	}
 
@@ -1,4 +1,4 @@
-package main
+package commitchanges
 
 import (
 	"fmt"
@@ -46,7 +46,7 @@ func TestCheckoutBranch(t *testing.T) {
 			},
 		}
 
-		if err := checkoutBranch("new_branch", "main", "", runner); err != nil {
+		if err := checkoutBranch("new_branch", "main", "", originRemotes, runner); err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	})
@@ -76,7 +76,7 @@ func TestCheckoutBranch(t *testing.T) {
 			},
 		}
 
-		if err := checkoutBranch("branch_from_local", "dev", "", runner); err != nil {
+		if err := checkoutBranch("branch_from_local", "dev", "", originRemotes, runner); err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	})
@@ -108,7 +108,7 @@ func TestCheckoutBranch(t *testing.T) {
 			},
 		}
 
-		if err := checkoutBranch("existing_branch", "main", "", runner); err != nil {
+		if err := checkoutBranch("existing_branch", "main", "", originRemotes, runner); err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	})
@@ -131,7 +131,7 @@ func TestCheckoutBranch(t *testing.T) {
 			},
 		}
 
-		if err := checkoutBranch("new_br", "main", "new_br", runner); err != nil {
+		if err := checkoutBranch("new_br", "main", "new_br", originRemotes, runner); err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	})
@@ -279,7 +279,7 @@ func TestCheckoutBranch(t *testing.T) {
 			},
 		}
 
-		if err := checkoutBranch("lokalise-sync", "master", "", runner); err != nil {
+		if err := checkoutBranch("lokalise-sync", "master", "", originRemotes, runner); err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 
@@ -365,7 +365,7 @@ func TestCheckoutBranch(t *testing.T) {
 			},
 		}
 
-		if err := checkoutBranch("lokalise-sync", "master", "", runner); err != nil {
+		if err := checkoutBranch("lokalise-sync", "master", "", originRemotes, runner); err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	})
@@ -526,7 +526,7 @@ func TestCheckoutBranch_RemoteBranch_StashRestore_MultipleFiles(t *testing.T) {
 		},
 	}
 
-	if err := checkoutBranch(branch, base, "", runner); err != nil {
+	if err := checkoutBranch(branch, base, "", originRemotes, runner); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -654,7 +654,7 @@ func TestCheckoutRemoteWithLocalChanges_UntrackedRestoredFromThirdParent(t *test
 	}
 
 	cause := fmt.Errorf("blocked by local changes")
-	err := checkoutRemoteWithLocalChanges(ref, ref, runner, cause)
+	err := checkoutRemoteWithLocalChanges(ref, "origin/"+ref, runner, cause)
 	if err != nil {
 		t.Fatalf("unexpected err: %v", err)
 	}
@@ -769,7 +769,7 @@ func TestCheckoutBranch_RemoteBranch_StashShowFails_NoDrop(t *testing.T) {
 		},
 	}
 
-	err := checkoutBranch(branch, base, "", runner)
+	err := checkoutBranch(branch, base, "", originRemotes, runner)
 	if err == nil {
 		t.Fatalf("expected error, got nil")
 	}
@@ -925,7 +925,7 @@ func TestCheckoutBranch_RemoteBranch_RestoreFails_NoDrop_StopsEarly(t *testing.T
 		},
 	}
 
-	err := checkoutBranch(branch, base, "", runner)
+	err := checkoutBranch(branch, base, "", originRemotes, runner)
 	if err == nil {
 		t.Fatalf("expected error, got nil")
 	}
@@ -1081,7 +1081,7 @@ func TestCheckoutBranch_RemoteBranch_DropFails_ReturnsError(t *testing.T) {
 		},
 	}
 
-	err := checkoutBranch(branch, base, "", runner)
+	err := checkoutBranch(branch, base, "", originRemotes, runner)
 	if err == nil {
 		t.Fatalf("expected error, got nil")
 	}
@@ -1191,7 +1191,7 @@ func TestCheckoutBranch_RemoteBranch_EmptyStashFileList_StillDropsAndSucceeds(t
 		},
 	}
 
-	if err := checkoutBranch(branch, base, "", runner); err != nil {
+	if err := checkoutBranch(branch, base, "", originRemotes, runner); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	if !dropped {
@@ -1240,7 +1240,7 @@ func TestCheckoutBranch_FetchesCorrectRefspec(t *testing.T) {
 		},
 	}
 
-	if err := checkoutBranch("new_branch", "main", "", runner); err != nil {
+	if err := checkoutBranch("new_branch", "main", "", originRemotes, runner); err != nil {
 		t.Fatal(err)
 	}
 
@@ -1305,7 +1305,7 @@ func TestCheckoutBranch_OverrideBranchExistsOnRemote(t *testing.T) {
 		},
 	}
 
-	if err := checkoutBranch(branch, base, "", &runner); err != nil {
+	if err := checkoutBranch(branch, base, "", originRemotes, &runner); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
@@ -1355,7 +1355,7 @@ func TestCheckoutBranch_OverrideMissing_FallbackToOriginBase_UnsetUpstream(t *te
 		},
 	}
 
-	if err := checkoutBranch(branch, base, "", &runner); err != nil {
+	if err := checkoutBranch(branch, base, "", originRemotes, &runner); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -1411,7 +1411,7 @@ func TestCheckoutBranch_HeadRefMatches_UsesRemoteHeadAndSetsUpstream(t *testing.
 		},
 	}
 
-	if err := checkoutBranch(branch, base, head, &runner); err != nil {
+	if err := checkoutBranch(branch, base, head, originRemotes, &runner); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
@@ -1434,7 +1434,7 @@ func TestCheckoutBranch_HasRemoteErrorStopsEarly(t *testing.T) {
 		},
 	}
 
-	err := checkoutBranch("feature/x", "main", "", runner)
+	err := checkoutBranch("feature/x", "main", "", originRemotes, runner)
 	if err == nil {
 		t.Fatalf("expected error, got nil")
 	}
@@ -1489,7 +1489,7 @@ func TestCheckoutBranch_RemoteExists_CleanRepo_CheckoutBlockedReturnsOriginalCau
 		},
 	}
 
-	err := checkoutBranch(branch, "main", "", runner)
+	err := checkoutBranch(branch, "main", "", originRemotes, runner)
 	if err == nil {
 		t.Fatalf("expected error, got nil")
 	}
@@ -1522,7 +1522,7 @@ func TestHasRemote(t *testing.T) {
 			},
 		}
 
-		ok, err := hasRemote(runner, wantRef)
+		ok, err := hasRemote(runner, originRemotes, wantRef)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -1554,7 +1554,7 @@ func TestHasRemote(t *testing.T) {
 			},
 		}
 
-		ok, err := hasRemote(runner, wantRef)
+		ok, err := hasRemote(runner, originRemotes, wantRef)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -1586,7 +1586,7 @@ func TestHasRemote(t *testing.T) {
 			},
 		}
 
-		ok, err := hasRemote(runner, wantRef)
+		ok, err := hasRemote(runner, originRemotes, wantRef)
 		if err == nil {
 			t.Fatalf("expected error, got nil")
 		}
@@ -1,54 +1,101 @@
-package main
+package commitchanges
 
 import (
 	"fmt"
+	"log/slog"
+	"os"
+	"slices"
 	"strings"
 
 	"github.com/bodrovis/lokalise-actions-common/v2/managedpaths"
+	"github.com/lokalise/lokalise-pull-action/src/command"
+	"github.com/lokalise/lokalise-pull-action/src/errkind"
+	"github.com/lokalise/lokalise-pull-action/src/lockfile"
 )
 
+// commitResult describes what commitAndPushChanges did, for the outputs and the job summary.
+// It is filled step by step, so a failed run still reports how far it got.
+type commitResult struct {
+	Base      string // base branch picked by resolveRealBase
+	Branch    string // branch the commit goes to
+	CommitSHA string // empty until a commit is created
+	Stats     commitStats
+	Languages map[string][]string // committed files grouped by language
+	Pushed    bool
+	ForcePush bool
+	NoChanges bool // nothing was staged, so nothing was committed
+}
+
 // commitAndPushChanges wires the whole flow: config -> git user -> base ref -> branch -> add -> commit -> push.
-func commitAndPushChanges(runner CommandRunner) (string, error) {
+func commitAndPushChanges(runner command.Runner) (commitResult, error) {
+	var res commitResult
+
 	config, err := envVarsToConfig()
 	if err != nil {
-		return "", err
+		return res, errkind.With(errkind.Config, err)
 	}
+	res.ForcePush = config.ForcePush
 
-	if err := setGitUser(config, runner); err != nil {
-		return "", err
+	cleanup, err := setGitUser(config, runner)
+	defer cleanup()
+	if err != nil {
+		return res, err
+	}
+
+	dropPushToken, err := passPushToken(config)
+	defer dropPushToken()
+	if err != nil {
+		return res, err
 	}
 
 	realBase, err := resolveRealBase(runner, config)
 	if err != nil {
-		return "", err
+		return res, err
 	}
-	fmt.Printf("Using base branch: %s\n", realBase)
+	slog.Info("Using base branch", "base", realBase)
+	res.Base = realBase
 
 	branchName, err := generateBranchNameForBase(config, realBase, runner)
 	if err != nil {
-		return "", err
+		return res, err
 	}
+	res.Branch = branchName
 
-	if err := checkoutBranch(branchName, realBase, config.HeadRef, runner); err != nil {
-		return "", err
+	if err := checkoutBranch(branchName, realBase, config.HeadRef, newRemotes(config), runner); err != nil {
+		return res, err
 	}
 
 	if err := stageManagedFiles(config, runner); err != nil {
-		return "", err
+		return res, err
 	}
 
-	return branchName, commitAndPush(branchName, runner, config)
+	res.Stats, err = commitAndPush(branchName, runner, config)
+	res.CommitSHA = res.Stats.SHA
+	res.Languages = groupFilesByLanguage(config, res.Stats.Files)
+	res.Pushed = err == nil && !config.SkipPush
+	return res, err
 }
 
-func stageManagedFiles(config *Config, runner CommandRunner) error {
+func stageManagedFiles(config *Config, runner command.Runner) error {
 	scope := buildTranslationScope(config)
 
 	filesToStage, err := managedpaths.CollectManagedGitPaths(runner, scope)
 	if err != nil {
 		return err
 	}
+
+	// The lockfile changes on every export, so it travels with the translations
+	// it describes but only makes a commit on its own when it records a new
+	// project fingerprint, which SKIP_UNCHANGED compares against on the next run.
+	lockPath, hasLockfile := lockfileToStage(config)
 	if len(filesToStage) == 0 {
-		return ErrNoChanges
+		if !hasLockfile || !lockfile.FingerprintChanged(runner, lockPath) {
+			return ErrNoChanges
+		}
+		slog.Info("Committing the lockfile alone: the project fingerprint changed", "path", lockPath)
+	}
+	if hasLockfile && !slices.Contains(filesToStage, lockPath) {
+		filesToStage = append(filesToStage, lockPath)
 	}
 
 	if err := runner.Run("git", append([]string{"add", "-A", "--"}, filesToStage...)...); err != nil {
@@ -58,6 +105,21 @@ func stageManagedFiles(config *Config, runner CommandRunner) error {
 	return nil
 }
 
+func lockfileToStage(config *Config) (string, bool) {
+	if config.LockfilePath == "" {
+		return "", false
+	}
+
+	if _, err := os.Stat(config.LockfilePath); err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("cannot access lockfile", "path", config.LockfilePath, "error", err)
+		}
+		return "", false
+	}
+
+	return config.LockfilePath, true
+}
+
 func buildTranslationScope(config *Config) managedpaths.TranslationScope {
 	return managedpaths.TranslationScope{
 		Paths:          config.TranslationPaths,
@@ -68,26 +130,45 @@ func buildTranslationScope(config *Config) managedpaths.TranslationScope {
 	}
 }
 
-// commitAndPush commits staged changes and pushes the branch (forcing if requested).
+// commitAndPush commits staged changes and pushes the branch (forcing if requested),
+// or leaves both to the GitHub API with COMMIT_BACKEND=api.
+// It returns the stats of the new commit, also when the push fails.
 // Returns ErrNoChanges when nothing is staged (non-fatal for CI).
-func commitAndPush(branchName string, runner CommandRunner, config *Config) error {
+func commitAndPush(branchName string, runner command.Runner, config *Config) (commitStats, error) {
 	hasStagedChanges, err := hasCachedDiff(runner)
 	if err != nil {
-		return err
+		return commitStats{}, err
 	}
 	if !hasStagedChanges {
-		return ErrNoChanges
+		return commitStats{}, ErrNoChanges
+	}
+
+	if config.CommitBackend == commitBackendAPI {
+		return commitViaAPI(branchName, runner, config, newGitHubClient(config))
 	}
 
 	output, err := runner.Capture("git", buildCommitArgs(config)...)
 	if err != nil {
-		return fmt.Errorf("failed to commit changes: %w\nOutput: %s", err, output)
+		return commitStats{}, fmt.Errorf("failed to commit changes: %w\nOutput: %s", err, output)
+	}
+
+	if config.SigningKey != "" {
+		if err := verifyCommitSignature(runner); err != nil {
+			return commitStats{}, err
+		}
+	}
+
+	stats := headCommitStats(runner)
+
+	if config.SkipPush {
+		slog.Info("SKIP_PUSH is on, leaving the commit unpushed", "branch", branchName)
+		return stats, nil
 	}
 
-	return pushBranch(branchName, runner, config)
+	return stats, pushBranch(branchName, runner, config)
 }
 
-func hasCachedDiff(runner CommandRunner) (bool, error) {
+func hasCachedDiff(runner command.Runner) (bool, error) {
 	out, err := runner.Capture("git", "diff", "--name-only", "--cached")
 	if err != nil {
 		return false, fmt.Errorf("failed to inspect staged changes: %w\nOutput: %s", err, out)
@@ -100,21 +181,89 @@ func buildCommitArgs(config *Config) []string {
 	if config.GitSignCommits {
 		args = append(args, "-S")
 	}
-	args = append(args, "-m", config.GitCommitMessage)
+	if author := commitAuthor(config); author != "" {
+		args = append(args, "--author", author)
+	}
+	args = append(args, "-m", commitMessage(config))
 	return args
 }
 
-func pushBranch(branchName string, runner CommandRunner, config *Config) error {
+// pushBranch pushes to GIT_PUSH_URL, GIT_PUSH_REMOTE or GIT_REMOTE, in that order.
+func pushBranch(branchName string, runner command.Runner, config *Config) error {
+	rem := newRemotes(config)
+
 	if config.ForcePush {
-		if err := runner.Run("git", "push", "--force-with-lease", "origin", branchName); err != nil {
-			return fmt.Errorf("failed to force-push branch %q: %w", branchName, err)
+		lease := forceWithLease(runner, rem, branchName)
+		if err := runner.Run("git", "push", lease, rem.push, branchName); err != nil {
+			return errkind.With(pushErrorKind(err), fmt.Errorf("failed to force-push branch %q: %w", branchName, err))
 		}
 		return nil
 	}
 
-	if err := runner.Run("git", "push", "origin", branchName); err != nil {
-		return fmt.Errorf("failed to push branch %q: %w", branchName, err)
+	if err := runner.Run("git", "push", rem.push, branchName); err != nil {
+		return errkind.With(pushErrorKind(err), fmt.Errorf("failed to push branch %q: %w", branchName, err))
 	}
 
 	return nil
 }
+
+// forceWithLease returns the lease flag for a forced push. A bare
+// --force-with-lease relies on the remote-tracking ref of a configured remote,
+// so a push URL gets the expected value spelled out: the ref fetched by
+// checkoutExistingRemoteBranch, or "must not exist" for a new branch.
+func forceWithLease(runner command.Runner, rem remotes, branchName string) string {
+	if rem.pushesToNamedRemote() {
+		return "--force-with-lease"
+	}
+
+	trackingRef := "refs/remotes/" + rem.tracking + "/" + branchName
+	if _, err := runner.Capture("git", "rev-parse", "--verify", "--quiet", trackingRef); err != nil {
+		return "--force-with-lease=" + branchName + ":"
+	}
+	return "--force-with-lease=" + branchName + ":" + trackingRef
+}
+
+// pushAuthFailures are the messages git and the common forges print when the
+// credentials are missing, wrong or not allowed to push.
+var pushAuthFailures = []string{
+	"authentication failed",
+	"could not read username",
+	"could not read password",
+	"permission to",
+	"permission denied (publickey)",
+	"http basic: access denied",
+	"the requested url returned error: 401",
+	"the requested url returned error: 403",
+}
+
+// pushRejections are the messages git prints when the remote refuses to
+// update the branch because it moved: a non-fast-forward update, or a
+// --force-with-lease whose expected value is out of date.
+var pushRejections = []string{
+	"[rejected]",
+	"non-fast-forward",
+	"stale info",
+}
+
+// pushErrorKind classifies a failed push from its stderr. Only a branch the
+// remote refused to update is reported as rejected. git exits with 128 on fatal
+// errors, which also cover DNS and network failures, a missing remote and a bad
+// refspec, so those are only reported as auth when stderr says so. Anything
+// else is a git error.
+func pushErrorKind(err error) errkind.Kind {
+	stderr := strings.ToLower(command.Stderr(err))
+	for _, marker := range pushRejections {
+		if strings.Contains(stderr, marker) {
+			return errkind.PushRejected
+		}
+	}
+
+	if command.IsExitCode(err, 128) {
+		for _, marker := range pushAuthFailures {
+			if strings.Contains(stderr, marker) {
+				return errkind.Auth
+			}
+		}
+	}
+	return errkind.Git
+}
@@ -1,10 +1,15 @@
-package main
+package commitchanges
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"slices"
 	"strings"
 	"testing"
+
+	"github.com/lokalise/lokalise-pull-action/src/command"
+	"github.com/lokalise/lokalise-pull-action/src/errkind"
 )
 
 func TestCommitAndPushChanges_ForcePush_UsesForceWithLease(t *testing.T) {
@@ -262,12 +267,12 @@ func TestCommitAndPushChanges_HappyPath_NoForce_NoOverride(t *testing.T) {
 		},
 	}
 
-	gotBranch, err := commitAndPushChanges(runner)
+	res, err := commitAndPushChanges(runner)
 	if err != nil {
 		t.Fatalf("unexpected err: %v", err)
 	}
-	if gotBranch != branchName {
-		t.Fatalf("branch mismatch: got %q want %q", gotBranch, branchName)
+	if res.Branch != branchName || !res.Pushed {
+		t.Fatalf("unexpected result: got %+v want branch %q", res, branchName)
 	}
 	if !diffCachedCalled || !commitCalled || !addCalled {
 		t.Fatalf("expected staged diff + commit + add; got diffCached=%v commit=%v add=%v", diffCachedCalled, commitCalled, addCalled)
@@ -305,7 +310,7 @@ func TestCommitAndPush_PushError(t *testing.T) {
 
 	config := &Config{}
 
-	err := commitAndPush("test_branch", runner, config)
+	_, err := commitAndPush("test_branch", runner, config)
 	if err == nil {
 		t.Errorf("Expected error, but got nil")
 	} else if !strings.Contains(err.Error(), "push failed") {
@@ -398,6 +403,11 @@ func TestCommitAndPushChanges_NoChanges_ReturnsErrNoChanges_NoPush(t *testing.T)
 				t.Fatalf("commit must not be called when there are no managed files")
 			}
 
+			// git config --global --get user.*: not set before the step
+			if len(args) == 4 && args[0] == "config" && args[1] == "--global" && args[2] == "--get" {
+				return "", &mockExitError{code: 1}
+			}
+
 			t.Fatalf("unexpected capture: git %v", args)
 			return "", nil
 		},
@@ -475,7 +485,7 @@ func TestCommitAndPush_CommitFails_NoPush(t *testing.T) {
 		},
 	}
 
-	err := commitAndPush("branch", runner, &Config{GitCommitMessage: "msg"})
+	_, err := commitAndPush("branch", runner, &Config{GitCommitMessage: "msg"})
 	if err == nil || !strings.Contains(err.Error(), "failed to commit changes") {
 		t.Fatalf("Expected commit error, got %v", err)
 	}
@@ -570,6 +580,10 @@ func TestCommitAndPushChanges_SyntheticBase_UsesRemoteHEAD(t *testing.T) {
 				return "ok", nil
 			}
 
+			if len(args) >= 4 && args[2] == "show" {
+				return "abc123 def456\n\n3\t1\tlocales/fr.json\n", nil
+			}
+
 			// validate generated branch name
 			if len(args) == 3 &&
 				args[0] == "check-ref-format" &&
@@ -580,6 +594,11 @@ func TestCommitAndPushChanges_SyntheticBase_UsesRemoteHEAD(t *testing.T) {
 				return "", nil
 			}
 
+			// git config --global --get user.*: not set before the step
+			if len(args) == 4 && args[0] == "config" && args[1] == "--global" && args[2] == "--get" {
+				return "", &mockExitError{code: 1}
+			}
+
 			t.Fatalf("unexpected capture: git %v", args)
 			return "", nil
 		},
@@ -621,11 +640,18 @@ func TestCommitAndPushChanges_SyntheticBase_UsesRemoteHEAD(t *testing.T) {
 		},
 	}
 
-	_, err := commitAndPushChanges(runner)
+	res, err := commitAndPushChanges(runner)
 	if err != nil {
 		t.Fatalf("unexpected err: %v", err)
 	}
 
+	if res.CommitSHA != "abc123" || res.Stats.ParentSHA != "def456" || res.Stats.Insertions != 3 || res.Stats.Deletions != 1 {
+		t.Fatalf("unexpected commit stats: %+v", res)
+	}
+	if !slices.Equal(res.Languages["fr"], []string{"locales/fr.json"}) {
+		t.Fatalf("unexpected languages: %v", res.Languages)
+	}
+
 	foundMaster := false
 	for _, refspec := range fetched {
 		if strings.Contains(refspec, "+refs/heads/master:refs/remotes/origin/master") {
@@ -656,6 +682,10 @@ func TestCommitAndPush_SignedCommit_AddsDashS(t *testing.T) {
 				return "ok", nil
 			}
 
+			if len(args) >= 4 && args[2] == "show" {
+				return "abc123 def456\n\n3\t1\tlocales/fr.json\n", nil
+			}
+
 			t.Fatalf("unexpected capture: git %v", args)
 			return "", nil
 		},
@@ -671,7 +701,7 @@ func TestCommitAndPush_SignedCommit_AddsDashS(t *testing.T) {
 		},
 	}
 
-	err := commitAndPush("branch", runner, &Config{
+	_, err := commitAndPush("branch", runner, &Config{
 		GitCommitMessage: "msg",
 		GitSignCommits:   true,
 	})
@@ -685,6 +715,35 @@ func TestCommitAndPush_SignedCommit_AddsDashS(t *testing.T) {
 	}
 }
 
+func TestCommitAndPush_SkipPush_CommitsWithoutPushing(t *testing.T) {
+	runner := &MockCommandRunner{
+		CaptureFunc: func(name string, args ...string) (string, error) {
+			switch {
+			case len(args) == 3 && args[0] == "diff" && args[2] == "--cached":
+				return "locales/fr.json\n", nil
+			case len(args) >= 1 && args[0] == "commit":
+				return "ok", nil
+			case len(args) >= 4 && args[2] == "show":
+				return "abc123 def456\n\n3\t1\tlocales/fr.json\n", nil
+			}
+			t.Fatalf("unexpected capture: git %v", args)
+			return "", nil
+		},
+		RunFunc: func(name string, args ...string) error {
+			t.Fatalf("nothing should be pushed, got: git %v", args)
+			return nil
+		},
+	}
+
+	stats, err := commitAndPush("branch", runner, &Config{GitCommitMessage: "msg", SkipPush: true})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if stats.SHA != "abc123" {
+		t.Fatalf("expected the commit stats, got %+v", stats)
+	}
+}
+
 func TestCommitAndPush_DiffCachedError(t *testing.T) {
 	runner := &MockCommandRunner{
 		CaptureFunc: func(name string, args ...string) (string, error) {
@@ -700,7 +759,7 @@ func TestCommitAndPush_DiffCachedError(t *testing.T) {
 		},
 	}
 
-	err := commitAndPush("branch", runner, &Config{GitCommitMessage: "msg"})
+	_, err := commitAndPush("branch", runner, &Config{GitCommitMessage: "msg"})
 	if err == nil {
 		t.Fatalf("expected error, got nil")
 	}
@@ -777,6 +836,11 @@ func TestCommitAndPushChanges_StagedDiffEmptyAfterAdd_ReturnsErrNoChanges(t *tes
 				return "", nil
 			}
 
+			// git config --global --get user.*: not set before the step
+			if len(args) == 4 && args[0] == "config" && args[1] == "--global" && args[2] == "--get" {
+				return "", &mockExitError{code: 1}
+			}
+
 			t.Fatalf("unexpected capture: git %v", args)
 			return "", nil
 		},
@@ -876,6 +940,11 @@ func TestCommitAndPushChanges_AddFails(t *testing.T) {
 				return "", nil
 			}
 
+			// git config --global --get user.*: not set before the step
+			if len(args) == 4 && args[0] == "config" && args[1] == "--global" && args[2] == "--get" {
+				return "", &mockExitError{code: 1}
+			}
+
 			t.Fatalf("unexpected capture: git %v", args)
 			return "", nil
 		},
@@ -910,3 +979,225 @@ func TestCommitAndPushChanges_AddFails(t *testing.T) {
 		t.Fatalf("expected add error, got %v", err)
 	}
 }
+
+func stagingRunner(t *testing.T, changed string, added *[]string) *MockCommandRunner {
+	t.Helper()
+
+	return &MockCommandRunner{
+		CaptureFunc: func(name string, args ...string) (string, error) {
+			if len(args) == 3 && args[0] == "rev-parse" && args[1] == "--verify" && args[2] == "HEAD" {
+				return "ok", nil
+			}
+			if len(args) == 5 && args[2] == "diff" && args[3] == "--name-only" && args[4] == "HEAD" {
+				return changed, nil
+			}
+			if len(args) == 5 && args[2] == "ls-files" && args[3] == "--others" {
+				return "", nil
+			}
+
+			t.Fatalf("unexpected capture: %s %v", name, args)
+			return "", nil
+		},
+		RunFunc: func(name string, args ...string) error {
+			if len(args) >= 3 && args[0] == "add" && args[1] == "-A" && args[2] == "--" {
+				*added = append([]string(nil), args[3:]...)
+				return nil
+			}
+
+			t.Fatalf("unexpected run: %s %v", name, args)
+			return nil
+		},
+	}
+}
+
+func TestStageManagedFiles_StagesLockfileWithTranslations(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	if err := os.MkdirAll("locales", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("locales/.lokalise.lock", []byte("{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := &Config{
+		TranslationPaths: []string{"locales"},
+		FileExts:         []string{"json"},
+		FlatNaming:       true,
+		AlwaysPullBase:   true,
+		BaseLang:         "en",
+		LockfilePath:     "locales/.lokalise.lock",
+	}
+
+	var added []string
+	runner := stagingRunner(t, "locales/fr.json\nlocales/.lokalise.lock\n", &added)
+
+	if err := stageManagedFiles(config, runner); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"locales/fr.json", "locales/.lokalise.lock"}
+	if !slices.Equal(added, want) {
+		t.Fatalf("staged files mismatch: want %v got %v", want, added)
+	}
+}
+
+func TestStageManagedFiles_LockfileAloneIsNotACommit(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	if err := os.MkdirAll("locales", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("locales/.lokalise.lock", []byte("{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := &Config{
+		TranslationPaths: []string{"locales"},
+		FileExts:         []string{"json"},
+		FlatNaming:       true,
+		AlwaysPullBase:   true,
+		BaseLang:         "en",
+		LockfilePath:     "locales/.lokalise.lock",
+	}
+
+	var added []string
+	runner := stagingRunner(t, "locales/.lokalise.lock\n", &added)
+
+	err := stageManagedFiles(config, runner)
+	if !errors.Is(err, ErrNoChanges) {
+		t.Fatalf("expected ErrNoChanges, got %v", err)
+	}
+	if added != nil {
+		t.Fatalf("nothing should be staged, got %v", added)
+	}
+}
+
+func TestStageManagedFiles_LockfileWithNewFingerprintIsCommittedAlone(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	if err := os.MkdirAll("locales", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("locales/.lokalise.lock", []byte(`{"project_fingerprint":"sha256:new"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := &Config{
+		TranslationPaths: []string{"locales"},
+		FileExts:         []string{"json"},
+		FlatNaming:       true,
+		AlwaysPullBase:   true,
+		BaseLang:         "en",
+		LockfilePath:     "locales/.lokalise.lock",
+	}
+
+	var added []string
+	runner := stagingRunner(t, "locales/.lokalise.lock\n", &added)
+	runner.OutputFunc = func(name string, args ...string) ([]byte, error) {
+		if len(args) == 2 && args[0] == "show" && args[1] == "HEAD:locales/.lokalise.lock" {
+			return []byte(`{"project_fingerprint":"sha256:old"}`), nil
+		}
+		t.Fatalf("unexpected output call: %s %v", name, args)
+		return nil, nil
+	}
+
+	if err := stageManagedFiles(config, runner); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(added, []string{"locales/.lokalise.lock"}) {
+		t.Fatalf("expected the lockfile alone, got %v", added)
+	}
+}
+
+func TestStageManagedFiles_MissingLockfileIsSkipped(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	config := &Config{
+		TranslationPaths: []string{"locales"},
+		FileExts:         []string{"json"},
+		FlatNaming:       true,
+		AlwaysPullBase:   true,
+		BaseLang:         "en",
+		LockfilePath:     "locales/.lokalise.lock",
+	}
+
+	var added []string
+	runner := stagingRunner(t, "locales/fr.json\n", &added)
+
+	if err := stageManagedFiles(config, runner); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !slices.Equal(added, []string{"locales/fr.json"}) {
+		t.Fatalf("staged files mismatch: %v", added)
+	}
+}
+
+func TestCommitAndPush_UnverifiedSignatureIsNotPushed(t *testing.T) {
+	runner := &MockCommandRunner{
+		CaptureFunc: func(name string, args ...string) (string, error) {
+			switch {
+			case len(args) == 3 && args[0] == "diff" && args[2] == "--cached":
+				return "locales/fr.json\n", nil
+			case len(args) >= 1 && args[0] == "commit":
+				return "ok", nil
+			case len(args) >= 1 && args[0] == "verify-commit":
+				return "error: no signature found", &mockExitError{code: 1}
+			}
+			t.Fatalf("unexpected capture: git %v", args)
+			return "", nil
+		},
+		RunFunc: func(name string, args ...string) error {
+			t.Fatalf("nothing should be pushed, got: git %v", args)
+			return nil
+		},
+	}
+
+	config := &Config{GitCommitMessage: "msg", GitSignCommits: true, SigningKey: "key", SigningFormat: signingFormatSSH}
+	_, err := commitAndPush("branch", runner, config)
+	if err == nil || !strings.Contains(err.Error(), "signature could not be verified") {
+		t.Fatalf("expected a verification error, got %v", err)
+	}
+}
+
+func TestPushBranch_ClassifiesFailures(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		code   int
+		stderr string
+		want   errkind.Kind
+	}{
+		{"bad token", 128, "remote: Invalid username or password.\nfatal: Authentication failed for 'https://github.com/o/r.git/'", errkind.Auth},
+		{"no credentials", 128, "fatal: could not read Username for 'https://github.com': terminal prompts disabled", errkind.Auth},
+		{"no write access", 128, "remote: Permission to o/r.git denied to bot.\nfatal: unable to access 'https://github.com/o/r.git/': The requested URL returned error: 403", errkind.Auth},
+		{"network", 128, "fatal: unable to access 'https://github.com/o/r.git/': Could not resolve host: github.com", errkind.Git},
+		{"no stderr", 128, "", errkind.Git},
+		{"rejected", 1, "! [rejected] lok_branch -> lok_branch (fetch first)", errkind.PushRejected},
+		{"non-fast-forward", 1, "hint: Updates were rejected because a pushed branch tip is behind its remote\nhint: counterpart. ... non-fast-forward", errkind.PushRejected},
+		{"stale lease", 1, "! [rejected] lok_branch -> lok_branch (stale info)", errkind.PushRejected},
+		{"hook declined", 1, "! [remote rejected] lok_branch -> lok_branch (pre-receive hook declined)", errkind.Git},
+		{"exit 1 without stderr", 1, "", errkind.Git},
+		{"auth text on exit 1", 1, "error: permission to write the pack file", errkind.Git},
+	}
+
+	for _, tt := range tests {
+		runner := &MockCommandRunner{
+			RunFunc: func(name string, args ...string) error {
+				return &command.Error{Err: &mockExitError{code: tt.code}, Stderr: tt.stderr}
+			},
+		}
+
+		err := pushBranch("lok_branch", runner, &Config{})
+		if err == nil {
+			t.Fatalf("%s: expected an error", tt.name)
+		}
+
+		_, wrapped := performCommit(func(command.Runner) (commitResult, error) { return commitResult{}, err }, runner)
+		if got := errkind.Of(wrapped); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
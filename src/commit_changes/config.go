@@ -1,46 +1,114 @@
-package main
+package commitchanges
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/bodrovis/lokalise-actions-common/v2/fileexts"
 	"github.com/bodrovis/lokalise-actions-common/v2/parsers"
+	"github.com/lokalise/lokalise-pull-action/src/cienv"
 )
 
 // Config aggregates all inputs required to construct the commit/branch/push.
 type Config struct {
-	GitHubActor        string   // used for default git user.name and noreply email
-	GitHubSHA          string   // used to shorten into branch uniqueness token
+	Actor              string   // CI user; default git user.name and noreply email
+	SHA                string   // commit being built; shortened into the branch uniqueness token
 	TempBranchPrefix   string   // prefix for generated tmp branches (e.g., "lok")
 	FileExts           []string // normalized extensions without dots (e.g., "json", "stringsdict")
 	BaseLang           string   // e.g., "en", "fr_FR"
 	FlatNaming         bool     // true: locales/en.json ; false: locales/en/app.json
 	AlwaysPullBase     bool     // if false, base language files/dir are excluded from the commit
-	GitUserName        string   // optional override for git config user.name
-	GitUserEmail       string   // optional override for git config user.email
+	GitUserName        string   // git config user.name; GIT_USER_NAME or the CI user's name
+	GitUserEmail       string   // git config user.email; GIT_USER_EMAIL or the CI user's email
 	GitCommitMessage   string   // commit message to use
-	GitSignCommits     bool     // add -S for git commit
+	GitSignCommits     bool     // add -S for git commit; implied by SigningKey
 	OverrideBranchName string   // static branch name to reuse a single PR
 	ForcePush          bool     // whether to force-push (overwriting history)
+	SkipPush           bool     // commit locally without pushing the branch
 	BaseRef            string   // base branch name (no refs/heads/ prefix)
 	HeadRef            string   // PR head branch (when running in a PR), no refs/heads/
 	TranslationPaths   []string // one or multiple roots like ["locales"]
+	LockfilePath       string   // optional export lockfile staged together with translations
+	Remote             string   // remote to fetch base branches from; origin when empty
+	PushRemote         string   // remote to push the branch to; Remote when empty
+	PushURL            string   // optional URL to push to instead of PushRemote
+	PushToken          string   // optional token sent to an https PushURL, see passPushToken
+	CommitBackend      string   // "git" (commit and push locally) or "api" (GitHub Git Data API); empty means git
+	GitHubToken        string   // token for the api backend
+	GitHubRepository   string   // owner/repo the api backend commits to
+	GitHubAPIURL       string   // REST API root for the api backend
+	SigningKey         string   // optional armored GPG or OpenSSH private key to sign with
+	SigningFormat      string   // "openpgp" or "ssh", set when SigningKey is
+	SigningPassphrase  string   // optional passphrase of a GPG SigningKey
+	IdentityScope      string   // where user.name/user.email go: "global", "local" or "commit"; empty means global
+	GitAuthorName      string   // optional commit author name when it differs from the committer
+	GitAuthorEmail     string   // optional commit author email when it differs from the committer
+	CoAuthors          []string // "Name <email>" entries added as Co-authored-by trailers
 }
 
+// Supported COMMIT_BACKEND values.
+const (
+	commitBackendGit = "git"
+	commitBackendAPI = "api"
+)
+
+const defaultGitHubAPIURL = "https://api.github.com"
+
 type translationInputs struct {
-	fileExts []string
-	paths    []string
-	baseLang string
+	fileExts     []string
+	paths        []string
+	baseLang     string
+	lockfilePath string
+}
+
+type remoteInputs struct {
+	remote     string
+	pushRemote string
+	pushURL    string
+	pushToken  string
+}
+
+type signingInputs struct {
+	key        string
+	format     string
+	passphrase string
+}
+
+type identityInputs struct {
+	scope       string
+	authorName  string
+	authorEmail string
+	coAuthors   []string
+}
+
+type apiInputs struct {
+	backend    string
+	token      string
+	repository string
+	apiURL     string
 }
 
 // envVarsToConfig reads env vars, validates required ones, normalizes arrays and returns a Config.
 // Notes:
-// - FILE_EXT may be a multi-line YAML block; if absent, we fall back to FILE_FORMAT.
-// - We strip "refs/heads/" from BaseRef/HeadRef if present.
-// - Commit message defaults to "Translations update".
+//   - FILE_EXT may be a multi-line YAML block; if absent, we fall back to FILE_FORMAT.
+//   - The actor, SHA and refs come from the CI environment (see cienv), so
+//     GitLab CI and Bitbucket Pipelines work without the GITHUB_* variables.
+//   - BASE_REF and HEAD_REF default to the refs of the CI run; "refs/heads/" is stripped.
+//   - GIT_REMOTE, GIT_PUSH_REMOTE and GIT_PUSH_URL route fetches and pushes, see newRemotes.
+//   - COMMIT_BACKEND=api commits through the GitHub API and needs GITHUB_TOKEN and the repository.
+//   - GIT_SIGNING_KEY turns on signing with that key, see setupSigning.
+//   - GIT_IDENTITY_SCOPE decides where the git identity is configured, see newConfigWriter.
+//   - Commit message defaults to "Translations update".
+//   - Malformed optional booleans fall back to false with a warning, or fail when STRICT_CONFIG is on.
 func envVarsToConfig() (*Config, error) {
+	ci := cienv.Detect()
+	if err := requireCIValues(ci); err != nil {
+		return nil, err
+	}
+
 	requiredStrings, requiredBools, err := readRequiredEnvVars()
 	if err != nil {
 		return nil, err
@@ -51,13 +119,78 @@ func envVarsToConfig() (*Config, error) {
 		return nil, err
 	}
 
-	return buildConfig(requiredStrings, requiredBools, translationInputs), nil
+	remoteInputs, err := readRemoteInputs()
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	optionalBools := map[string]bool{
+		"GIT_SIGN_COMMITS": readOptionalBoolEnv(&problems, "GIT_SIGN_COMMITS"),
+		"SKIP_PUSH":        readOptionalBoolEnv(&problems, "SKIP_PUSH"),
+	}
+	strict := readOptionalBoolEnv(&problems, "STRICT_CONFIG")
+	if err := reportInputProblems(problems, strict); err != nil {
+		return nil, err
+	}
+
+	signingInputs, err := readSigningInputs()
+	if err != nil {
+		return nil, err
+	}
+
+	identityInputs, err := readIdentityInputs()
+	if err != nil {
+		return nil, err
+	}
+
+	apiInputs, err := readAPIInputs(ci, remoteInputs, optionalBools, signingInputs, identityInputs)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildConfig(ci, requiredStrings, requiredBools, optionalBools, translationInputs, remoteInputs, apiInputs, signingInputs, identityInputs), nil
+}
+
+// shaVars and actorVars name the variables each CI provider sets for the
+// commit SHA and the actor. GITHUB_SHA and GITHUB_ACTOR are accepted
+// everywhere as overrides. Bitbucket exposes no usable actor.
+var (
+	shaVars = map[cienv.Provider]string{
+		cienv.GitLabCI:           "CI_COMMIT_SHA",
+		cienv.BitbucketPipelines: "BITBUCKET_COMMIT",
+	}
+	actorVars = map[cienv.Provider]string{
+		cienv.GitLabCI: "GITLAB_USER_LOGIN",
+	}
+)
+
+// requireCIValues checks the values the CI environment must provide. The actor
+// is only needed to derive the git identity, so providers that expose no usable
+// login (Bitbucket) work once GIT_USER_NAME and GIT_USER_EMAIL are set.
+func requireCIValues(ci cienv.Env) error {
+	hasIdentity := firstNonEmpty(os.Getenv("GIT_USER_NAME"), ci.UserName) != "" &&
+		firstNonEmpty(os.Getenv("GIT_USER_EMAIL"), ci.UserEmail) != ""
+	if ci.Actor == "" && !hasIdentity {
+		if name, ok := actorVars[ci.Provider]; ok {
+			return fmt.Errorf("environment variable %s (or GITHUB_ACTOR) is required, or set GIT_USER_NAME and GIT_USER_EMAIL", name)
+		}
+		if ci.Provider == cienv.BitbucketPipelines {
+			return fmt.Errorf("environment variables GIT_USER_NAME and GIT_USER_EMAIL (or GITHUB_ACTOR) are required on Bitbucket Pipelines")
+		}
+		return fmt.Errorf("environment variable GITHUB_ACTOR is required, or set GIT_USER_NAME and GIT_USER_EMAIL")
+	}
+	if ci.SHA == "" {
+		if name, ok := shaVars[ci.Provider]; ok {
+			return fmt.Errorf("environment variable %s (or GITHUB_SHA) is required", name)
+		}
+		return fmt.Errorf("environment variable GITHUB_SHA is required")
+	}
+	return nil
 }
 
 func readRequiredEnvVars() (map[string]string, map[string]bool, error) {
 	requiredStrings, err := readRequiredStringEnv(
-		"GITHUB_ACTOR",
-		"GITHUB_SHA",
 		"TEMP_BRANCH_PREFIX",
 		"TRANSLATIONS_PATH",
 		"BASE_LANG",
@@ -94,37 +227,220 @@ func readTranslationInputs(baseLangEnv string) (*translationInputs, error) {
 		return nil, err
 	}
 
+	lockfilePath, err := readLockfilePath(fileExts)
+	if err != nil {
+		return nil, err
+	}
+
 	return &translationInputs{
-		fileExts: fileExts,
-		paths:    paths,
-		baseLang: baseLang,
+		fileExts:     fileExts,
+		paths:        paths,
+		baseLang:     baseLang,
+		lockfilePath: lockfilePath,
 	}, nil
 }
 
+// readLockfilePath returns the optional LOCKFILE_PATH normalized to forward slashes.
+// A lockfile with a translation extension would be staged as a translation on
+// every run, so it is rejected.
+func readLockfilePath(fileExts []string) (string, error) {
+	raw := strings.TrimSpace(os.Getenv("LOCKFILE_PATH"))
+	if raw == "" {
+		return "", nil
+	}
+
+	clean, err := parsers.EnsureRepoRelativePath(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid LOCKFILE_PATH: %w", err)
+	}
+
+	ext := strings.TrimPrefix(filepath.Ext(clean), ".")
+	for _, managed := range fileExts {
+		if ext != "" && strings.EqualFold(managed, ext) {
+			return "", fmt.Errorf("LOCKFILE_PATH %q must not use a translation file extension (%s)", raw, ext)
+		}
+	}
+
+	return filepath.ToSlash(clean), nil
+}
+
+// readRemoteInputs reads the optional remote settings. Defaults are applied
+// later by newRemotes, so an unset value stays empty here.
+func readRemoteInputs() (*remoteInputs, error) {
+	inputs := &remoteInputs{
+		remote:     strings.TrimSpace(os.Getenv("GIT_REMOTE")),
+		pushRemote: strings.TrimSpace(os.Getenv("GIT_PUSH_REMOTE")),
+		pushURL:    strings.TrimSpace(os.Getenv("GIT_PUSH_URL")),
+		pushToken:  strings.TrimSpace(os.Getenv("GIT_PUSH_TOKEN")),
+	}
+
+	if err := validateRemoteName("GIT_REMOTE", inputs.remote); err != nil {
+		return nil, err
+	}
+	if err := validateRemoteName("GIT_PUSH_REMOTE", inputs.pushRemote); err != nil {
+		return nil, err
+	}
+	if err := validatePushURL(inputs.pushURL, inputs.pushToken); err != nil {
+		return nil, err
+	}
+
+	return inputs, nil
+}
+
+// readAPIInputs reads COMMIT_BACKEND and, for the api backend, the GitHub
+// settings it needs. The API writes to the repository itself, so the push
+// settings of the git backend cannot be combined with it.
+func readAPIInputs(ci cienv.Env, remoteSettings *remoteInputs, optionalBools map[string]bool, signing *signingInputs, identity *identityInputs) (*apiInputs, error) {
+	backend := strings.ToLower(strings.TrimSpace(os.Getenv("COMMIT_BACKEND")))
+	switch backend {
+	case "", commitBackendGit:
+		return &apiInputs{backend: backend}, nil
+	case commitBackendAPI:
+	default:
+		return nil, fmt.Errorf("environment variable COMMIT_BACKEND has incorrect value %q, expected git or api", backend)
+	}
+
+	inputs := &apiInputs{
+		backend:    backend,
+		token:      strings.TrimSpace(os.Getenv("GITHUB_TOKEN")),
+		repository: ci.Repository,
+		apiURL:     strings.TrimRight(firstNonEmpty(os.Getenv("GITHUB_API_URL"), defaultGitHubAPIURL), "/"),
+	}
+
+	if inputs.token == "" {
+		return nil, fmt.Errorf("environment variable GITHUB_TOKEN is required when COMMIT_BACKEND is api")
+	}
+	if owner, repo, ok := strings.Cut(inputs.repository, "/"); !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return nil, fmt.Errorf("environment variable GITHUB_REPOSITORY must be owner/repo when COMMIT_BACKEND is api, got %q", inputs.repository)
+	}
+	if remoteSettings.pushRemote != "" || remoteSettings.pushURL != "" {
+		return nil, fmt.Errorf("COMMIT_BACKEND=api commits to GITHUB_REPOSITORY and cannot be combined with GIT_PUSH_REMOTE or GIT_PUSH_URL")
+	}
+	if optionalBools["SKIP_PUSH"] {
+		return nil, fmt.Errorf("COMMIT_BACKEND=api creates the commit on the remote and cannot be combined with SKIP_PUSH")
+	}
+	if optionalBools["GIT_SIGN_COMMITS"] || signing.key != "" {
+		slog.Warn("GIT_SIGN_COMMITS and GIT_SIGNING_KEY are ignored with COMMIT_BACKEND=api, GitHub signs the commit")
+	}
+	if identity.authorName != "" || identity.authorEmail != "" {
+		slog.Warn("GIT_COMMIT_AUTHOR_NAME and GIT_COMMIT_AUTHOR_EMAIL are ignored with COMMIT_BACKEND=api, GitHub sets the author")
+	}
+
+	return inputs, nil
+}
+
+// readSigningInputs reads the optional signing key. The key is kept as is
+// (secrets keep their line breaks); only its type is checked here.
+func readSigningInputs() (*signingInputs, error) {
+	key := os.Getenv("GIT_SIGNING_KEY")
+	passphrase := os.Getenv("GIT_SIGNING_KEY_PASSPHRASE")
+	if strings.TrimSpace(key) == "" {
+		return &signingInputs{}, nil
+	}
+
+	format, err := detectSigningFormat(os.Getenv("GIT_SIGNING_FORMAT"), key)
+	if err != nil {
+		return nil, err
+	}
+	if format == signingFormatSSH && passphrase != "" {
+		return nil, fmt.Errorf("GIT_SIGNING_KEY_PASSPHRASE is only supported for GPG keys, use an SSH key without a passphrase")
+	}
+
+	return &signingInputs{key: key, format: format, passphrase: passphrase}, nil
+}
+
+// readIdentityInputs reads where the git identity goes and the optional
+// author and co-authors. Names and emails end up inside "Name <email>", so
+// angle brackets and line breaks are rejected.
+func readIdentityInputs() (*identityInputs, error) {
+	scope := strings.ToLower(strings.TrimSpace(os.Getenv("GIT_IDENTITY_SCOPE")))
+	switch scope {
+	case "", identityScopeGlobal, identityScopeLocal, identityScopeCommit:
+	default:
+		return nil, fmt.Errorf("environment variable GIT_IDENTITY_SCOPE has incorrect value %q, expected global, local or commit", scope)
+	}
+
+	inputs := &identityInputs{
+		scope:       scope,
+		authorName:  strings.TrimSpace(os.Getenv("GIT_COMMIT_AUTHOR_NAME")),
+		authorEmail: strings.TrimSpace(os.Getenv("GIT_COMMIT_AUTHOR_EMAIL")),
+	}
+	for key, value := range map[string]string{
+		"GIT_COMMIT_AUTHOR_NAME":  inputs.authorName,
+		"GIT_COMMIT_AUTHOR_EMAIL": inputs.authorEmail,
+	} {
+		if strings.ContainsAny(value, "<>\r\n") {
+			return nil, fmt.Errorf("environment variable %s has incorrect value %q", key, value)
+		}
+	}
+
+	for _, coAuthor := range parsers.ParseStringArrayEnv("GIT_CO_AUTHORS") {
+		if !isNameWithEmail(coAuthor) {
+			return nil, fmt.Errorf("environment variable GIT_CO_AUTHORS has incorrect entry %q, expected \"Name <email>\"", coAuthor)
+		}
+		inputs.coAuthors = append(inputs.coAuthors, coAuthor)
+	}
+
+	return inputs, nil
+}
+
+// isNameWithEmail reports whether s looks like "Name <email>".
+func isNameWithEmail(s string) bool {
+	name, rest, ok := strings.Cut(s, "<")
+	email, ok2 := strings.CutSuffix(rest, ">")
+	return ok && ok2 &&
+		strings.TrimSpace(name) != "" &&
+		strings.Contains(email, "@") &&
+		!strings.ContainsAny(email, "<> ")
+}
+
 func buildConfig(
+	ci cienv.Env,
 	requiredStrings map[string]string,
 	requiredBools map[string]bool,
+	optionalBools map[string]bool,
 	inputs *translationInputs,
+	remoteSettings *remoteInputs,
+	api *apiInputs,
+	signing *signingInputs,
+	identity *identityInputs,
 ) *Config {
-	baseRef, headRef := parseGitRefs()
+	baseRef, headRef := parseGitRefs(ci)
 
 	return &Config{
-		GitHubActor:        requiredStrings["GITHUB_ACTOR"],
-		GitHubSHA:          requiredStrings["GITHUB_SHA"],
+		Actor:              ci.Actor,
+		SHA:                ci.SHA,
 		TempBranchPrefix:   requiredStrings["TEMP_BRANCH_PREFIX"],
 		FileExts:           inputs.fileExts,
 		BaseLang:           inputs.baseLang,
 		FlatNaming:         requiredBools["FLAT_NAMING"],
 		AlwaysPullBase:     requiredBools["ALWAYS_PULL_BASE"],
-		GitUserName:        strings.TrimSpace(os.Getenv("GIT_USER_NAME")),
-		GitUserEmail:       strings.TrimSpace(os.Getenv("GIT_USER_EMAIL")),
+		GitUserName:        firstNonEmpty(os.Getenv("GIT_USER_NAME"), ci.UserName),
+		GitUserEmail:       firstNonEmpty(os.Getenv("GIT_USER_EMAIL"), ci.UserEmail),
 		GitCommitMessage:   resolveCommitMessage(),
-		GitSignCommits:     parseOptionalBoolEnvFalse("GIT_SIGN_COMMITS"),
+		GitSignCommits:     optionalBools["GIT_SIGN_COMMITS"] || signing.key != "",
 		OverrideBranchName: strings.TrimSpace(os.Getenv("OVERRIDE_BRANCH_NAME")),
 		ForcePush:          requiredBools["FORCE_PUSH"],
+		SkipPush:           optionalBools["SKIP_PUSH"],
 		BaseRef:            baseRef,
 		HeadRef:            headRef,
 		TranslationPaths:   inputs.paths,
+		LockfilePath:       inputs.lockfilePath,
+		Remote:             remoteSettings.remote,
+		PushRemote:         remoteSettings.pushRemote,
+		PushURL:            remoteSettings.pushURL,
+		PushToken:          remoteSettings.pushToken,
+		CommitBackend:      api.backend,
+		GitHubToken:        api.token,
+		GitHubRepository:   api.repository,
+		GitHubAPIURL:       api.apiURL,
+		SigningKey:         signing.key,
+		SigningFormat:      signing.format,
+		SigningPassphrase:  signing.passphrase,
+		IdentityScope:      identity.scope,
+		GitAuthorName:      identity.authorName,
+		GitAuthorEmail:     identity.authorEmail,
+		CoAuthors:          identity.coAuthors,
 	}
 }
 
@@ -142,24 +458,45 @@ func readRequiredStringEnv(keys ...string) (map[string]string, error) {
 	return values, nil
 }
 
+// readRequiredBoolEnv reports all malformed keys at once rather than the first one.
 func readRequiredBoolEnv(keys ...string) (map[string]bool, error) {
 	values := make(map[string]bool, len(keys))
+	var invalid []string
 
 	for _, key := range keys {
 		value, err := parsers.ParseBoolEnv(key)
 		if err != nil {
-			return nil, fmt.Errorf("environment variable %s has incorrect value, expected true or false", key)
+			invalid = append(invalid, key)
+			continue
 		}
 		values[key] = value
 	}
 
-	return values, nil
+	switch len(invalid) {
+	case 0:
+		return values, nil
+	case 1:
+		return nil, fmt.Errorf("environment variable %s has incorrect value, expected true or false", invalid[0])
+	default:
+		return nil, fmt.Errorf("environment variables %s have incorrect values, expected true or false", strings.Join(invalid, ", "))
+	}
 }
 
-func parseGitRefs() (baseRef, headRef string) {
-	baseRef = strings.TrimPrefix(strings.TrimSpace(os.Getenv("BASE_REF")), "refs/heads/")
-	headRef = strings.TrimPrefix(strings.TrimSpace(os.Getenv("HEAD_REF")), "refs/heads/")
-	return baseRef, headRef
+// parseGitRefs mirrors the action: BASE_REF falls back to the target branch of
+// the pull/merge request, then to the built ref; HEAD_REF to its source branch.
+func parseGitRefs(ci cienv.Env) (baseRef, headRef string) {
+	baseRef = firstNonEmpty(os.Getenv("BASE_REF"), ci.BaseRef, ci.RefName)
+	headRef = firstNonEmpty(os.Getenv("HEAD_REF"), ci.HeadRef)
+	return strings.TrimPrefix(baseRef, "refs/heads/"), strings.TrimPrefix(headRef, "refs/heads/")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
 }
 
 // resolveFileExts returns normalized file extensions from FILE_EXT or, if it is
@@ -176,11 +513,29 @@ func resolveCommitMessage() string {
 	return commitMsg
 }
 
-// parseOptionalBoolEnvFalse returns false if the variable is unset or invalid.
-func parseOptionalBoolEnvFalse(key string) bool {
+// readOptionalBoolEnv returns false if the variable is unset or invalid.
+// Invalid values are appended to problems.
+func readOptionalBoolEnv(problems *[]string, key string) bool {
 	value, err := parsers.ParseBoolEnv(key)
 	if err != nil {
+		*problems = append(*problems, fmt.Sprintf("%s has incorrect value %q, expected true or false", key, os.Getenv(key)))
 		return false
 	}
 	return value
 }
+
+// reportInputProblems fails in strict mode and prints warnings otherwise.
+func reportInputProblems(problems []string, strict bool) error {
+	if len(problems) == 0 {
+		return nil
+	}
+
+	if strict {
+		return fmt.Errorf("invalid inputs (STRICT_CONFIG is on): %s", strings.Join(problems, "; "))
+	}
+	for _, problem := range problems {
+		slog.Warn(problem)
+	}
+
+	return nil
+}
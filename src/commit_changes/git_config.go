@@ -0,0 +1,146 @@
+package commitchanges
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/lokalise/lokalise-pull-action/src/command"
+)
+
+// Supported GIT_IDENTITY_SCOPE values.
+const (
+	identityScopeGlobal = "global" // ~/.gitconfig, the default
+	identityScopeLocal  = "local"  // .git/config of the repository
+	identityScopeCommit = "commit" // only the git commands run by this step
+)
+
+// configWriter sets the git config values the step needs (identity, signing)
+// and puts the previous ones back when the step ends.
+type configWriter interface {
+	set(key, value string) error
+	restore()
+}
+
+// newConfigWriter returns the writer for GIT_IDENTITY_SCOPE; empty means global.
+func newConfigWriter(scope string, runner command.Runner) configWriter {
+	switch scope {
+	case identityScopeCommit:
+		return newEnvConfig()
+	case identityScopeLocal:
+		return &fileConfig{runner: runner, scope: "--local"}
+	default:
+		return &fileConfig{runner: runner, scope: "--global"}
+	}
+}
+
+// fileConfig writes with `git config --global` or `--local` and remembers
+// what it replaced, so restore can leave the file the way it found it.
+type fileConfig struct {
+	runner   command.Runner
+	scope    string        // "--global" or "--local"
+	previous []configValue // in the order the keys were first set
+}
+
+type configValue struct {
+	key   string
+	value string
+	set   bool // for remembered values: false when the key did not exist
+}
+
+func (g *fileConfig) set(key, value string) error {
+	if !g.remembers(key) {
+		out, err := g.runner.Capture("git", "config", g.scope, "--get", key)
+		switch {
+		case err == nil:
+			g.previous = append(g.previous, configValue{key: key, value: strings.TrimRight(out, "\r\n"), set: true})
+		case command.IsExitCode(err, 1): // the key is not set
+			g.previous = append(g.previous, configValue{key: key})
+		default:
+			return fmt.Errorf("failed to read git config %s: %w", key, err)
+		}
+	}
+
+	if err := g.runner.Run("git", "config", g.scope, key, value); err != nil {
+		return fmt.Errorf("failed to set git config %s: %w", key, err)
+	}
+	return nil
+}
+
+func (g *fileConfig) remembers(key string) bool {
+	for _, v := range g.previous {
+		if v.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// restore is best-effort: it runs on exit, when there is nobody left to handle an error.
+func (g *fileConfig) restore() {
+	for i := len(g.previous) - 1; i >= 0; i-- {
+		v := g.previous[i]
+
+		var err error
+		if v.set {
+			err = g.runner.Run("git", "config", g.scope, v.key, v.value)
+		} else {
+			err = g.runner.Run("git", "config", g.scope, "--unset", v.key)
+		}
+		if err != nil {
+			slog.Warn("cannot restore git config", "key", v.key, "error", err)
+		}
+	}
+	g.previous = nil
+}
+
+// envConfig passes values through GIT_CONFIG_COUNT, GIT_CONFIG_KEY_<n> and
+// GIT_CONFIG_VALUE_<n> (git 2.31+), which act like `git -c key=value` for every
+// git command this process starts. No config file is touched. Pairs set
+// before the step are kept; ours are appended after them.
+type envConfig struct {
+	base      int    // GIT_CONFIG_COUNT when the step started
+	prevCount string // its raw value, restored on exit
+	hadCount  bool
+	next      int
+}
+
+func newEnvConfig() *envConfig {
+	prev, had := os.LookupEnv("GIT_CONFIG_COUNT")
+	base, err := strconv.Atoi(strings.TrimSpace(prev))
+	if err != nil || base < 0 {
+		base = 0
+	}
+	return &envConfig{base: base, prevCount: prev, hadCount: had, next: base}
+}
+
+func (e *envConfig) set(key, value string) error {
+	n := strconv.Itoa(e.next)
+	if err := os.Setenv("GIT_CONFIG_KEY_"+n, key); err != nil {
+		return fmt.Errorf("failed to pass git config %s: %w", key, err)
+	}
+	if err := os.Setenv("GIT_CONFIG_VALUE_"+n, value); err != nil {
+		return fmt.Errorf("failed to pass git config %s: %w", key, err)
+	}
+	e.next++
+	if err := os.Setenv("GIT_CONFIG_COUNT", strconv.Itoa(e.next)); err != nil {
+		return fmt.Errorf("failed to pass git config %s: %w", key, err)
+	}
+	return nil
+}
+
+func (e *envConfig) restore() {
+	for i := e.base; i < e.next; i++ {
+		n := strconv.Itoa(i)
+		_ = os.Unsetenv("GIT_CONFIG_KEY_" + n)
+		_ = os.Unsetenv("GIT_CONFIG_VALUE_" + n)
+	}
+	if e.hadCount {
+		_ = os.Setenv("GIT_CONFIG_COUNT", e.prevCount)
+	} else {
+		_ = os.Unsetenv("GIT_CONFIG_COUNT")
+	}
+	e.next = e.base
+}
@@ -0,0 +1,166 @@
+package commitchanges
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNewConfigWriter(t *testing.T) {
+	runner := &MockCommandRunner{}
+
+	tests := []struct {
+		scope string
+		want  string
+	}{
+		{scope: "", want: "--global"},
+		{scope: identityScopeGlobal, want: "--global"},
+		{scope: identityScopeLocal, want: "--local"},
+	}
+	for _, tt := range tests {
+		w, ok := newConfigWriter(tt.scope, runner).(*fileConfig)
+		if !ok || w.scope != tt.want {
+			t.Errorf("newConfigWriter(%q) = %+v, want a %s file config", tt.scope, w, tt.want)
+		}
+	}
+
+	if _, ok := newConfigWriter(identityScopeCommit, runner).(*envConfig); !ok {
+		t.Error("the commit scope must not write config files")
+	}
+}
+
+func TestFileConfig_Restore(t *testing.T) {
+	var runs []string
+	runner := &MockCommandRunner{
+		CaptureFunc: func(name string, args ...string) (string, error) {
+			if args[len(args)-1] == "gpg.format" {
+				return "openpgp\n", nil
+			}
+			return "", &mockExitError{code: 1}
+		},
+		RunFunc: func(name string, args ...string) error {
+			runs = append(runs, strings.Join(args, " "))
+			return nil
+		},
+	}
+
+	cfg := &fileConfig{runner: runner, scope: "--global"}
+	for _, v := range []configValue{{key: "gpg.format", value: "ssh"}, {key: "user.signingkey", value: "/tmp/key"}, {key: "gpg.format", value: "ssh"}} {
+		if err := cfg.set(v.key, v.value); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	cfg.restore()
+
+	want := []string{
+		"config --global gpg.format ssh",
+		"config --global user.signingkey /tmp/key",
+		"config --global gpg.format ssh",
+		"config --global --unset user.signingkey",
+		"config --global gpg.format openpgp",
+	}
+	if !slices.Equal(runs, want) {
+		t.Fatalf("calls mismatch:\ngot  %q\nwant %q", runs, want)
+	}
+}
+
+func TestFileConfig_LocalScope(t *testing.T) {
+	var calls []string
+	runner := recordingRunner(&calls, func([]string) (string, error) {
+		return "", &mockExitError{code: 1}
+	})
+
+	cfg := &fileConfig{runner: runner, scope: "--local"}
+	if err := cfg.set("user.name", "bot"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg.restore()
+
+	want := []string{
+		"config --local --get user.name",
+		"config --local user.name bot",
+		"config --local --unset user.name",
+	}
+	if !slices.Equal(calls, want) {
+		t.Fatalf("calls mismatch:\ngot  %q\nwant %q", calls, want)
+	}
+}
+
+func TestFileConfig_ReadFailure(t *testing.T) {
+	runner := &MockCommandRunner{
+		CaptureFunc: func(name string, args ...string) (string, error) {
+			return "", &mockExitError{code: 128}
+		},
+		RunFunc: func(name string, args ...string) error {
+			t.Fatalf("nothing should be set when the old value cannot be read: %v", args)
+			return nil
+		},
+	}
+
+	if err := (&fileConfig{runner: runner, scope: "--global"}).set("gpg.format", "ssh"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestEnvConfig_AppendsAndRestores(t *testing.T) {
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "safe.directory")
+	t.Setenv("GIT_CONFIG_VALUE_0", "*")
+
+	cfg := newEnvConfig()
+	if err := cfg.set("user.name", "bot"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cfg.set("user.email", "bot@example.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for key, want := range map[string]string{
+		"GIT_CONFIG_COUNT":   "3",
+		"GIT_CONFIG_KEY_0":   "safe.directory",
+		"GIT_CONFIG_KEY_1":   "user.name",
+		"GIT_CONFIG_VALUE_1": "bot",
+		"GIT_CONFIG_KEY_2":   "user.email",
+		"GIT_CONFIG_VALUE_2": "bot@example.com",
+	} {
+		if got := os.Getenv(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+
+	cfg.restore()
+
+	if got := os.Getenv("GIT_CONFIG_COUNT"); got != "1" {
+		t.Errorf("GIT_CONFIG_COUNT = %q, want 1", got)
+	}
+	if got := os.Getenv("GIT_CONFIG_KEY_0"); got != "safe.directory" {
+		t.Errorf("existing pair removed, GIT_CONFIG_KEY_0 = %q", got)
+	}
+	for _, key := range []string{"GIT_CONFIG_KEY_1", "GIT_CONFIG_VALUE_1", "GIT_CONFIG_KEY_2", "GIT_CONFIG_VALUE_2"} {
+		if _, ok := os.LookupEnv(key); ok {
+			t.Errorf("%s should be unset", key)
+		}
+	}
+}
+
+func TestEnvConfig_UnsetsCount(t *testing.T) {
+	t.Setenv("GIT_CONFIG_COUNT", "")
+	os.Unsetenv("GIT_CONFIG_COUNT")
+
+	cfg := newEnvConfig()
+	if err := cfg.set("user.name", "bot"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := os.Getenv("GIT_CONFIG_KEY_0"); got != "user.name" {
+		t.Fatalf("GIT_CONFIG_KEY_0 = %q", got)
+	}
+
+	cfg.restore()
+
+	for _, key := range []string{"GIT_CONFIG_COUNT", "GIT_CONFIG_KEY_0", "GIT_CONFIG_VALUE_0"} {
+		if _, ok := os.LookupEnv(key); ok {
+			t.Errorf("%s should be unset", key)
+		}
+	}
+}
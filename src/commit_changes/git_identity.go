@@ -1,33 +1,91 @@
-package main
+package commitchanges
 
 import (
 	"fmt"
+	"strings"
+
+	"github.com/lokalise/lokalise-pull-action/src/command"
+	"github.com/lokalise/lokalise-pull-action/src/errkind"
 )
 
 // setGitUser ensures git has user.name/user.email configured,
 // defaulting to the GitHub actor with a noreply email if not provided by inputs.
-func setGitUser(config *Config, runner CommandRunner) error {
+// GIT_IDENTITY_SCOPE decides where they go (see newConfigWriter). With
+// GIT_SIGNING_KEY it also sets up signing (see setupSigning). The returned
+// cleanup removes the key and restores the previous config, and must run on exit.
+func setGitUser(config *Config, runner command.Runner) (cleanup func(), err error) {
 	username, email := resolveGitIdentity(config)
 
-	if err := runner.Run("git", "config", "--global", "user.name", username); err != nil {
-		return fmt.Errorf("failed to set git user.name: %w", err)
+	gitConfig := newConfigWriter(config.IdentityScope, runner)
+	cleanup = gitConfig.restore
+
+	if err := gitConfig.set("user.name", username); err != nil {
+		return cleanup, fmt.Errorf("failed to set git user.name: %w", err)
+	}
+	if err := gitConfig.set("user.email", email); err != nil {
+		return cleanup, fmt.Errorf("failed to set git user.email: %w", err)
+	}
+
+	// The api backend has GitHub sign the commit.
+	if config.SigningKey == "" || config.CommitBackend == commitBackendAPI {
+		return cleanup, nil
 	}
-	if err := runner.Run("git", "config", "--global", "user.email", email); err != nil {
-		return fmt.Errorf("failed to set git user.email: %w", err)
+
+	signing, err := setupSigning(config, email, gitConfig, runner)
+	if err != nil {
+		return cleanup, errkind.With(errkind.Config, err)
 	}
-	return nil
+
+	return func() {
+		signing.cleanup()
+		gitConfig.restore()
+	}, nil
 }
 
 func resolveGitIdentity(config *Config) (username, email string) {
 	username = config.GitUserName
 	if username == "" {
-		username = config.GitHubActor
+		username = config.Actor
 	}
 
 	email = config.GitUserEmail
 	if email == "" {
-		email = fmt.Sprintf("%s@users.noreply.github.com", config.GitHubActor)
+		email = fmt.Sprintf("%s@users.noreply.github.com", config.Actor)
 	}
 
 	return username, email
 }
+
+// commitAuthor returns the --author value for GIT_COMMIT_AUTHOR_NAME/EMAIL,
+// or "" when neither is set and the committer is also the author. A missing
+// half falls back to the committer identity.
+func commitAuthor(config *Config) string {
+	if config.GitAuthorName == "" && config.GitAuthorEmail == "" {
+		return ""
+	}
+
+	name, email := resolveGitIdentity(config)
+	if config.GitAuthorName != "" {
+		name = config.GitAuthorName
+	}
+	if config.GitAuthorEmail != "" {
+		email = config.GitAuthorEmail
+	}
+
+	return fmt.Sprintf("%s <%s>", name, email)
+}
+
+// commitMessage appends a Co-authored-by trailer for every GIT_CO_AUTHORS entry.
+func commitMessage(config *Config) string {
+	if len(config.CoAuthors) == 0 {
+		return config.GitCommitMessage
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(config.GitCommitMessage, "\n"))
+	b.WriteString("\n")
+	for _, coAuthor := range config.CoAuthors {
+		b.WriteString("\nCo-authored-by: " + coAuthor)
+	}
+	return b.String()
+}
@@ -1,8 +1,15 @@
-package main
+package commitchanges
 
 import (
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+
+	"github.com/lokalise/lokalise-pull-action/src/command"
 )
 
 func TestSetGitUser(t *testing.T) {
@@ -21,10 +28,10 @@ func TestSetGitUser(t *testing.T) {
 	}
 
 	config := &Config{
-		GitHubActor: "test_actor",
+		Actor: "test_actor",
 	}
 
-	err := setGitUser(config, runner)
+	_, err := setGitUser(config, runner)
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
@@ -46,12 +53,12 @@ func TestSetGitUser_WithCustomValues(t *testing.T) {
 	}
 
 	config := &Config{
-		GitHubActor:  "ignored_actor",
+		Actor:        "ignored_actor",
 		GitUserName:  "custom_user",
 		GitUserEmail: "custom_email@example.com",
 	}
 
-	err := setGitUser(config, runner)
+	_, err := setGitUser(config, runner)
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
@@ -67,7 +74,7 @@ func TestResolveGitIdentity(t *testing.T) {
 		{
 			name: "default actor and noreply email",
 			config: &Config{
-				GitHubActor: "test_actor",
+				Actor: "test_actor",
 			},
 			wantUserName:  "test_actor",
 			wantUserEmail: "test_actor@users.noreply.github.com",
@@ -75,7 +82,7 @@ func TestResolveGitIdentity(t *testing.T) {
 		{
 			name: "custom name and custom email",
 			config: &Config{
-				GitHubActor:  "ignored_actor",
+				Actor:        "ignored_actor",
 				GitUserName:  "custom_user",
 				GitUserEmail: "custom_email@example.com",
 			},
@@ -85,7 +92,7 @@ func TestResolveGitIdentity(t *testing.T) {
 		{
 			name: "custom name only gets custom noreply email",
 			config: &Config{
-				GitHubActor: "ignored_actor",
+				Actor:       "ignored_actor",
 				GitUserName: "custom_user",
 			},
 			wantUserName:  "custom_user",
@@ -106,3 +113,162 @@ func TestResolveGitIdentity(t *testing.T) {
 		})
 	}
 }
+
+func TestSetGitUser_LocalScopeRestoresPreviousIdentity(t *testing.T) {
+	var calls []string
+	runner := recordingRunner(&calls, func(args []string) (string, error) {
+		if args[len(args)-1] == "user.name" {
+			return "Developer\n", nil
+		}
+		return "", &mockExitError{code: 1}
+	})
+
+	cleanup, err := setGitUser(&Config{Actor: "bot", IdentityScope: identityScopeLocal}, runner)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cleanup()
+
+	want := []string{
+		"config --local --get user.name",
+		"config --local user.name bot",
+		"config --local --get user.email",
+		"config --local user.email bot@users.noreply.github.com",
+		"config --local --unset user.email",
+		"config --local user.name Developer",
+	}
+	if !slices.Equal(calls, want) {
+		t.Fatalf("calls mismatch:\ngot  %q\nwant %q", calls, want)
+	}
+}
+
+func TestCommitAuthor(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		want   string
+	}{
+		{
+			name:   "committer is the author",
+			config: &Config{Actor: "bot"},
+		},
+		{
+			name:   "separate author",
+			config: &Config{Actor: "bot", GitAuthorName: "Jane Doe", GitAuthorEmail: "jane@example.com"},
+			want:   "Jane Doe <jane@example.com>",
+		},
+		{
+			name:   "author name only keeps the committer email",
+			config: &Config{Actor: "bot", GitUserEmail: "bot@example.com", GitAuthorName: "Jane Doe"},
+			want:   "Jane Doe <bot@example.com>",
+		},
+		{
+			name:   "author email only keeps the committer name",
+			config: &Config{Actor: "bot", GitAuthorEmail: "jane@example.com"},
+			want:   "bot <jane@example.com>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commitAuthor(tt.config); got != tt.want {
+				t.Fatalf("commitAuthor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommitMessage_CoAuthors(t *testing.T) {
+	config := &Config{GitCommitMessage: "Translations update\n"}
+	if got := commitMessage(config); got != config.GitCommitMessage {
+		t.Fatalf("message without co-authors changed: %q", got)
+	}
+
+	config.CoAuthors = []string{"Ann <ann@example.com>", "Bob <bob@example.com>"}
+	want := "Translations update\n\nCo-authored-by: Ann <ann@example.com>\nCo-authored-by: Bob <bob@example.com>"
+	if got := commitMessage(config); got != want {
+		t.Fatalf("commitMessage() = %q, want %q", got, want)
+	}
+}
+
+func TestBuildCommitArgs_Author(t *testing.T) {
+	config := &Config{
+		Actor:            "bot",
+		GitCommitMessage: "msg",
+		GitAuthorName:    "Jane Doe",
+		GitAuthorEmail:   "jane@example.com",
+		CoAuthors:        []string{"Ann <ann@example.com>"},
+	}
+
+	want := []string{"commit", "--author", "Jane Doe <jane@example.com>", "-m", "msg\n\nCo-authored-by: Ann <ann@example.com>"}
+	if got := buildCommitArgs(config); !slices.Equal(got, want) {
+		t.Fatalf("buildCommitArgs() = %q, want %q", got, want)
+	}
+}
+
+// TestCommitScope_RealGit commits with GIT_IDENTITY_SCOPE=commit and checks
+// that neither the global nor the repository config was written.
+func TestCommitScope_RealGit(t *testing.T) {
+	requireBinaries(t, "git")
+	tmp := t.TempDir()
+
+	globalConfigFile := filepath.Join(tmp, "gitconfig")
+	globalConfig := "[user]\n\tname = Developer\n\temail = dev@example.com\n"
+	if err := os.WriteFile(globalConfigFile, []byte(globalConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", globalConfigFile)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_CONFIG_COUNT", "")
+
+	repo := filepath.Join(tmp, "repo")
+	if out, err := exec.Command("git", "init", "-q", repo).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	t.Chdir(repo)
+
+	runner := command.Default{}
+	config := &Config{
+		Actor:            "bot",
+		GitUserEmail:     "bot@example.com",
+		GitCommitMessage: "Translations update",
+		IdentityScope:    identityScopeCommit,
+		GitAuthorName:    "Jane Doe",
+		GitAuthorEmail:   "jane@example.com",
+		CoAuthors:        []string{"Ann <ann@example.com>"},
+	}
+	cleanup, err := setGitUser(config, runner)
+	if err != nil {
+		t.Fatalf("setGitUser: %v", err)
+	}
+
+	if err := os.WriteFile("fr.json", []byte(`{"hello":"bonjour"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.Run("git", "add", "fr.json"); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := runner.Capture("git", buildCommitArgs(config)...); err != nil {
+		t.Fatalf("git commit: %v\n%s", err, out)
+	}
+
+	cleanup()
+
+	out, err := runner.Capture("git", "log", "-1", "--format=%an <%ae>|%cn <%ce>|%(trailers:key=Co-authored-by,valueonly)")
+	if err != nil {
+		t.Fatalf("git log: %v", err)
+	}
+	if got, want := strings.TrimSpace(out), "Jane Doe <jane@example.com>|bot <bot@example.com>|Ann <ann@example.com>"; got != want {
+		t.Fatalf("commit identities = %q, want %q", got, want)
+	}
+
+	if data, _ := os.ReadFile(globalConfigFile); string(data) != globalConfig {
+		t.Fatalf("global config changed:\n%s", data)
+	}
+	if out, err := runner.Capture("git", "config", "--local", "--get", "user.name"); err == nil {
+		t.Fatalf("user.name written to the repository config: %q", out)
+	}
+	if _, ok := os.LookupEnv("GIT_CONFIG_KEY_0"); ok {
+		t.Fatal("the identity must not outlive the step")
+	}
+}
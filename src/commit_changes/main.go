@@ -1,116 +1,128 @@
-package main
+// Package commitchanges stages translation files (with inclusion/exclusion rules),
+// creates a commit on a temp (or overridden) branch, and pushes it to origin
+// (or to the remote or URL configured with GIT_PUSH_REMOTE / GIT_PUSH_URL).
+// The PR itself is handled by a separate step.
+//
+// Design goals:
+// - Work both on normal push workflows and PR workflows.
+// - Respect "flat" vs "nested" i18n layouts.
+// - Optionally exclude base language changes (to reduce noisy diffs).
+// - Be idempotent over repeated runs with the same override branch.
+package commitchanges
 
 import (
-	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
-	"os/exec"
+	"log/slog"
+	"strconv"
 	"strings"
 
-	"github.com/bodrovis/lokalise-actions-common/v2/githuboutput"
+	"github.com/lokalise/lokalise-pull-action/src/actionlog/summary"
+	"github.com/lokalise/lokalise-pull-action/src/command"
+	"github.com/lokalise/lokalise-pull-action/src/errkind"
 )
 
-// This program stages translation files (with inclusion/exclusion rules),
-// creates a commit on a temp (or overridden) branch, and pushes it to origin.
-// The PR itself is handled by a separate script.
-//
-// Design goals:
-// - Work both on normal push workflows and PR workflows.
-// - Respect "flat" vs "nested" i18n layouts.
-// - Optionally exclude base language changes (to reduce noisy diffs).
-// - Be idempotent over repeated runs with the same override branch.
-
 // ErrNoChanges is returned when there is nothing staged to commit.
 var ErrNoChanges = errors.New("no changes to commit")
 
-var exitFunc = os.Exit
-
-// CommandRunner abstracts git invocations for testability.
-type CommandRunner interface {
-	Run(name string, args ...string) error
-	Capture(name string, args ...string) (string, error)
-}
-
-// DefaultCommandRunner pipes git stdout/stderr to the current process for visibility.
-type DefaultCommandRunner struct{}
-
-func (d DefaultCommandRunner) Run(name string, args ...string) error {
-	cmd := exec.Command(name, args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
-}
-
-// Capture returns combined stdout+stderr as a string, useful for parsing or error messages.
-func (d DefaultCommandRunner) Capture(name string, args ...string) (string, error) {
-	var out bytes.Buffer
-	cmd := exec.Command(name, args...)
-	cmd.Stdout = &out
-	cmd.Stderr = &out
-	err := cmd.Run()
-	return out.String(), err
-}
-
-type commitFunc func(CommandRunner) (string, error)
-
-func main() {
-	if err := run(); err != nil {
-		returnWithError(err.Error())
-	}
-}
+type commitFunc func(command.Runner) (commitResult, error)
 
-func run() error {
-	return runWith(
+// Main runs the commit step configured by the environment and returns the
+// process exit code. Outputs, including the error_kind of a failure, go to write.
+func Main(write func(string, string) bool) int {
+	err := runWith(
 		commitAndPushChanges,
-		githuboutput.WriteToGitHubOutput,
-		DefaultCommandRunner{},
+		write,
+		command.Default{},
+		summary.WriteToStepSummary,
 	)
+	if err != nil {
+		return errkind.Report(err, errkind.Of(err), write)
+	}
+	return 0
 }
 
 func runWith(
 	commit commitFunc,
 	write func(string, string) bool,
-	runner CommandRunner,
+	runner command.Runner,
+	summarize func(string) bool,
 ) error {
-	branchName, err := performCommit(commit, runner)
+	res, err := performCommit(commit, runner)
+	writeCommitSummary(res, err, summarize)
 	if err != nil {
 		return err
 	}
 
-	return writeOutputs(branchName, write)
+	if res.NoChanges {
+		// Not a failure, but workflows may want to tell it apart from a skipped step.
+		if !write(errkind.Output, string(errkind.NothingToCommit)) {
+			return fmt.Errorf("failed to write to GitHub output")
+		}
+		return nil
+	}
+
+	return writeOutputs(res, write)
 }
 
 func performCommit(
 	commit commitFunc,
-	runner CommandRunner,
-) (string, error) {
-	branchName, err := commit(runner)
+	runner command.Runner,
+) (commitResult, error) {
+	res, err := commit(runner)
 	if err != nil {
 		if errors.Is(err, ErrNoChanges) {
-			fmt.Fprintln(os.Stderr, "No changes detected, exiting")
-			return "", nil
+			slog.Info("No changes detected, exiting")
+			res.NoChanges = true
+			return res, nil
 		}
 
-		return "", fmt.Errorf("error committing and pushing changes: %w", err)
+		return res, errkind.With(errkind.Git, fmt.Errorf("error committing and pushing changes: %w", err))
 	}
 
-	return branchName, nil
+	return res, nil
 }
 
+// writeOutputs reports the branch and, once a commit exists, its SHAs, diff
+// stats, the committed files grouped by language (as a JSON object) and
+// whether the local branch points at it.
 func writeOutputs(
-	branchName string,
+	res commitResult,
 	write func(string, string) bool,
 ) error {
-	if branchName == "" {
+	if res.Branch == "" {
 		return nil
 	}
 
-	if !write("branch_name", branchName) ||
+	if !write("branch_name", res.Branch) ||
 		!write("commit_created", "true") {
 		return fmt.Errorf("failed to write to GitHub output")
 	}
 
+	if res.CommitSHA == "" {
+		return nil
+	}
+
+	languages := res.Languages
+	if languages == nil {
+		languages = map[string][]string{}
+	}
+	filesByLanguage, err := json.Marshal(languages)
+	if err != nil {
+		return fmt.Errorf("failed to encode files by language: %w", err)
+	}
+
+	if !write("commit_sha", res.CommitSHA) ||
+		!write("parent_sha", res.Stats.ParentSHA) ||
+		!write("files_changed", strconv.Itoa(len(res.Stats.Files))) ||
+		!write("insertions", strconv.Itoa(res.Stats.Insertions)) ||
+		!write("deletions", strconv.Itoa(res.Stats.Deletions)) ||
+		!write("files_by_language", string(filesByLanguage)) ||
+		!write("local_branch_synced", strconv.FormatBool(!res.Stats.LocalBehind)) {
+		return fmt.Errorf("failed to write to GitHub output")
+	}
+
 	return nil
 }
 
@@ -126,20 +138,6 @@ func splitNonEmptyLines(s string) []string {
 	return res
 }
 
-// isExitCode checks whether err has the given exit code.
-// Supports both *exec.ExitError and any custom error type implementing ExitCode() int.
-func isExitCode(err error, code int) bool {
-	type exitCoderError interface {
-		error
-		ExitCode() int
-	}
-
-	if ec, ok := errors.AsType[exitCoderError](err); ok {
-		return ec.ExitCode() == code
-	}
-	return false
-}
-
 // sanitizeString whitelists characters acceptable for git refs and trims to maxLength.
 // Allowed: letters, digits, underscore, hyphen, slash, dot.
 // Notes: We intentionally allow "/" to keep hierarchical branch names.
@@ -165,8 +163,3 @@ func sanitizeString(input string, maxLength int) string {
 	}
 	return result
 }
-
-func returnWithError(message string) {
-	fmt.Fprintf(os.Stderr, "Error: %s\n", message)
-	exitFunc(1)
-}
@@ -1,15 +1,20 @@
-package main
+package commitchanges
 
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"strings"
 	"testing"
+
+	"github.com/lokalise/lokalise-pull-action/src/command"
+	"github.com/lokalise/lokalise-pull-action/src/errkind"
 )
 
 type MockCommandRunner struct {
 	RunFunc     func(name string, args ...string) error
 	CaptureFunc func(name string, args ...string) (string, error)
+	OutputFunc  func(name string, args ...string) ([]byte, error)
 }
 
 func (m *MockCommandRunner) Run(name string, args ...string) error {
@@ -26,6 +31,15 @@ func (m *MockCommandRunner) Capture(name string, args ...string) (string, error)
 	return "", nil
 }
 
+func (m *MockCommandRunner) Output(name string, args ...string) ([]byte, error) {
+	if m.OutputFunc != nil {
+		return m.OutputFunc(name, args...)
+	}
+	return nil, nil
+}
+
+func noSummary(string) bool { return true }
+
 type mockExitError struct{ code int }
 
 func (e *mockExitError) Error() string { return fmt.Sprintf("exit status %d", e.code) }
@@ -42,7 +56,7 @@ func TestRunWith_Success(t *testing.T) {
 	var gotBranchName string
 	outputs := map[string]string{}
 
-	commit := func(gotRunner CommandRunner) (string, error) {
+	commit := func(gotRunner command.Runner) (commitResult, error) {
 		commitCalled = true
 
 		if gotRunner != runner {
@@ -50,7 +64,7 @@ func TestRunWith_Success(t *testing.T) {
 		}
 
 		gotBranchName = "lokalise/update-translations"
-		return gotBranchName, nil
+		return commitResult{Branch: gotBranchName}, nil
 	}
 
 	write := func(key, value string) bool {
@@ -59,7 +73,7 @@ func TestRunWith_Success(t *testing.T) {
 		return true
 	}
 
-	err := runWith(commit, write, runner)
+	err := runWith(commit, write, runner, noSummary)
 	if err != nil {
 		t.Fatalf("runWith returned unexpected error: %v", err)
 	}
@@ -91,24 +105,24 @@ func TestRunWith_Success_WhenNoChanges(t *testing.T) {
 	runner := &MockCommandRunner{}
 
 	commitCalled := false
-	writeCalled := false
+	outputs := map[string]string{}
 
-	commit := func(gotRunner CommandRunner) (string, error) {
+	commit := func(gotRunner command.Runner) (commitResult, error) {
 		commitCalled = true
 
 		if gotRunner != runner {
 			t.Fatalf("commit got unexpected runner")
 		}
 
-		return "", ErrNoChanges
+		return commitResult{}, ErrNoChanges
 	}
 
 	write := func(key, value string) bool {
-		writeCalled = true
+		outputs[key] = value
 		return true
 	}
 
-	err := runWith(commit, write, runner)
+	err := runWith(commit, write, runner, noSummary)
 	if err != nil {
 		t.Fatalf("runWith returned unexpected error: %v", err)
 	}
@@ -117,8 +131,8 @@ func TestRunWith_Success_WhenNoChanges(t *testing.T) {
 		t.Fatal("expected commit to be called")
 	}
 
-	if writeCalled {
-		t.Fatal("write should not be called when there are no changes")
+	if len(outputs) != 1 || outputs["error_kind"] != "nothing_to_commit" {
+		t.Fatalf("expected only error_kind=nothing_to_commit, got %v", outputs)
 	}
 }
 
@@ -127,12 +141,12 @@ func TestRunWith_ReturnsError_WhenCommitFails(t *testing.T) {
 
 	runner := &MockCommandRunner{}
 
-	commit := func(gotRunner CommandRunner) (string, error) {
+	commit := func(gotRunner command.Runner) (commitResult, error) {
 		if gotRunner != runner {
 			t.Fatalf("commit got unexpected runner")
 		}
 
-		return "", errors.New("push failed")
+		return commitResult{}, errors.New("push failed")
 	}
 
 	write := func(key, value string) bool {
@@ -140,7 +154,7 @@ func TestRunWith_ReturnsError_WhenCommitFails(t *testing.T) {
 		return true
 	}
 
-	err := runWith(commit, write, runner)
+	err := runWith(commit, write, runner, noSummary)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -155,19 +169,19 @@ func TestRunWith_ReturnsError_WhenWriteFails(t *testing.T) {
 
 	runner := &MockCommandRunner{}
 
-	commit := func(gotRunner CommandRunner) (string, error) {
+	commit := func(gotRunner command.Runner) (commitResult, error) {
 		if gotRunner != runner {
 			t.Fatalf("commit got unexpected runner")
 		}
 
-		return "lokalise/update-translations", nil
+		return commitResult{Branch: "lokalise/update-translations"}, nil
 	}
 
 	write := func(key, value string) bool {
 		return false
 	}
 
-	err := runWith(commit, write, runner)
+	err := runWith(commit, write, runner, noSummary)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -182,21 +196,21 @@ func TestPerformCommit_Success(t *testing.T) {
 
 	runner := &MockCommandRunner{}
 
-	commit := func(gotRunner CommandRunner) (string, error) {
+	commit := func(gotRunner command.Runner) (commitResult, error) {
 		if gotRunner != runner {
 			t.Fatalf("commit got unexpected runner")
 		}
 
-		return "feature/generated-translations", nil
+		return commitResult{Branch: "feature/generated-translations"}, nil
 	}
 
-	branchName, err := performCommit(commit, runner)
+	res, err := performCommit(commit, runner)
 	if err != nil {
 		t.Fatalf("performCommit returned unexpected error: %v", err)
 	}
 
-	if branchName != "feature/generated-translations" {
-		t.Fatalf("unexpected branch name: %q", branchName)
+	if res.Branch != "feature/generated-translations" {
+		t.Fatalf("unexpected branch name: %q", res.Branch)
 	}
 }
 
@@ -205,21 +219,21 @@ func TestPerformCommit_NoChanges(t *testing.T) {
 
 	runner := &MockCommandRunner{}
 
-	commit := func(gotRunner CommandRunner) (string, error) {
+	commit := func(gotRunner command.Runner) (commitResult, error) {
 		if gotRunner != runner {
 			t.Fatalf("commit got unexpected runner")
 		}
 
-		return "", ErrNoChanges
+		return commitResult{}, ErrNoChanges
 	}
 
-	branchName, err := performCommit(commit, runner)
+	res, err := performCommit(commit, runner)
 	if err != nil {
 		t.Fatalf("performCommit returned unexpected error: %v", err)
 	}
 
-	if branchName != "" {
-		t.Fatalf("expected empty branch name, got %q", branchName)
+	if res.Branch != "" || !res.NoChanges {
+		t.Fatalf("expected an empty result without changes, got %+v", res)
 	}
 }
 
@@ -228,21 +242,21 @@ func TestPerformCommit_ReturnsWrappedError(t *testing.T) {
 
 	runner := &MockCommandRunner{}
 
-	commit := func(gotRunner CommandRunner) (string, error) {
+	commit := func(gotRunner command.Runner) (commitResult, error) {
 		if gotRunner != runner {
 			t.Fatalf("commit got unexpected runner")
 		}
 
-		return "", errors.New("git status failed")
+		return commitResult{}, errors.New("git status failed")
 	}
 
-	branchName, err := performCommit(commit, runner)
+	res, err := performCommit(commit, runner)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
 
-	if branchName != "" {
-		t.Fatalf("expected empty branch name, got %q", branchName)
+	if res.Branch != "" {
+		t.Fatalf("expected empty branch name, got %q", res.Branch)
 	}
 
 	if !strings.Contains(err.Error(), "error committing and pushing changes: git status failed") {
@@ -262,7 +276,7 @@ func TestWriteOutputs_Success(t *testing.T) {
 		return true
 	}
 
-	err := writeOutputs("feature/generated-translations", write)
+	err := writeOutputs(commitResult{Branch: "feature/generated-translations"}, write)
 	if err != nil {
 		t.Fatalf("writeOutputs returned unexpected error: %v", err)
 	}
@@ -280,6 +294,52 @@ func TestWriteOutputs_Success(t *testing.T) {
 	}
 }
 
+func TestWriteOutputs_WritesCommitStats(t *testing.T) {
+	t.Parallel()
+
+	outputs := map[string]string{}
+	write := func(key, value string) bool {
+		outputs[key] = value
+		return true
+	}
+
+	res := commitResult{
+		Branch:    "lok_main_1",
+		CommitSHA: "abc123",
+		Stats: commitStats{
+			SHA:        "abc123",
+			ParentSHA:  "def456",
+			Files:      []string{"locales/de.json", "locales/fr.json", "lokalise.lock"},
+			Insertions: 12,
+			Deletions:  4,
+		},
+		Languages: map[string][]string{
+			"de":    {"locales/de.json"},
+			"fr":    {"locales/fr.json"},
+			"other": {"lokalise.lock"},
+		},
+	}
+
+	if err := writeOutputs(res, write); err != nil {
+		t.Fatalf("writeOutputs returned unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"branch_name":         "lok_main_1",
+		"commit_created":      "true",
+		"commit_sha":          "abc123",
+		"parent_sha":          "def456",
+		"files_changed":       "3",
+		"insertions":          "12",
+		"deletions":           "4",
+		"files_by_language":   `{"de":["locales/de.json"],"fr":["locales/fr.json"],"other":["lokalise.lock"]}`,
+		"local_branch_synced": "true",
+	}
+	if !maps.Equal(outputs, want) {
+		t.Fatalf("outputs mismatch:\ngot  %v\nwant %v", outputs, want)
+	}
+}
+
 func TestWriteOutputs_Success_WithEmptyBranchName(t *testing.T) {
 	t.Parallel()
 
@@ -290,7 +350,7 @@ func TestWriteOutputs_Success_WithEmptyBranchName(t *testing.T) {
 		return true
 	}
 
-	err := writeOutputs("", write)
+	err := writeOutputs(commitResult{}, write)
 	if err != nil {
 		t.Fatalf("writeOutputs returned unexpected error: %v", err)
 	}
@@ -307,7 +367,7 @@ func TestWriteOutputs_ReturnsError_WhenWriteFails(t *testing.T) {
 		return false
 	}
 
-	err := writeOutputs("feature/generated-translations", write)
+	err := writeOutputs(commitResult{Branch: "feature/generated-translations"}, write)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -522,9 +582,9 @@ func TestIsExitCode(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := isExitCode(tt.err, tt.code)
+			got := command.IsExitCode(tt.err, tt.code)
 			if got != tt.want {
-				t.Fatalf("isExitCode(%v, %d) = %v, want %v", tt.err, tt.code, got, tt.want)
+				t.Fatalf("command.IsExitCode(%v, %d) = %v, want %v", tt.err, tt.code, got, tt.want)
 			}
 		})
 	}
@@ -534,3 +594,21 @@ func TestIsExitCode(t *testing.T) {
 func containsSubstring(s, substr string) bool {
 	return strings.Contains(s, substr)
 }
+
+func TestPerformCommit_ClassifiesFailures(t *testing.T) {
+	t.Parallel()
+
+	_, err := performCommit(func(command.Runner) (commitResult, error) {
+		return commitResult{}, errors.New("git checkout failed")
+	}, &MockCommandRunner{})
+	if kind := errkind.Of(err); kind != errkind.Git {
+		t.Fatalf("git failures should be classified as %q, got %q", errkind.Git, kind)
+	}
+
+	_, err = performCommit(func(command.Runner) (commitResult, error) {
+		return commitResult{}, fmt.Errorf("commit: %w", errkind.With(errkind.Config, errors.New("BASE_LANG is required")))
+	}, &MockCommandRunner{})
+	if kind := errkind.Of(err); kind != errkind.Config {
+		t.Fatalf("an explicit kind must be kept, got %q", kind)
+	}
+}
@@ -0,0 +1,136 @@
+package commitchanges
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	defaultRemote = "origin"
+
+	// pushURLTracking names the remote-tracking refs of a branch fetched back
+	// from GIT_PUSH_URL when no GIT_PUSH_REMOTE is given. It keeps them apart
+	// from the upstream refs under refs/remotes/origin/.
+	pushURLTracking = "lokalise-push"
+
+	// defaultPushUser is the user name paired with GIT_PUSH_TOKEN. GitHub
+	// requires it for installation tokens, GitLab accepts any name.
+	defaultPushUser = "x-access-token"
+)
+
+// remotes tells the git commands where to fetch from and where to push to.
+// Base branches and PR heads come from fetch; the translation branch itself is
+// looked up, fetched back and pushed through push, which may be a fork.
+type remotes struct {
+	fetch    string // remote name used for base branches and the default branch
+	push     string // remote name or URL the branch is pushed to
+	tracking string // name under refs/remotes/ for branches fetched from push
+}
+
+// newRemotes applies the defaults: everything goes through origin unless
+// GIT_REMOTE, GIT_PUSH_REMOTE or GIT_PUSH_URL say otherwise.
+func newRemotes(cfg *Config) remotes {
+	r := remotes{fetch: firstNonEmpty(cfg.Remote, defaultRemote)}
+	r.push = firstNonEmpty(cfg.PushRemote, r.fetch)
+	r.tracking = r.push
+
+	if cfg.PushURL != "" {
+		r.push = cfg.PushURL
+		if cfg.PushToken != "" {
+			r.push, _ = splitPushUser(cfg.PushURL)
+		}
+		r.tracking = firstNonEmpty(cfg.PushRemote, pushURLTracking)
+	}
+
+	return r
+}
+
+// pushesToNamedRemote reports whether push is a configured remote, which is
+// what git needs to set the upstream of the branch.
+func (r remotes) pushesToNamedRemote() bool {
+	return r.push == r.tracking
+}
+
+// passPushToken hands GIT_PUSH_TOKEN to the git commands that talk to
+// GIT_PUSH_URL as an http.<url>.extraHeader, through the environment (see
+// envConfig). A token in the URL would be on the command line of git, which
+// any process on the runner can read. The empty value first drops the headers
+// other config sends to the same URL, such as the one actions/checkout persists.
+// The returned cleanup removes the header again.
+func passPushToken(cfg *Config) (cleanup func(), err error) {
+	if cfg.PushURL == "" || cfg.PushToken == "" {
+		return func() {}, nil
+	}
+
+	pushURL, user := splitPushUser(cfg.PushURL)
+	credentials := base64.StdEncoding.EncodeToString([]byte(user + ":" + cfg.PushToken))
+	key := "http." + pushURL + ".extraHeader"
+
+	env := newEnvConfig()
+	if err := env.set(key, ""); err != nil {
+		env.restore()
+		return func() {}, err
+	}
+	if err := env.set(key, "Authorization: Basic "+credentials); err != nil {
+		env.restore()
+		return func() {}, err
+	}
+	return env.restore, nil
+}
+
+// splitPushUser returns the push URL without its user name, and that user
+// name, defaultPushUser when the URL has none.
+func splitPushUser(raw string) (pushURL, user string) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw, defaultPushUser
+	}
+
+	user = defaultPushUser
+	if u.User != nil && u.User.Username() != "" {
+		user = u.User.Username()
+	}
+	u.User = nil
+
+	return u.String(), user
+}
+
+// validateRemoteName rejects values git would read as an option or split on.
+func validateRemoteName(key, name string) error {
+	if name == "" {
+		return nil
+	}
+	if strings.HasPrefix(name, "-") || strings.ContainsAny(name, " \t\r\n") {
+		return fmt.Errorf("environment variable %s has incorrect value %q, expected a git remote name", key, name)
+	}
+	return nil
+}
+
+// validatePushURL checks GIT_PUSH_URL and that GIT_PUSH_TOKEN has a URL it
+// can be used with.
+func validatePushURL(rawURL, token string) error {
+	if rawURL == "" {
+		if token != "" {
+			return fmt.Errorf("environment variable GIT_PUSH_TOKEN requires GIT_PUSH_URL")
+		}
+		return nil
+	}
+
+	if strings.HasPrefix(rawURL, "-") {
+		return fmt.Errorf("environment variable GIT_PUSH_URL has incorrect value %q", rawURL)
+	}
+	if token == "" {
+		return nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("environment variable GIT_PUSH_TOKEN requires an https GIT_PUSH_URL")
+	}
+	if _, hasPassword := u.User.Password(); hasPassword {
+		return fmt.Errorf("environment variable GIT_PUSH_URL already contains credentials, drop GIT_PUSH_TOKEN or the password in the URL")
+	}
+	return nil
+}
@@ -1,54 +1,58 @@
-package main
+package commitchanges
 
 import (
 	"bufio"
-	"fmt"
+	"log/slog"
 	"strings"
+
+	"github.com/lokalise/lokalise-pull-action/src/command"
 )
 
 // resolveRealBase determines a usable base branch.
-// If cfg.BaseRef is empty/synthetic, we ask the remote what HEAD points to,
-// Uses locale-agnostic methods first and falls back to parsing `git remote show origin` best-effort.
+// If cfg.BaseRef is empty/synthetic, we ask the fetch remote (GIT_REMOTE, origin by default)
+// what HEAD points to. Uses locale-agnostic methods first and falls back to parsing
+// `git remote show <remote>` best-effort.
 //
 // Order:
-//  1. git ls-remote --symref origin HEAD  -> "ref: refs/heads/<branch> HEAD"
-//  2. git symbolic-ref --short refs/remotes/origin/HEAD -> "origin/<branch>"
-//  3. git remote show origin  -> parse "HEAD branch: <branch>" (best-effort)
+//  1. git ls-remote --symref <remote> HEAD  -> "ref: refs/heads/<branch> HEAD"
+//  2. git symbolic-ref --short refs/remotes/<remote>/HEAD -> "<remote>/<branch>"
+//  3. git remote show <remote>  -> parse "HEAD branch: <branch>" (best-effort)
 //  4. fallback "main"
-func resolveRealBase(runner CommandRunner, cfg *Config) (string, error) {
+func resolveRealBase(runner command.Runner, cfg *Config) (string, error) {
 	base := strings.TrimSpace(cfg.BaseRef)
 	if !isSyntheticRef(base) {
 		return base, nil
 	}
 
-	if br, source, ok := resolveFallbackBase(runner); ok {
-		fmt.Printf("BASE_REF synthetic/empty, using %s: %s\n", source, br)
+	remote := newRemotes(cfg).fetch
+	if br, source, ok := resolveFallbackBase(runner, remote); ok {
+		slog.Info("BASE_REF synthetic/empty, using the default branch", "source", source, "branch", br)
 		return br, nil
 	}
 
-	fmt.Println("Could not resolve default branch from origin; falling back to main")
+	slog.Warn("Could not resolve default branch from the remote; falling back to main", "remote", remote)
 
 	return "main", nil
 }
 
-func resolveFallbackBase(runner CommandRunner) (branch, source string, ok bool) {
-	if br, ok := getDefaultBranchFromLsRemote(runner); ok {
+func resolveFallbackBase(runner command.Runner, remote string) (branch, source string, ok bool) {
+	if br, ok := getDefaultBranchFromLsRemote(runner, remote); ok {
 		return br, "remote HEAD via ls-remote", true
 	}
 
-	if br, ok := getDefaultBranchFromSymbolicRef(runner); ok {
-		return br, "origin/HEAD via symbolic-ref", true
+	if br, ok := getDefaultBranchFromSymbolicRef(runner, remote); ok {
+		return br, remote + "/HEAD via symbolic-ref", true
 	}
 
-	if br, ok := getDefaultBranchFromRemoteShow(runner); ok {
-		return br, "remote show origin", true
+	if br, ok := getDefaultBranchFromRemoteShow(runner, remote); ok {
+		return br, "remote show " + remote, true
 	}
 
 	return "", "", false
 }
 
-func getDefaultBranchFromLsRemote(runner CommandRunner) (string, bool) {
-	out, err := runner.Capture("git", "ls-remote", "--symref", "origin", "HEAD")
+func getDefaultBranchFromLsRemote(runner command.Runner, remote string) (string, bool) {
+	out, err := runner.Capture("git", "ls-remote", "--symref", remote, "HEAD")
 	if err != nil || strings.TrimSpace(out) == "" {
 		return "", false
 	}
@@ -88,8 +92,8 @@ func parseLsRemoteHeadLine(line string) (string, bool) {
 	return br, true
 }
 
-func getDefaultBranchFromSymbolicRef(runner CommandRunner) (string, bool) {
-	out, err := runner.Capture("git", "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD")
+func getDefaultBranchFromSymbolicRef(runner command.Runner, remote string) (string, bool) {
+	out, err := runner.Capture("git", "symbolic-ref", "--quiet", "--short", "refs/remotes/"+remote+"/HEAD")
 	if err != nil {
 		return "", false
 	}
@@ -110,8 +114,8 @@ func parseSymbolicRefBranch(out string) (string, bool) {
 	return line, true
 }
 
-func getDefaultBranchFromRemoteShow(runner CommandRunner) (string, bool) {
-	out, err := runner.Capture("git", "remote", "show", "origin")
+func getDefaultBranchFromRemoteShow(runner command.Runner, remote string) (string, bool) {
+	out, err := runner.Capture("git", "remote", "show", remote)
 	if err != nil || strings.TrimSpace(out) == "" {
 		return "", false
 	}
@@ -1,4 +1,4 @@
-package main
+package commitchanges
 
 import (
 	"fmt"
@@ -166,7 +166,7 @@ func TestGetDefaultBranchFromLsRemote_CRLF_AndCutPrefix(t *testing.T) {
 			return out, nil
 		},
 	}
-	br, ok := getDefaultBranchFromLsRemote(r)
+	br, ok := getDefaultBranchFromLsRemote(r, "origin")
 	if !ok || br != "qa" {
 		t.Fatalf("want qa/true, got %q/%v", br, ok)
 	}
@@ -251,7 +251,7 @@ func TestGetDefaultBranchFromLsRemote_NoMatchingHeadRef(t *testing.T) {
 				},
 			}
 
-			br, ok := getDefaultBranchFromLsRemote(r)
+			br, ok := getDefaultBranchFromLsRemote(r, "origin")
 			if ok {
 				t.Fatalf("expected ok=false, got branch=%q", br)
 			}
@@ -270,7 +270,7 @@ func TestGetDefaultBranchFromSymbolicRef(t *testing.T) {
 			},
 		}
 
-		br, ok := getDefaultBranchFromSymbolicRef(r)
+		br, ok := getDefaultBranchFromSymbolicRef(r, "origin")
 		if !ok || br != "feature/x" {
 			t.Fatalf("want feature/x/true, got %q/%v", br, ok)
 		}
@@ -283,7 +283,7 @@ func TestGetDefaultBranchFromSymbolicRef(t *testing.T) {
 			},
 		}
 
-		br, ok := getDefaultBranchFromSymbolicRef(r)
+		br, ok := getDefaultBranchFromSymbolicRef(r, "origin")
 		if !ok || br != "main" {
 			t.Fatalf("want main/true, got %q/%v", br, ok)
 		}
@@ -296,7 +296,7 @@ func TestGetDefaultBranchFromSymbolicRef(t *testing.T) {
 			},
 		}
 
-		br, ok := getDefaultBranchFromSymbolicRef(r)
+		br, ok := getDefaultBranchFromSymbolicRef(r, "origin")
 		if ok || br != "" {
 			t.Fatalf("want empty/false, got %q/%v", br, ok)
 		}
@@ -309,7 +309,7 @@ func TestGetDefaultBranchFromSymbolicRef(t *testing.T) {
 			},
 		}
 
-		br, ok := getDefaultBranchFromSymbolicRef(r)
+		br, ok := getDefaultBranchFromSymbolicRef(r, "origin")
 		if ok || br != "" {
 			t.Fatalf("want empty/false, got %q/%v", br, ok)
 		}
@@ -331,7 +331,7 @@ func TestGetDefaultBranchFromRemoteShow(t *testing.T) {
 			},
 		}
 
-		br, ok := getDefaultBranchFromRemoteShow(r)
+		br, ok := getDefaultBranchFromRemoteShow(r, "origin")
 		if !ok || br != "release" {
 			t.Fatalf("want release/true, got %q/%v", br, ok)
 		}
@@ -344,7 +344,7 @@ func TestGetDefaultBranchFromRemoteShow(t *testing.T) {
 			},
 		}
 
-		br, ok := getDefaultBranchFromRemoteShow(r)
+		br, ok := getDefaultBranchFromRemoteShow(r, "origin")
 		if ok || br != "" {
 			t.Fatalf("want empty/false, got %q/%v", br, ok)
 		}
@@ -357,7 +357,7 @@ func TestGetDefaultBranchFromRemoteShow(t *testing.T) {
 			},
 		}
 
-		br, ok := getDefaultBranchFromRemoteShow(r)
+		br, ok := getDefaultBranchFromRemoteShow(r, "origin")
 		if ok || br != "" {
 			t.Fatalf("want empty/false, got %q/%v", br, ok)
 		}
@@ -370,7 +370,7 @@ func TestGetDefaultBranchFromRemoteShow(t *testing.T) {
 			},
 		}
 
-		br, ok := getDefaultBranchFromRemoteShow(r)
+		br, ok := getDefaultBranchFromRemoteShow(r, "origin")
 		if ok || br != "" {
 			t.Fatalf("want empty/false, got %q/%v", br, ok)
 		}
@@ -1,12 +1,14 @@
-package main
+package commitchanges
 
 import (
 	"fmt"
-	"os"
+	"log/slog"
 	"strings"
+
+	"github.com/lokalise/lokalise-pull-action/src/command"
 )
 
-func stashIfDirty(runner CommandRunner, msg string) (string, bool, error) {
+func stashIfDirty(runner command.Runner, msg string) (string, bool, error) {
 	out, err := runner.Capture("git", "status", "--porcelain=v1")
 	if err != nil {
 		return "", false, fmt.Errorf("failed to check git status: %w\nOutput: %s", err, out)
@@ -33,7 +35,7 @@ func stashIfDirty(runner CommandRunner, msg string) (string, bool, error) {
 	return stashRef, true, nil
 }
 
-func restoreFilesFromStash(remote, stashRef string, runner CommandRunner) error {
+func restoreFilesFromStash(remote, stashRef string, runner command.Runner) error {
 	files, err := listStashedFiles(runner, stashRef)
 	if err != nil {
 		return fmt.Errorf("checked out %s but failed to list stashed files: %w", remote, err)
@@ -48,7 +50,7 @@ func restoreFilesFromStash(remote, stashRef string, runner CommandRunner) error
 	return nil
 }
 
-func restoreFileFromStash(runner CommandRunner, stashRef, file string) error {
+func restoreFileFromStash(runner command.Runner, stashRef, file string) error {
 	if err := runner.Run("git", "checkout", stashRef, "--", file); err == nil {
 		return nil
 	} else {
@@ -62,7 +64,7 @@ func restoreFileFromStash(runner CommandRunner, stashRef, file string) error {
 	return nil
 }
 
-func listStashedFiles(runner CommandRunner, stashRef string) ([]string, error) {
+func listStashedFiles(runner command.Runner, stashRef string) ([]string, error) {
 	out, err := runner.Capture("git", "stash", "show", "--name-only", "--include-untracked", stashRef)
 	if err != nil {
 		return nil, fmt.Errorf("%w\nOutput: %s", err, out)
@@ -70,7 +72,7 @@ func listStashedFiles(runner CommandRunner, stashRef string) ([]string, error) {
 	return splitNonEmptyLines(out), nil
 }
 
-func restoreStashBestEffort(runner CommandRunner, stashHash string) {
+func restoreStashBestEffort(runner command.Runner, stashHash string) {
 	stashHash = strings.TrimSpace(stashHash)
 	if stashHash == "" {
 		return
@@ -78,16 +80,16 @@ func restoreStashBestEffort(runner CommandRunner, stashHash string) {
 
 	selector, err := findStashSelectorByHash(runner, stashHash)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to resolve stash %s for restore: %v\n", stashHash, err)
+		slog.Warn("failed to resolve stash for restore", "stash", stashHash, "error", err)
 		return
 	}
 
 	if err := runner.Run("git", "stash", "pop", selector); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to restore stash %s (%s): %v\n", selector, stashHash, err)
+		slog.Warn("failed to restore stash", "selector", selector, "stash", stashHash, "error", err)
 	}
 }
 
-func findStashSelectorByHash(runner CommandRunner, stashHash string) (string, error) {
+func findStashSelectorByHash(runner command.Runner, stashHash string) (string, error) {
 	stashHash = strings.TrimSpace(stashHash)
 	if stashHash == "" {
 		return "", fmt.Errorf("stash hash is empty")
@@ -121,7 +123,7 @@ func findStashSelectorByHash(runner CommandRunner, stashHash string) (string, er
 	return "", fmt.Errorf("created stash %s was not found in stash list", stashHash)
 }
 
-func dropStashByHash(runner CommandRunner, stashHash string) error {
+func dropStashByHash(runner command.Runner, stashHash string) error {
 	selector, err := findStashSelectorByHash(runner, stashHash)
 	if err != nil {
 		return err
@@ -0,0 +1,118 @@
+package commitchanges
+
+import (
+	"fmt"
+	"log/slog"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/lokalise/lokalise-pull-action/src/command"
+)
+
+// commitStats describes the commit created by commitAndPush.
+type commitStats struct {
+	SHA        string
+	ParentSHA  string   // empty for a root commit
+	Files      []string // repo-relative paths touched by the commit
+	Insertions int
+	Deletions  int
+	// LocalBehind is set by COMMIT_BACKEND=api when the commit exists on
+	// GitHub but the local branch could not be moved to it.
+	LocalBehind bool
+}
+
+// headCommitStats reads the SHA, parent and diff stats of HEAD. They are only
+// reported, so failures are logged and yield empty stats.
+func headCommitStats(runner command.Runner) commitStats {
+	out, err := runner.Capture("git", "-c", "core.quotepath=false", "show", "--no-renames", "--numstat", "--format=%H %P", "HEAD")
+	if err != nil {
+		slog.Warn("cannot read the commit stats", "error", err)
+		return commitStats{}
+	}
+
+	stats, err := parseCommitStats(out)
+	if err != nil {
+		slog.Warn("cannot parse the commit stats", "error", err)
+		return commitStats{}
+	}
+	return stats
+}
+
+// parseCommitStats parses `git show --numstat --format="%H %P"` output: the
+// SHAs on the first line, then "<added>\t<deleted>\t<path>" per file.
+// Binary files report "-" for both counts and only add to the file list.
+func parseCommitStats(out string) (commitStats, error) {
+	lines := splitNonEmptyLines(out)
+	if len(lines) == 0 {
+		return commitStats{}, fmt.Errorf("empty output")
+	}
+
+	var stats commitStats
+
+	shas := strings.Fields(lines[0])
+	stats.SHA = shas[0]
+	if len(shas) > 1 {
+		stats.ParentSHA = shas[1]
+	}
+
+	for _, line := range lines[1:] {
+		added, rest, ok1 := strings.Cut(line, "\t")
+		deleted, file, ok2 := strings.Cut(rest, "\t")
+		if !ok1 || !ok2 || file == "" {
+			return commitStats{}, fmt.Errorf("unexpected numstat line %q", line)
+		}
+
+		stats.Files = append(stats.Files, file)
+		stats.Insertions += numstatCount(added)
+		stats.Deletions += numstatCount(deleted)
+	}
+
+	return stats, nil
+}
+
+func numstatCount(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0 // "-" for binary files
+	}
+	return n
+}
+
+// groupFilesByLanguage keys repo-relative paths by language: the file name for
+// flat layouts (locales/fr.json), the first directory for nested ones (locales/fr/app.json).
+// Files outside the translation paths, like the lockfile, go under "other".
+func groupFilesByLanguage(config *Config, files []string) map[string][]string {
+	byLang := map[string][]string{}
+	for _, f := range files {
+		lang := languageOf(config, f)
+		byLang[lang] = append(byLang[lang], f)
+	}
+	return byLang
+}
+
+func languageOf(config *Config, file string) string {
+	file = path.Clean(strings.ReplaceAll(file, "\\", "/"))
+
+	for _, root := range config.TranslationPaths {
+		root = path.Clean(strings.ReplaceAll(root, "\\", "/"))
+
+		rel, ok := strings.CutPrefix(file, root+"/")
+		if root == "." {
+			rel, ok = file, true
+		}
+		if !ok || rel == "" {
+			continue
+		}
+
+		if config.FlatNaming {
+			base := path.Base(rel)
+			return strings.TrimSuffix(base, path.Ext(base))
+		}
+		if lang, _, found := strings.Cut(rel, "/"); found {
+			return lang
+		}
+	}
+
+	return "other"
+}
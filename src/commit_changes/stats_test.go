@@ -0,0 +1,92 @@
+package commitchanges
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCommitStats(t *testing.T) {
+	t.Parallel()
+
+	out := "abc123 def456\n\n10\t2\tlocales/fr.json\n-\t-\tassets/logo.png\n3\t0\tlocales/de été.json\n"
+
+	got, err := parseCommitStats(out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := commitStats{
+		SHA:        "abc123",
+		ParentSHA:  "def456",
+		Files:      []string{"locales/fr.json", "assets/logo.png", "locales/de été.json"},
+		Insertions: 13,
+		Deletions:  2,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseCommitStats_RootCommit(t *testing.T) {
+	t.Parallel()
+
+	got, err := parseCommitStats("abc123 \n\n1\t0\tlocales/en.json\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.SHA != "abc123" || got.ParentSHA != "" || len(got.Files) != 1 {
+		t.Fatalf("unexpected stats: %+v", got)
+	}
+}
+
+func TestParseCommitStats_Malformed(t *testing.T) {
+	t.Parallel()
+
+	for _, out := range []string{"", "abc123\n\nnot numstat\n"} {
+		if _, err := parseCommitStats(out); err == nil {
+			t.Errorf("expected error for %q", out)
+		}
+	}
+}
+
+func TestHeadCommitStats_FailureYieldsEmptyStats(t *testing.T) {
+	t.Parallel()
+
+	runner := &MockCommandRunner{
+		CaptureFunc: func(string, ...string) (string, error) {
+			return "fatal: bad revision", &mockExitError{code: 128}
+		},
+	}
+
+	if got := headCommitStats(runner); !reflect.DeepEqual(got, commitStats{}) {
+		t.Fatalf("expected empty stats, got %+v", got)
+	}
+}
+
+func TestGroupFilesByLanguage(t *testing.T) {
+	t.Parallel()
+
+	files := []string{"locales/fr.json", "locales/de.json", "lokalise.lock"}
+	flat := &Config{TranslationPaths: []string{"locales"}, FlatNaming: true}
+
+	want := map[string][]string{
+		"fr":    {"locales/fr.json"},
+		"de":    {"locales/de.json"},
+		"other": {"lokalise.lock"},
+	}
+	if got := groupFilesByLanguage(flat, files); !reflect.DeepEqual(got, want) {
+		t.Fatalf("flat: got %v, want %v", got, want)
+	}
+
+	nested := &Config{TranslationPaths: []string{"src/i18n", "locales"}}
+	files = []string{"locales/fr/app.json", "src/i18n/pt_BR/common.yml", "locales/app.json"}
+
+	want = map[string][]string{
+		"fr":    {"locales/fr/app.json"},
+		"pt_BR": {"src/i18n/pt_BR/common.yml"},
+		"other": {"locales/app.json"},
+	}
+	if got := groupFilesByLanguage(nested, files); !reflect.DeepEqual(got, want) {
+		t.Fatalf("nested: got %v, want %v", got, want)
+	}
+}
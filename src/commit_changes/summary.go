@@ -0,0 +1,49 @@
+package commitchanges
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/lokalise/lokalise-pull-action/src/actionlog/summary"
+	"github.com/lokalise/lokalise-pull-action/src/errkind"
+)
+
+// writeCommitSummary appends the base branch, branch, commit and push result
+// to the job summary. The summary is informational: failures are logged only.
+func writeCommitSummary(res commitResult, err error, summarize func(string) bool) {
+	var md summary.Markdown
+	md.Heading("Commit")
+	md.Table([]string{"Item", "Value"}, [][]string{
+		{"Base branch", summary.Code(res.Base)},
+		{"Branch", summary.Code(res.Branch)},
+		{"Commit", summary.Code(res.CommitSHA)},
+		{"Changes", changesOf(res)},
+		{"Push", pushResultOf(res, err)},
+	})
+
+	if !summarize(md.String()) {
+		slog.Debug("job summary not written")
+	}
+}
+
+func changesOf(res commitResult) string {
+	if res.CommitSHA == "" {
+		return summary.Code("")
+	}
+	return fmt.Sprintf("%d files, +%d −%d", len(res.Stats.Files), res.Stats.Insertions, res.Stats.Deletions)
+}
+
+func pushResultOf(res commitResult, err error) string {
+	switch {
+	case res.NoChanges:
+		return "nothing to commit"
+	case res.Pushed && res.ForcePush:
+		return "force-pushed"
+	case res.Pushed:
+		return "pushed"
+	case err != nil:
+		return "failed (" + string(errkind.Of(err)) + "): " + err.Error()
+	default:
+		return "not pushed"
+	}
+}
@@ -0,0 +1,76 @@
+package commitchanges
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lokalise/lokalise-pull-action/src/command"
+	"github.com/lokalise/lokalise-pull-action/src/errkind"
+)
+
+func TestWriteCommitSummary(t *testing.T) {
+	t.Parallel()
+
+	var got string
+	summarize := func(md string) bool { got = md; return true }
+
+	res := commitResult{
+		Base: "main", Branch: "lok_main_1", CommitSHA: "abc123", Pushed: true, ForcePush: true,
+		Stats: commitStats{SHA: "abc123", Files: []string{"locales/fr.json", "locales/de.json"}, Insertions: 3, Deletions: 1},
+	}
+	writeCommitSummary(res, nil, summarize)
+
+	for _, want := range []string{
+		"### Commit",
+		"| Base branch | `main` |",
+		"| Branch | `lok_main_1` |",
+		"| Commit | `abc123` |",
+		"| Changes | 2 files, +3 −1 |",
+		"| Push | force-pushed |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("summary is missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestPushResultOf(t *testing.T) {
+	t.Parallel()
+
+	rejected := errkind.With(errkind.PushRejected, errors.New("failed to push branch"))
+
+	tests := []struct {
+		res  commitResult
+		err  error
+		want string
+	}{
+		{commitResult{NoChanges: true}, nil, "nothing to commit"},
+		{commitResult{Pushed: true}, nil, "pushed"},
+		{commitResult{CommitSHA: "abc"}, rejected, "failed (push_rejected): failed to push branch"},
+		{commitResult{}, nil, "not pushed"},
+	}
+
+	for _, tt := range tests {
+		if got := pushResultOf(tt.res, tt.err); got != tt.want {
+			t.Errorf("pushResultOf(%+v) = %q, want %q", tt.res, got, tt.want)
+		}
+	}
+}
+
+func TestRunWith_WritesSummaryOnFailure(t *testing.T) {
+	t.Parallel()
+
+	var got string
+	commit := func(command.Runner) (commitResult, error) {
+		return commitResult{Base: "main", Branch: "lok"}, errors.New("checkout failed")
+	}
+
+	err := runWith(commit, func(string, string) bool { return true }, &MockCommandRunner{}, func(md string) bool { got = md; return true })
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(got, "| Push | failed (git_error): error committing and pushing changes: checkout failed |") {
+		t.Fatalf("unexpected summary:\n%s", got)
+	}
+}
@@ -1,35 +1,37 @@
-package main
+package commitchanges
 
 import (
 	"fmt"
 	"strings"
+
+	"github.com/lokalise/lokalise-pull-action/src/command"
 )
 
 // worktreeEqualsRef checks if tracked working tree changes and index changes match <ref>.
 // Untracked files are not considered here; use readWorktreeStatus/hasUntrackedFiles separately.
 // If yes -> safe to force-checkout.
 // It uses `git diff --quiet` exit codes.
-func worktreeEqualsRef(ref string, runner CommandRunner) (bool, error) {
+func worktreeEqualsRef(ref string, runner command.Runner) (bool, error) {
 	_, err1 := runner.Capture("git", "diff", "--quiet", ref)
-	if err1 != nil && !isExitCode(err1, 1) {
+	if err1 != nil && !command.IsExitCode(err1, 1) {
 		return false, fmt.Errorf("git diff failed: %w", err1)
 	}
-	if isExitCode(err1, 1) {
+	if command.IsExitCode(err1, 1) {
 		return false, nil
 	}
 
 	_, err2 := runner.Capture("git", "diff", "--quiet", "--cached", ref)
-	if err2 != nil && !isExitCode(err2, 1) {
+	if err2 != nil && !command.IsExitCode(err2, 1) {
 		return false, fmt.Errorf("git diff --cached failed: %w", err2)
 	}
-	if isExitCode(err2, 1) {
+	if command.IsExitCode(err2, 1) {
 		return false, nil
 	}
 
 	return true, nil
 }
 
-func readWorktreeStatus(runner CommandRunner) (status string, hasUntracked bool, err error) {
+func readWorktreeStatus(runner command.Runner) (status string, hasUntracked bool, err error) {
 	out, err := runner.Capture("git", "status", "--porcelain=v1")
 	if err != nil {
 		return "", false, fmt.Errorf("failed to check status: %w\nOutput: %s", err, out)
@@ -1,7 +1,10 @@
-package main
+package detectchangedfiles
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/bodrovis/lokalise-actions-common/v2/fileexts"
 	"github.com/bodrovis/lokalise-actions-common/v2/parsers"
@@ -14,6 +17,7 @@ type Config struct {
 	AlwaysPullBase bool     // if false, base language files/dirs are excluded from change detection
 	BaseLang       string   // e.g., "en", "fr_FR"
 	Paths          []string // repo-relative translation roots, e.g. ["locales", "packages/app/locales"]
+	LockfilePath   string   // optional export lockfile; a new project fingerprint in it counts as a change
 }
 
 type configInputs struct {
@@ -22,6 +26,7 @@ type configInputs struct {
 	paths          []string
 	fileExt        []string
 	baseLang       string
+	lockfilePath   string
 }
 
 // prepareConfig reads action inputs from environment variables, applies
@@ -56,12 +61,18 @@ func readConfigInputs() (*configInputs, error) {
 		return nil, err
 	}
 
+	lockfilePath, err := readLockfilePath()
+	if err != nil {
+		return nil, err
+	}
+
 	return &configInputs{
 		flatNaming:     flatNaming,
 		alwaysPullBase: alwaysPullBase,
 		paths:          paths,
 		fileExt:        fileExt,
 		baseLang:       baseLang,
+		lockfilePath:   lockfilePath,
 	}, nil
 }
 
@@ -72,6 +83,7 @@ func buildConfig(inputs *configInputs) *Config {
 		AlwaysPullBase: inputs.alwaysPullBase,
 		BaseLang:       inputs.baseLang,
 		Paths:          inputs.paths,
+		LockfilePath:   inputs.lockfilePath,
 	}
 }
 
@@ -94,3 +106,18 @@ func parseBooleanFlags() (flatNaming bool, alwaysPullBase bool, err error) {
 func resolveFileExts() ([]string, error) {
 	return fileexts.ResolveFromEnv("FILE_EXT", "FILE_FORMAT")
 }
+
+// readLockfilePath returns the optional LOCKFILE_PATH normalized to forward slashes.
+func readLockfilePath() (string, error) {
+	raw := strings.TrimSpace(os.Getenv("LOCKFILE_PATH"))
+	if raw == "" {
+		return "", nil
+	}
+
+	clean, err := parsers.EnsureRepoRelativePath(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid LOCKFILE_PATH: %w", err)
+	}
+
+	return filepath.ToSlash(clean), nil
+}
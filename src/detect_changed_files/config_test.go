@@ -1,4 +1,4 @@
-package main
+package detectchangedfiles
 
 import (
 	"path/filepath"
@@ -195,6 +195,37 @@ func TestPrepareConfig(t *testing.T) {
 				Paths:          []string{"a/c"},
 			},
 		},
+		{
+			name: "normalizes LOCKFILE_PATH",
+			envVars: map[string]string{
+				"TRANSLATIONS_PATH": "locales",
+				"FILE_FORMAT":       "json",
+				"BASE_LANG":         "en",
+				"FLAT_NAMING":       "true",
+				"ALWAYS_PULL_BASE":  "false",
+				"LOCKFILE_PATH":     "./locales//.lokalise.lock",
+			},
+			expectedConfig: &Config{
+				FileExts:       []string{"json"},
+				FlatNaming:     true,
+				AlwaysPullBase: false,
+				BaseLang:       "en",
+				Paths:          []string{"locales"},
+				LockfilePath:   "locales/.lokalise.lock",
+			},
+		},
+		{
+			name: "rejects LOCKFILE_PATH outside the repo",
+			envVars: map[string]string{
+				"TRANSLATIONS_PATH": "locales",
+				"FILE_FORMAT":       "json",
+				"BASE_LANG":         "en",
+				"FLAT_NAMING":       "true",
+				"ALWAYS_PULL_BASE":  "false",
+				"LOCKFILE_PATH":     "../.lokalise.lock",
+			},
+			expectedError: "invalid LOCKFILE_PATH",
+		},
 		{
 			name: "rejects parent escape",
 			envVars: map[string]string{
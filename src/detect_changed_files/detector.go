@@ -1,14 +1,17 @@
-package main
+package detectchangedfiles
 
 import (
 	"github.com/bodrovis/lokalise-actions-common/v2/managedpaths"
+
+	"github.com/lokalise/lokalise-pull-action/src/command"
 )
 
 // detectChangedFiles keeps the entrypoint thin by delegating all Git path
 // collection and translation-file matching to shared helpers.
-func detectChangedFiles(config *Config, runner CommandRunner) (bool, error) {
+// It returns the changed translation files, sorted.
+func detectChangedFiles(config *Config, runner command.Capturer) ([]string, error) {
 	scope := buildTranslationScope(config)
-	return managedpaths.HasManagedGitPaths(runner, scope)
+	return managedpaths.CollectManagedGitPaths(runner, scope)
 }
 
 // buildTranslationScope converts env-derived action config into the shared
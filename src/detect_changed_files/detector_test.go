@@ -1,4 +1,4 @@
-package main
+package detectchangedfiles
 
 import (
 	"fmt"
@@ -34,7 +34,8 @@ func TestDetectChangedFiles(t *testing.T) {
 		BaseLang:       "en",
 	}
 
-	changed, err := detectChangedFiles(config, mockRunner)
+	files, err := detectChangedFiles(config, mockRunner)
+	changed := len(files) > 0
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -65,7 +66,8 @@ func TestDetectChangedFiles_NoChanges(t *testing.T) {
 		BaseLang:       "en",
 	}
 
-	changed, err := detectChangedFiles(config, mockRunner)
+	files, err := detectChangedFiles(config, mockRunner)
+	changed := len(files) > 0
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -99,7 +101,8 @@ func TestDetectChangedFiles_AllChangesExcluded_Flat_PerExt(t *testing.T) {
 		BaseLang:       "en",
 	}
 
-	changed, err := detectChangedFiles(config, mockRunner)
+	files, err := detectChangedFiles(config, mockRunner)
+	changed := len(files) > 0
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -136,7 +139,8 @@ func TestDetectChangedFiles_Nested_BaseDirExcluded(t *testing.T) {
 		BaseLang:       "en",
 	}
 
-	changed, err := detectChangedFiles(config, mockRunner)
+	files, err := detectChangedFiles(config, mockRunner)
+	changed := len(files) > 0
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -224,7 +228,8 @@ func TestDetectChangedFiles_DeletedManagedFileStillCounts(t *testing.T) {
 		BaseLang:       "en",
 	}
 
-	changed, err := detectChangedFiles(config, mockRunner)
+	files, err := detectChangedFiles(config, mockRunner)
+	changed := len(files) > 0
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -258,7 +263,8 @@ func TestDetectChangedFiles_NoHeadFallback(t *testing.T) {
 		BaseLang:       "en",
 	}
 
-	changed, err := detectChangedFiles(config, mockRunner)
+	files, err := detectChangedFiles(config, mockRunner)
+	changed := len(files) > 0
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -287,7 +293,8 @@ func TestDetectChangedFiles_AlwaysPullBaseIncludesBaseFile_Flat(t *testing.T) {
 		BaseLang:       "en",
 	}
 
-	changed, err := detectChangedFiles(config, mockRunner)
+	files, err := detectChangedFiles(config, mockRunner)
+	changed := len(files) > 0
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -319,7 +326,8 @@ func TestDetectChangedFiles_Nested_BaseOnlyChangesExcluded(t *testing.T) {
 		BaseLang:       "en",
 	}
 
-	changed, err := detectChangedFiles(config, mockRunner)
+	files, err := detectChangedFiles(config, mockRunner)
+	changed := len(files) > 0
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -352,7 +360,8 @@ func TestDetectChangedFiles_OnlyNonManagedChanges(t *testing.T) {
 		BaseLang:       "en",
 	}
 
-	changed, err := detectChangedFiles(config, mockRunner)
+	files, err := detectChangedFiles(config, mockRunner)
+	changed := len(files) > 0
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -381,7 +390,8 @@ func TestDetectChangedFiles_UntrackedManagedOnly(t *testing.T) {
 		BaseLang:       "en",
 	}
 
-	changed, err := detectChangedFiles(config, mockRunner)
+	files, err := detectChangedFiles(config, mockRunner)
+	changed := len(files) > 0
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -439,7 +449,8 @@ func TestDetectChangedFiles_MultipleRoots_SecondRootMatches(t *testing.T) {
 		BaseLang:       "en",
 	}
 
-	changed, err := detectChangedFiles(config, mockRunner)
+	files, err := detectChangedFiles(config, mockRunner)
+	changed := len(files) > 0
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
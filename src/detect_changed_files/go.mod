@@ -1,9 +1,23 @@
-module detect_changed_files
+module github.com/lokalise/lokalise-pull-action/src/detect_changed_files
 
 go 1.26
 
 toolchain go1.26.4
 
-require github.com/bodrovis/lokalise-actions-common/v2 v2.15.0
+require (
+	github.com/bodrovis/lokalise-actions-common/v2 v2.15.0
+	github.com/lokalise/lokalise-pull-action/src/actionlog v0.0.0
+	github.com/lokalise/lokalise-pull-action/src/command v0.0.0
+	github.com/lokalise/lokalise-pull-action/src/errkind v0.0.0
+	github.com/lokalise/lokalise-pull-action/src/lockfile v0.0.0
+)
 
 require go.yaml.in/yaml/v4 v4.0.0-rc.6 // indirect
+
+replace github.com/lokalise/lokalise-pull-action/src/actionlog => ../actionlog
+
+replace github.com/lokalise/lokalise-pull-action/src/command => ../command
+
+replace github.com/lokalise/lokalise-pull-action/src/errkind => ../errkind
+
+replace github.com/lokalise/lokalise-pull-action/src/lockfile => ../lockfile
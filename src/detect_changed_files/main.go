@@ -1,88 +1,93 @@
-package main
-
-import (
-	"bytes"
-	"fmt"
-	"os"
-	"os/exec"
-
-	"github.com/bodrovis/lokalise-actions-common/v2/githuboutput"
-)
-
-// This program inspects git state to decide whether translation files changed.
+// Package detectchangedfiles inspects git state to decide whether translation files changed.
 // It supports both "flat" layouts (e.g., locales/en.json) and nested layouts
 // (e.g., locales/en/app.json), and can optionally exclude base language files.
 // Result is written as a GitHub Actions output variable `has_changes`.
+package detectchangedfiles
 
-// CommandRunner abstracts shell execution for testability (inject a fake runner).
-type CommandRunner interface {
-	Capture(name string, args ...string) (string, error)
-}
-
-type DefaultCommandRunner struct{}
-
-func (d DefaultCommandRunner) Capture(name string, args ...string) (string, error) {
-	var out bytes.Buffer
-	cmd := exec.Command(name, args...)
-	cmd.Stdout = &out
-	cmd.Stderr = &out
-	err := cmd.Run()
-	return out.String(), err
-}
-
-var exitFunc = os.Exit
+import (
+	"fmt"
+	"log/slog"
 
-func main() {
-	if err := run(); err != nil {
-		returnWithError(err.Error())
-	}
-}
+	"github.com/lokalise/lokalise-pull-action/src/actionlog/summary"
+	"github.com/lokalise/lokalise-pull-action/src/command"
+	"github.com/lokalise/lokalise-pull-action/src/errkind"
+	"github.com/lokalise/lokalise-pull-action/src/lockfile"
+)
 
-func run() error {
-	return runWith(
+// Main runs the detection step configured by the environment and returns the
+// process exit code. Outputs, including the error_kind of a failure, go to write.
+func Main(write func(string, string) bool) int {
+	err := runWith(
 		prepareConfig,
 		detectChangedFiles,
-		githuboutput.WriteToGitHubOutput,
-		DefaultCommandRunner{},
+		write,
+		command.Default{},
+		summary.WriteToStepSummary,
 	)
+	if err != nil {
+		return errkind.Report(err, errkind.Of(err), write)
+	}
+	return 0
 }
 
-type detectFunc func(*Config, CommandRunner) (bool, error)
+// detectFunc returns the changed translation files.
+type detectFunc func(*Config, command.Capturer) ([]string, error)
+
+// gitReader runs the read-only git commands of the detection.
+type gitReader interface {
+	command.Capturer
+	command.Outputter
+}
 
 func runWith(
 	prepare func() (*Config, error),
 	detect detectFunc,
 	write func(string, string) bool,
-	runner CommandRunner,
+	runner gitReader,
+	summarize func(string) bool,
 ) error {
 	cfg, err := prepare()
 	if err != nil {
-		return fmt.Errorf("error preparing configuration: %w", err)
+		return errkind.With(errkind.Config, fmt.Errorf("error preparing configuration: %w", err))
 	}
 
-	changed, err := detectChanges(cfg, detect, runner)
+	files, err := detectChanges(cfg, detect, runner)
 	if err != nil {
 		return err
 	}
 
+	changed := len(files) > 0 || fingerprintChanged(cfg, runner)
 	if err := writeChangesOutput(changed, write); err != nil {
 		return err
 	}
 
+	writeChangesSummary(cfg, files, summarize)
+
 	return nil
 }
 
 func detectChanges(
 	cfg *Config,
 	detect detectFunc,
-	runner CommandRunner,
-) (bool, error) {
-	changed, err := detect(cfg, runner)
+	runner command.Capturer,
+) ([]string, error) {
+	files, err := detect(cfg, runner)
 	if err != nil {
-		return false, fmt.Errorf("error detecting changes: %w", err)
+		return nil, errkind.With(errkind.Git, fmt.Errorf("error detecting changes: %w", err))
 	}
 
-	return changed, nil
+	return files, nil
+}
+
+// fingerprintChanged reports a lockfile that only records a new project
+// fingerprint, so the commit step still commits it on its own.
+func fingerprintChanged(cfg *Config, runner command.Outputter) bool {
+	if cfg.LockfilePath == "" || !lockfile.FingerprintChanged(runner, cfg.LockfilePath) {
+		return false
+	}
+
+	slog.Info("No translation file changed, but the lockfile records a new project fingerprint.", "path", cfg.LockfilePath)
+	return true
 }
 
 func writeChangesOutput(
@@ -92,9 +97,9 @@ func writeChangesOutput(
 	outputValue := "false"
 	if changed {
 		outputValue = "true"
-		fmt.Println("Detected changes in translation files.")
+		slog.Info("Detected changes in translation files.")
 	} else {
-		fmt.Println("No changes detected in translation files.")
+		slog.Info("No changes detected in translation files.")
 	}
 
 	if !write("has_changes", outputValue) {
@@ -103,8 +108,3 @@ func writeChangesOutput(
 
 	return nil
 }
-
-func returnWithError(message string) {
-	fmt.Fprintf(os.Stderr, "Error: %s\n", message)
-	exitFunc(1)
-}
@@ -1,30 +1,39 @@
-package main
+package detectchangedfiles
 
 import (
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/lokalise/lokalise-pull-action/src/command"
+	"github.com/lokalise/lokalise-pull-action/src/errkind"
 )
 
 type MockCommandRunner struct {
-	Output map[string]string
-	Err    map[string]error
+	Outputs map[string]string
+	Err     map[string]error
 }
 
 func (m MockCommandRunner) Capture(name string, args ...string) (string, error) {
 	key := filepath.ToSlash(name + " " + strings.Join(args, " "))
 
 	if err, ok := m.Err[key]; ok {
-		return m.Output[key], err
+		return m.Outputs[key], err
 	}
-	if output, ok := m.Output[key]; ok {
+	if output, ok := m.Outputs[key]; ok {
 		return output, nil
 	}
 	return "", fmt.Errorf("command %q not mocked", key)
 }
 
+func (m MockCommandRunner) Output(name string, args ...string) ([]byte, error) {
+	out, err := m.Capture(name, args...)
+	return []byte(out), err
+}
+
 func makeKey(args []string) string {
 	return filepath.ToSlash("git " + strings.Join(args, " "))
 }
@@ -33,10 +42,12 @@ func joinLines(lines ...string) string {
 	return strings.Join(lines, "\n")
 }
 
+func noSummary(string) bool { return true }
+
 func newMockCommandRunner(output map[string]string, err map[string]error) MockCommandRunner {
 	return MockCommandRunner{
-		Output: output,
-		Err:    err,
+		Outputs: output,
+		Err:     err,
 	}
 }
 
@@ -57,7 +68,7 @@ func TestRunWith_Success_WhenChangesDetected(t *testing.T) {
 		return cfg, nil
 	}
 
-	detect := func(gotCfg *Config, runner CommandRunner) (bool, error) {
+	detect := func(gotCfg *Config, runner command.Capturer) ([]string, error) {
 		detectCalled = true
 
 		if gotCfg != cfg {
@@ -69,7 +80,7 @@ func TestRunWith_Success_WhenChangesDetected(t *testing.T) {
 			t.Fatalf("detect got unexpected runner type: %T", runner)
 		}
 
-		return true, nil
+		return []string{"locales/fr.json"}, nil
 	}
 
 	write := func(key, value string) bool {
@@ -81,7 +92,7 @@ func TestRunWith_Success_WhenChangesDetected(t *testing.T) {
 
 	runner := newMockCommandRunner(nil, nil)
 
-	err := runWith(prepare, detect, write, runner)
+	err := runWith(prepare, detect, write, runner, noSummary)
 	if err != nil {
 		t.Fatalf("runWith returned unexpected error: %v", err)
 	}
@@ -115,11 +126,11 @@ func TestRunWith_Success_WhenNoChangesDetected(t *testing.T) {
 		return cfg, nil
 	}
 
-	detect := func(gotCfg *Config, runner CommandRunner) (bool, error) {
+	detect := func(gotCfg *Config, runner command.Capturer) ([]string, error) {
 		if gotCfg != cfg {
 			t.Fatalf("detect got unexpected config pointer")
 		}
-		return false, nil
+		return nil, nil
 	}
 
 	write := func(key, value string) bool {
@@ -130,7 +141,7 @@ func TestRunWith_Success_WhenNoChangesDetected(t *testing.T) {
 
 	runner := newMockCommandRunner(nil, nil)
 
-	err := runWith(prepare, detect, write, runner)
+	err := runWith(prepare, detect, write, runner, noSummary)
 	if err != nil {
 		t.Fatalf("runWith returned unexpected error: %v", err)
 	}
@@ -143,6 +154,46 @@ func TestRunWith_Success_WhenNoChangesDetected(t *testing.T) {
 	}
 }
 
+func TestRunWith_NewFingerprintInLockfileIsAChange(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	if err := os.MkdirAll("locales", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("locales/.lokalise.lock", []byte(`{"project_fingerprint":"sha256:new"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{LockfilePath: "locales/.lokalise.lock"}
+	prepare := func() (*Config, error) { return cfg, nil }
+	detect := func(*Config, command.Capturer) ([]string, error) { return nil, nil }
+
+	for _, tt := range []struct {
+		committed string
+		want      string
+	}{
+		{`{"project_fingerprint":"sha256:old"}`, "true"},
+		{`{"project_fingerprint":"sha256:new"}`, "false"},
+	} {
+		runner := newMockCommandRunner(map[string]string{
+			"git show HEAD:locales/.lokalise.lock": tt.committed,
+		}, nil)
+
+		outputs := map[string]string{}
+		write := func(key, value string) bool {
+			outputs[key] = value
+			return true
+		}
+
+		if err := runWith(prepare, detect, write, runner, noSummary); err != nil {
+			t.Fatalf("runWith returned unexpected error: %v", err)
+		}
+		if outputs["has_changes"] != tt.want {
+			t.Fatalf("committed %s: has_changes = %q, want %q", tt.committed, outputs["has_changes"], tt.want)
+		}
+	}
+}
+
 func TestRunWith_ReturnsError_WhenPrepareFails(t *testing.T) {
 	t.Parallel()
 
@@ -150,9 +201,9 @@ func TestRunWith_ReturnsError_WhenPrepareFails(t *testing.T) {
 		return nil, errors.New("bad config")
 	}
 
-	detect := func(_ *Config, _ CommandRunner) (bool, error) {
+	detect := func(_ *Config, _ command.Capturer) ([]string, error) {
 		t.Fatal("detect should not be called")
-		return false, nil
+		return nil, nil
 	}
 
 	write := func(_, _ string) bool {
@@ -162,7 +213,7 @@ func TestRunWith_ReturnsError_WhenPrepareFails(t *testing.T) {
 
 	runner := newMockCommandRunner(nil, nil)
 
-	err := runWith(prepare, detect, write, runner)
+	err := runWith(prepare, detect, write, runner, noSummary)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -170,6 +221,9 @@ func TestRunWith_ReturnsError_WhenPrepareFails(t *testing.T) {
 	if !strings.Contains(err.Error(), "error preparing configuration: bad config") {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	if kind := errkind.Of(err); kind != errkind.Config {
+		t.Fatalf("expected %q, got %q", errkind.Config, kind)
+	}
 }
 
 func TestRunWith_ReturnsError_WhenDetectFails(t *testing.T) {
@@ -181,11 +235,11 @@ func TestRunWith_ReturnsError_WhenDetectFails(t *testing.T) {
 		return cfg, nil
 	}
 
-	detect := func(gotCfg *Config, runner CommandRunner) (bool, error) {
+	detect := func(gotCfg *Config, runner command.Capturer) ([]string, error) {
 		if gotCfg != cfg {
 			t.Fatalf("detect got unexpected config pointer")
 		}
-		return false, errors.New("git failure")
+		return nil, errors.New("git failure")
 	}
 
 	write := func(_, _ string) bool {
@@ -195,7 +249,7 @@ func TestRunWith_ReturnsError_WhenDetectFails(t *testing.T) {
 
 	runner := newMockCommandRunner(nil, nil)
 
-	err := runWith(prepare, detect, write, runner)
+	err := runWith(prepare, detect, write, runner, noSummary)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -203,6 +257,9 @@ func TestRunWith_ReturnsError_WhenDetectFails(t *testing.T) {
 	if !strings.Contains(err.Error(), "error detecting changes: git failure") {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	if kind := errkind.Of(err); kind != errkind.Git {
+		t.Fatalf("expected %q, got %q", errkind.Git, kind)
+	}
 }
 
 func TestRunWith_ReturnsError_WhenWriteFails(t *testing.T) {
@@ -214,11 +271,11 @@ func TestRunWith_ReturnsError_WhenWriteFails(t *testing.T) {
 		return cfg, nil
 	}
 
-	detect := func(gotCfg *Config, runner CommandRunner) (bool, error) {
+	detect := func(gotCfg *Config, runner command.Capturer) ([]string, error) {
 		if gotCfg != cfg {
 			t.Fatalf("detect got unexpected config pointer")
 		}
-		return true, nil
+		return []string{"locales/fr.json"}, nil
 	}
 
 	write := func(_, _ string) bool {
@@ -227,7 +284,7 @@ func TestRunWith_ReturnsError_WhenWriteFails(t *testing.T) {
 
 	runner := newMockCommandRunner(nil, nil)
 
-	err := runWith(prepare, detect, write, runner)
+	err := runWith(prepare, detect, write, runner, noSummary)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -243,7 +300,7 @@ func TestDetectChanges_Success(t *testing.T) {
 	cfg := &Config{}
 	runner := newMockCommandRunner(nil, nil)
 
-	detect := func(gotCfg *Config, gotRunner CommandRunner) (bool, error) {
+	detect := func(gotCfg *Config, gotRunner command.Capturer) ([]string, error) {
 		if gotCfg != cfg {
 			t.Fatalf("detect got unexpected config pointer")
 		}
@@ -252,15 +309,15 @@ func TestDetectChanges_Success(t *testing.T) {
 			t.Fatalf("detect got unexpected runner type: %T", gotRunner)
 		}
 
-		return true, nil
+		return []string{"locales/fr.json"}, nil
 	}
 
-	changed, err := detectChanges(cfg, detect, runner)
+	files, err := detectChanges(cfg, detect, runner)
 	if err != nil {
 		t.Fatalf("detectChanges returned unexpected error: %v", err)
 	}
-	if !changed {
-		t.Fatal("expected changed=true")
+	if len(files) != 1 {
+		t.Fatalf("expected one changed file, got %v", files)
 	}
 }
 
@@ -270,16 +327,16 @@ func TestDetectChanges_ReturnsWrappedError(t *testing.T) {
 	cfg := &Config{}
 	runner := newMockCommandRunner(nil, nil)
 
-	detect := func(_ *Config, _ CommandRunner) (bool, error) {
-		return false, errors.New("diff failed")
+	detect := func(_ *Config, _ command.Capturer) ([]string, error) {
+		return nil, errors.New("diff failed")
 	}
 
-	changed, err := detectChanges(cfg, detect, runner)
+	files, err := detectChanges(cfg, detect, runner)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
-	if changed {
-		t.Fatal("expected changed=false")
+	if files != nil {
+		t.Fatalf("expected no files, got %v", files)
 	}
 	if !strings.Contains(err.Error(), "error detecting changes: diff failed") {
 		t.Fatalf("unexpected error: %v", err)
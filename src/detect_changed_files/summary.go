@@ -0,0 +1,78 @@
+package detectchangedfiles
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/lokalise/lokalise-pull-action/src/actionlog/summary"
+)
+
+// writeChangesSummary appends the changed translation files, grouped by
+// language, to the job summary. The summary is informational: failures are logged only.
+func writeChangesSummary(cfg *Config, files []string, summarize func(string) bool) {
+	var md summary.Markdown
+	md.Heading("Translation changes")
+
+	if len(files) == 0 {
+		md.Paragraph("No changes detected in translation files.")
+	} else {
+		byLang := groupFilesByLanguage(cfg, files)
+
+		rows := make([][]string, 0, len(byLang))
+		for _, lang := range slices.Sorted(maps.Keys(byLang)) {
+			cells := make([]string, 0, len(byLang[lang]))
+			for _, f := range byLang[lang] {
+				cells = append(cells, summary.Code(f))
+			}
+			rows = append(rows, []string{summary.Code(lang), fmt.Sprint(len(cells)), strings.Join(cells, "<br>")})
+		}
+
+		md.Paragraph(fmt.Sprintf("%d changed files in %d languages.", len(files), len(byLang)))
+		md.Table([]string{"Language", "Files", "Paths"}, rows)
+	}
+
+	if !summarize(md.String()) {
+		slog.Debug("job summary not written")
+	}
+}
+
+// groupFilesByLanguage keys repo-relative paths by language: the file name for
+// flat layouts (locales/fr.json), the first directory for nested ones (locales/fr/app.json).
+func groupFilesByLanguage(cfg *Config, files []string) map[string][]string {
+	byLang := map[string][]string{}
+	for _, f := range files {
+		lang := languageOf(cfg, f)
+		byLang[lang] = append(byLang[lang], f)
+	}
+	return byLang
+}
+
+func languageOf(cfg *Config, file string) string {
+	file = path.Clean(strings.ReplaceAll(file, "\\", "/"))
+
+	for _, root := range cfg.Paths {
+		root = path.Clean(strings.ReplaceAll(root, "\\", "/"))
+
+		rel, ok := strings.CutPrefix(file, root+"/")
+		if root == "." {
+			rel, ok = file, true
+		}
+		if !ok || rel == "" {
+			continue
+		}
+
+		if cfg.FlatNaming {
+			base := path.Base(rel)
+			return strings.TrimSuffix(base, path.Ext(base))
+		}
+		if lang, _, found := strings.Cut(rel, "/"); found {
+			return lang
+		}
+	}
+
+	return "other"
+}
@@ -0,0 +1,61 @@
+package detectchangedfiles
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLanguageOf(t *testing.T) {
+	t.Parallel()
+
+	nested := &Config{Paths: []string{"locales", "packages/app/i18n"}}
+	flat := &Config{Paths: []string{"locales"}, FlatNaming: true}
+
+	tests := []struct {
+		cfg  *Config
+		file string
+		want string
+	}{
+		{nested, "locales/fr/app.json", "fr"},
+		{nested, "packages/app/i18n/de_DE/main.json", "de_DE"},
+		{nested, "locales/readme.json", "other"},
+		{flat, "locales/fr.json", "fr"},
+		{flat, "locales/sub/pt_BR.json", "pt_BR"},
+		{flat, "other/fr.json", "other"},
+	}
+
+	for _, tt := range tests {
+		if got := languageOf(tt.cfg, tt.file); got != tt.want {
+			t.Errorf("languageOf(%q) = %q, want %q", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestWriteChangesSummary(t *testing.T) {
+	t.Parallel()
+
+	var got string
+	summarize := func(md string) bool { got = md; return true }
+
+	cfg := &Config{Paths: []string{"locales"}}
+	writeChangesSummary(cfg, []string{"locales/fr/a.json", "locales/de/a.json", "locales/fr/b.json"}, summarize)
+
+	for _, want := range []string{
+		"### Translation changes",
+		"3 changed files in 2 languages.",
+		"| `de` | 1 | `locales/de/a.json` |",
+		"| `fr` | 2 | `locales/fr/a.json`<br>`locales/fr/b.json` |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("summary is missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Index(got, "`de`") > strings.Index(got, "`fr`") {
+		t.Fatalf("languages should be sorted:\n%s", got)
+	}
+
+	writeChangesSummary(cfg, nil, summarize)
+	if !strings.Contains(got, "No changes detected") {
+		t.Fatalf("unexpected summary without changes:\n%s", got)
+	}
+}
@@ -0,0 +1,91 @@
+// Package errkind classifies the failures of the action binaries. Every step
+// reports the kind of its failure in the error_kind output and exits with the
+// code of that kind, so workflows can retry or alert selectively:
+//
+//	unknown 1, invalid_config 2, auth 3, rate_limited 4, timeout 5,
+//	api_error 6, git_error 7, push_rejected 8.
+//
+// nothing_to_commit is reported with exit code 0: it is an outcome, not a failure.
+package errkind
+
+import (
+	"errors"
+	"log/slog"
+)
+
+// Output is the step output that names the class of a failure.
+const Output = "error_kind"
+
+// Kind classifies a failure.
+type Kind string
+
+const (
+	Unknown         Kind = "unknown"
+	Config          Kind = "invalid_config"
+	Auth            Kind = "auth"
+	RateLimited     Kind = "rate_limited"
+	Timeout         Kind = "timeout"
+	API             Kind = "api_error"
+	Git             Kind = "git_error"
+	PushRejected    Kind = "push_rejected"
+	NothingToCommit Kind = "nothing_to_commit"
+)
+
+var exitCodes = map[Kind]int{
+	Unknown:         1,
+	Config:          2,
+	Auth:            3,
+	RateLimited:     4,
+	Timeout:         5,
+	API:             6,
+	Git:             7,
+	PushRejected:    8,
+	NothingToCommit: 0,
+}
+
+// ExitCode returns the process exit code for k.
+func (k Kind) ExitCode() int {
+	if code, ok := exitCodes[k]; ok {
+		return code
+	}
+	return 1
+}
+
+// kindError attaches a Kind to an error without changing its message.
+type kindError struct {
+	kind Kind
+	err  error
+}
+
+func (e *kindError) Error() string { return e.err.Error() }
+func (e *kindError) Unwrap() error { return e.err }
+
+// With marks err as kind. An error that is already classified keeps its
+// kind: the innermost classification is the most specific one.
+func With(kind Kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := errors.AsType[*kindError](err); ok {
+		return err
+	}
+	return &kindError{kind: kind, err: err}
+}
+
+// Of returns the kind attached to err with With, or Unknown.
+func Of(err error) Kind {
+	if ke, ok := errors.AsType[*kindError](err); ok {
+		return ke.kind
+	}
+	return Unknown
+}
+
+// Report prints err, writes kind to the error_kind output and returns the exit code.
+func Report(err error, kind Kind, write func(string, string) bool) int {
+	slog.Error(err.Error(), "error_kind", string(kind))
+	if !write(Output, string(kind)) {
+		slog.Warn("cannot write output", "output", Output)
+	}
+
+	return kind.ExitCode()
+}
@@ -0,0 +1,73 @@
+package errkind
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestWithAndOf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want Kind
+	}{
+		{"explicit kind", With(Config, errors.New("bad input")), Config},
+		{"wrapped", fmt.Errorf("run: %w", With(Git, errors.New("not a git repository"))), Git},
+		{"inner kind wins", With(API, With(Config, errors.New("x"))), Config},
+		{"plain", errors.New("disk full"), Unknown},
+	}
+
+	for _, tt := range tests {
+		if got := Of(tt.err); got != tt.want {
+			t.Errorf("%s: Of(%v) = %q, want %q", tt.name, tt.err, got, tt.want)
+		}
+	}
+
+	if With(Config, nil) != nil {
+		t.Fatal("With must keep nil errors nil")
+	}
+	if err := With(Auth, errors.New("Bad credentials")); err.Error() != "Bad credentials" {
+		t.Fatalf("the message must not change, got %q", err)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	t.Parallel()
+
+	tests := map[Kind]int{
+		Unknown:         1,
+		Config:          2,
+		Auth:            3,
+		RateLimited:     4,
+		Timeout:         5,
+		API:             6,
+		Git:             7,
+		PushRejected:    8,
+		NothingToCommit: 0,
+		Kind("other"):   1,
+	}
+
+	for kind, want := range tests {
+		if got := kind.ExitCode(); got != want {
+			t.Errorf("%q.ExitCode() = %d, want %d", kind, got, want)
+		}
+	}
+}
+
+func TestReport(t *testing.T) {
+	t.Parallel()
+
+	outputs := map[string]string{}
+	write := func(name, value string) bool { outputs[name] = value; return true }
+
+	if code := Report(errors.New("git checkout failed"), Git, write); code != 7 || outputs[Output] != "git_error" {
+		t.Fatalf("unexpected result: code=%d outputs=%v", code, outputs)
+	}
+
+	if code := Report(errors.New("boom"), Unknown, func(string, string) bool { return false }); code != 1 {
+		t.Fatalf("unknown errors should exit with 1 even when the output cannot be written, got %d", code)
+	}
+}
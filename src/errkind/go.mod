@@ -0,0 +1,5 @@
+module github.com/lokalise/lokalise-pull-action/src/errkind
+
+go 1.26
+
+toolchain go1.26.4
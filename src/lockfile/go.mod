@@ -0,0 +1,9 @@
+module github.com/lokalise/lokalise-pull-action/src/lockfile
+
+go 1.26
+
+toolchain go1.26.4
+
+require github.com/lokalise/lokalise-pull-action/src/command v0.0.0
+
+replace github.com/lokalise/lokalise-pull-action/src/command => ../command
@@ -0,0 +1,67 @@
+// Package lockfile reads the project fingerprint the download step records in
+// the export lockfile (LOCKFILE_PATH), for the steps that detect and commit changes.
+// The rest of the lockfile format is owned by the download step.
+package lockfile
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/lokalise/lokalise-pull-action/src/command"
+)
+
+// fingerprintField is the part of the lockfile read here.
+type fingerprintField struct {
+	ProjectFingerprint string `json:"project_fingerprint"`
+}
+
+// FingerprintChanged reports whether the lockfile at path (repo-relative)
+// records a project fingerprint other than the one committed at HEAD.
+//
+// The download step records a new fingerprint whenever the project changed,
+// even when no translation file did. That lockfile has to be committed, or
+// SKIP_UNCHANGED keeps comparing against a stale fingerprint and never skips again.
+// A lockfile without a fingerprint never counts as changed; a fingerprinted
+// lockfile missing from HEAD does.
+func FingerprintChanged(git command.Outputter, path string) bool {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("cannot read lockfile", "path", path, "error", err)
+		}
+		return false
+	}
+
+	current, err := fingerprintOf(raw)
+	if err != nil {
+		slog.Warn("cannot parse lockfile", "path", path, "error", err)
+		return false
+	}
+	if current == "" {
+		return false
+	}
+
+	committed, err := git.Output("git", "show", "HEAD:"+filepath.ToSlash(path))
+	if err != nil {
+		// Not committed yet (or no HEAD at all): the first fingerprint is a change.
+		return true
+	}
+
+	previous, err := fingerprintOf(committed)
+	if err != nil {
+		slog.Warn("cannot parse committed lockfile", "path", path, "error", err)
+		return true
+	}
+
+	return previous != current
+}
+
+func fingerprintOf(raw []byte) (string, error) {
+	var f fingerprintField
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return "", err
+	}
+	return f.ProjectFingerprint, nil
+}
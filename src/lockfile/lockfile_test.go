@@ -0,0 +1,83 @@
+package lockfile
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+// headRunner serves "git show HEAD:<path>" from a fixed blob.
+type headRunner struct {
+	blob string
+	err  error
+}
+
+func (h *headRunner) Output(name string, args ...string) ([]byte, error) {
+	return []byte(h.blob), h.err
+}
+
+func writeLock(t *testing.T, content string) string {
+	t.Helper()
+
+	if err := os.MkdirAll("locales", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("locales/.lokalise.lock", []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return "locales/.lokalise.lock"
+}
+
+func TestFingerprintChanged(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string // "" = no lockfile on disk
+		head    *headRunner
+		want    bool
+	}{
+		{"no lockfile", "", &headRunner{}, false},
+		{"no fingerprint recorded", `{"version":1}`, &headRunner{}, false},
+		{"broken lockfile", `{`, &headRunner{}, false},
+		{"same fingerprint", `{"project_fingerprint":"sha256:a","exported_at":"now"}`, &headRunner{blob: `{"project_fingerprint":"sha256:a","exported_at":"before"}`}, false},
+		{"new fingerprint", `{"project_fingerprint":"sha256:b"}`, &headRunner{blob: `{"project_fingerprint":"sha256:a"}`}, true},
+		{"first fingerprint", `{"project_fingerprint":"sha256:b"}`, &headRunner{blob: `{"version":1}`}, true},
+		{"lockfile not committed", `{"project_fingerprint":"sha256:b"}`, &headRunner{err: errors.New("exit status 128")}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+
+			path := "locales/.lokalise.lock"
+			if tt.current != "" {
+				path = writeLock(t, tt.current)
+			}
+
+			if got := FingerprintChanged(tt.head, path); got != tt.want {
+				t.Fatalf("FingerprintChanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFingerprintChanged_ReadsHEAD(t *testing.T) {
+	t.Chdir(t.TempDir())
+	path := writeLock(t, `{"project_fingerprint":"sha256:b"}`)
+
+	var got []string
+	git := outputFunc(func(name string, args ...string) ([]byte, error) {
+		got = append([]string{name}, args...)
+		return []byte(`{"project_fingerprint":"sha256:b"}`), nil
+	})
+
+	if FingerprintChanged(git, path) {
+		t.Fatal("same fingerprint must not count as changed")
+	}
+	if len(got) != 3 || got[1] != "show" || got[2] != "HEAD:locales/.lokalise.lock" {
+		t.Fatalf("unexpected git call %v", got)
+	}
+}
+
+type outputFunc func(name string, args ...string) ([]byte, error)
+
+func (f outputFunc) Output(name string, args ...string) ([]byte, error) { return f(name, args...) }
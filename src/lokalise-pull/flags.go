@@ -0,0 +1,178 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"slices"
+	"strings"
+)
+
+// envVar is a setting the steps read from the environment. Each one is also a
+// flag named after it: LOKALISE_API_KEY becomes --lokalise-api-key.
+type envVar struct {
+	Name    string
+	Usage   string
+	Default string // used when neither the flag nor the variable is set; mirrors action.yml
+	Bool    bool   // the flag may be given without a value
+}
+
+var translationVars = []envVar{
+	{Name: "FILE_FORMAT", Usage: "format of the translation files, e.g. json", Default: "json"},
+	{Name: "FILE_EXT", Usage: "extension(s) of the translation files, one per line (defaults to the file format)"},
+	{Name: "TRANSLATIONS_PATH", Usage: "paths to the translation files, one per line", Default: "locales"},
+	{Name: "BASE_LANG", Usage: "base language, e.g. en or fr_FR", Default: "en"},
+	{Name: "FLAT_NAMING", Usage: "translation files are named locales/en.json rather than locales/en/file.json", Default: "false", Bool: true},
+	{Name: "ALWAYS_PULL_BASE", Usage: "include changes of the base language files", Default: "false", Bool: true},
+	{Name: "STRICT_CONFIG", Usage: "fail on malformed boolean or numeric settings", Default: "false", Bool: true},
+}
+
+var downloadVars = []envVar{
+	{Name: "LOKALISE_API_KEY", Usage: "Lokalise API token"},
+	{Name: "LOKALISE_PROJECT_ID", Usage: "Lokalise project ID"},
+	{Name: "LOKALISE_PROJECTS", Usage: "YAML or JSON list of projects to download into one pull"},
+	{Name: "PROJECT_CONFLICT_POLICY", Usage: "what to do when projects produce the same file: fail, first-wins or merge-json", Default: "fail"},
+	{Name: "USE_PROJECT_BRANCHES", Usage: "download from the Lokalise branch matching the git branch", Default: "false", Bool: true},
+	{Name: "PROJECT_BRANCH_MAPPING", Usage: "rules mapping git branches to Lokalise branches, one per line"},
+	{Name: "PROJECT_BRANCH_FALLBACK", Usage: "Lokalise branch used when the mapped one does not exist", Default: "master"},
+	{Name: "GITHUB_REF_NAME", Usage: "git branch used for {{ref}} tags and project branches"},
+	{Name: "GITHUB_HEAD_REF", Usage: "pull request head branch, preferred over GITHUB_REF_NAME"},
+	{Name: "ADDITIONAL_PARAMS", Usage: "extra download params as JSON or YAML"},
+	{Name: "STRICT_PARAMS", Usage: "fail on unknown download params", Default: "false", Bool: true},
+	{Name: "SKIP_INCLUDE_TAGS", Usage: "do not filter keys by tags", Default: "false", Bool: true},
+	{Name: "INCLUDE_TAGS", Usage: "tags sent as include_tags (defaults to the git branch)"},
+	{Name: "INCLUDE_TAGS_FALLBACK", Usage: "download the untagged keys when include_tags match no keys", Default: "false", Bool: true},
+	{Name: "EXCLUDE_TAGS", Usage: "tags sent as exclude_tags"},
+	{Name: "SKIP_ORIGINAL_FILENAMES", Usage: "do not set original_filenames and directory_prefix", Default: "false", Bool: true},
+	{Name: "DIRECTORY_PREFIX", Usage: "directory_prefix download param", Default: "/"},
+	{Name: "DOWNLOAD_DEST", Usage: "directory the bundle is extracted into", Default: "./"},
+	{Name: "ASYNC_MODE", Usage: "export asynchronously: true, false or auto", Default: "false"},
+	{Name: "ASYNC_AUTO_THRESHOLD", Usage: "key-language pairs from which auto mode exports asynchronously", Default: "10000"},
+	{Name: "ASYNC_POLL_INITIAL_WAIT", Usage: "seconds before the first poll of an async export", Default: "1"},
+	{Name: "ASYNC_POLL_MAX_WAIT", Usage: "seconds to poll an async export", Default: "120"},
+	{Name: "ASYNC_STATE_FILE", Usage: "file remembering in-flight async exports"},
+	{Name: "ASYNC_PROCESS_ID", Usage: "async export process to resume"},
+	{Name: "MAX_RETRIES", Usage: "retries on rate limits and other retryable errors", Default: "3"},
+	{Name: "SLEEP_TIME", Usage: "seconds to sleep before retrying", Default: "1"},
+	{Name: "HTTP_TIMEOUT", Usage: "timeout of HTTP calls, in seconds", Default: "120"},
+	{Name: "DOWNLOAD_TIMEOUT", Usage: "timeout of the whole download, in seconds", Default: "600"},
+	{Name: "SKIP_UNCHANGED", Usage: "skip the export when the project has not changed", Default: "false", Bool: true},
+	{Name: "LOCKFILE_PATH", Usage: "lockfile recording the export metadata"},
+	{Name: "DOWNLOAD_CACHE_DIR", Usage: "directory caching downloaded bundles"},
+	{Name: "DOWNLOAD_CACHE_TTL", Usage: "seconds a cached bundle can be reused", Default: "3600"},
+	{Name: "LOCAL_BUNDLE", Usage: "path or file:// URL of a bundle to extract instead of calling the API"},
+}
+
+var detectVars = []envVar{
+	{Name: "LOCKFILE_PATH", Usage: "lockfile whose new project fingerprint counts as a change"},
+}
+
+var commitVars = []envVar{
+	{Name: "GITHUB_ACTOR", Usage: "user the default git identity is derived from"},
+	{Name: "GITHUB_SHA", Usage: "commit SHA used to make temp branch names unique"},
+	{Name: "BASE_REF", Usage: "base branch of the commit and the pull request"},
+	{Name: "HEAD_REF", Usage: "pull request head branch when running for a pull request"},
+	{Name: "TEMP_BRANCH_PREFIX", Usage: "prefix of the generated branch", Default: "lok"},
+	{Name: "OVERRIDE_BRANCH_NAME", Usage: "static branch name to use instead of a generated one"},
+	{Name: "GIT_USER_NAME", Usage: "git user.name (defaults to GITHUB_ACTOR)"},
+	{Name: "GIT_USER_EMAIL", Usage: "git user.email (defaults to the noreply address of GITHUB_ACTOR)"},
+	{Name: "GIT_COMMIT_MESSAGE", Usage: "commit message", Default: "Translations update"},
+	{Name: "GIT_IDENTITY_SCOPE", Usage: "where the git identity is set: global, local or commit (global by default, commit with --local)"},
+	{Name: "GIT_COMMIT_AUTHOR_NAME", Usage: "commit author name when it differs from the committer"},
+	{Name: "GIT_COMMIT_AUTHOR_EMAIL", Usage: "commit author email when it differs from the committer"},
+	{Name: "GIT_CO_AUTHORS", Usage: "\"Name <email>\" entries, one per line, added as Co-authored-by trailers"},
+	{Name: "GIT_SIGN_COMMITS", Usage: "sign the commit with git commit -S", Default: "false", Bool: true},
+	{Name: "GIT_SIGNING_KEY", Usage: "armored GPG or OpenSSH private key to sign the commit with (implies GIT_SIGN_COMMITS)"},
+	{Name: "GIT_SIGNING_KEY_PASSPHRASE", Usage: "passphrase of a GPG signing key"},
+	{Name: "GIT_SIGNING_FORMAT", Usage: "signing key type: gpg or ssh (detected from the key by default)"},
+	{Name: "FORCE_PUSH", Usage: "force-push the branch", Default: "false", Bool: true},
+	{Name: "SKIP_PUSH", Usage: "commit without pushing the branch", Default: "false", Bool: true},
+	{Name: "GIT_REMOTE", Usage: "remote the base branch is fetched from", Default: "origin"},
+	{Name: "GIT_PUSH_REMOTE", Usage: "remote the branch is pushed to, such as a fork (defaults to GIT_REMOTE)"},
+	{Name: "GIT_PUSH_URL", Usage: "URL the branch is pushed to instead of GIT_PUSH_REMOTE"},
+	{Name: "GIT_PUSH_TOKEN", Usage: "token for an https GIT_PUSH_URL, passed to git through the environment"},
+	{Name: "COMMIT_BACKEND", Usage: "git, or api to commit through the GitHub API (needs GITHUB_TOKEN and GITHUB_REPOSITORY)", Default: "git"},
+	{Name: "LOCKFILE_PATH", Usage: "lockfile committed with the translations, or alone when its project fingerprint changed"},
+}
+
+var prVars = []envVar{
+	{Name: "PR_PROVIDER", Usage: "forge to open the request on: github, gitlab or bitbucket (defaults to the CI service)"},
+	{Name: "GITHUB_TOKEN", Usage: "GitHub token with pull-requests: write"},
+	{Name: "GITLAB_TOKEN", Usage: "GitLab access token with the api scope"},
+	{Name: "GITLAB_API_URL", Usage: "GitLab REST API URL (defaults to CI_API_V4_URL, then gitlab.com)"},
+	{Name: "BITBUCKET_TOKEN", Usage: "Bitbucket access token, or username:app_password"},
+	{Name: "BITBUCKET_API_URL", Usage: "Bitbucket REST API URL"},
+	{Name: "GITHUB_REPOSITORY", Usage: "base repository as owner/repo (group/project on GitLab; defaults to the CI repository)"},
+	{Name: "GITHUB_API_URL", Usage: "GitHub REST API URL (for GitHub Enterprise Server)"},
+	{Name: "GITHUB_GRAPHQL_URL", Usage: "GitHub GraphQL API URL (for GitHub Enterprise Server)"},
+	{Name: "GITHUB_EVENT_PATH", Usage: "event payload the fork head repository is read from"},
+	{Name: "HEAD_REPOSITORY", Usage: "repository the branch was pushed to, for forks"},
+	{Name: "BRANCH_NAME", Usage: "head branch of the pull/merge request (set by commit in the run command)"},
+	{Name: "BASE_REF", Usage: "base branch of the pull request (defaults to the default branch)"},
+	{Name: "PR_TITLE", Usage: "pull request title"},
+	{Name: "PR_BODY", Usage: "pull request body"},
+	{Name: "PR_DRAFT", Usage: "create the pull request as a draft", Default: "false", Bool: true},
+	{Name: "PR_LABELS", Usage: "comma-separated labels"},
+	{Name: "PR_REVIEWERS", Usage: "comma-separated reviewers (account IDs or {UUIDs} on Bitbucket)"},
+	{Name: "PR_TEAMS_REVIEWERS", Usage: "comma-separated team reviewers (GitHub only)"},
+	{Name: "PR_ASSIGNEES", Usage: "comma-separated assignees (not on Bitbucket)"},
+}
+
+var ciVars = []envVar{
+	{Name: "CI_PROVIDER", Usage: "CI service: github, gitlab, bitbucket or generic (detected by default)"},
+	{Name: "OUTPUT_FILE", Usage: "file outputs are appended to as name=value lines outside GitHub Actions"},
+}
+
+var logVars = []envVar{
+	{Name: "LOG_FORMAT", Usage: "log format: text, github or json (defaults to github inside GitHub Actions)"},
+	{Name: "LOG_LEVEL", Usage: "minimum log level: debug, info, warn or error"},
+}
+
+// mergeVars concatenates groups, keeping the first definition of a variable.
+func mergeVars(groups ...[]envVar) []envVar {
+	var res []envVar
+	for _, group := range groups {
+		for _, v := range group {
+			if !slices.ContainsFunc(res, func(seen envVar) bool { return seen.Name == v.Name }) {
+				res = append(res, v)
+			}
+		}
+	}
+	return res
+}
+
+// flagName turns an environment variable name into a flag name.
+func flagName(env string) string {
+	return strings.ReplaceAll(strings.ToLower(env), "_", "-")
+}
+
+// bindEnvFlags defines a flag per variable. A flag given on the command line
+// overrides the environment variable it mirrors.
+func bindEnvFlags(fs *flag.FlagSet, vars []envVar) {
+	for _, v := range vars {
+		usage := v.Usage + " (" + v.Name + ")"
+		set := func(value string) error { return os.Setenv(v.Name, value) }
+
+		if v.Bool {
+			fs.BoolFunc(flagName(v.Name), usage, set)
+		} else {
+			fs.Func(flagName(v.Name), usage, set)
+		}
+	}
+}
+
+// applyDefaults sets the action defaults of variables that are still unset,
+// so a step behaves the same as in the action when run by hand.
+func applyDefaults(vars []envVar) error {
+	for _, v := range vars {
+		if v.Default == "" {
+			continue
+		}
+		if _, ok := os.LookupEnv(v.Name); ok {
+			continue
+		}
+		if err := os.Setenv(v.Name, v.Default); err != nil {
+			return err
+		}
+	}
+	return nil
+}
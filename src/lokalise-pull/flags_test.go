@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestFlagName(t *testing.T) {
+	t.Parallel()
+
+	if got := flagName("LOKALISE_API_KEY"); got != "lokalise-api-key" {
+		t.Fatalf("unexpected flag name %q", got)
+	}
+}
+
+func TestBindEnvFlags_OverridesEnvironment(t *testing.T) {
+	t.Setenv("BASE_LANG", "en")
+	t.Setenv("FLAT_NAMING", "false")
+	t.Setenv("FORCE_PUSH", "")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	bindEnvFlags(fs, mergeVars(translationVars, commitVars))
+
+	if err := fs.Parse([]string{"--base-lang=fr_FR", "--flat-naming", "--force-push=false"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for key, want := range map[string]string{"BASE_LANG": "fr_FR", "FLAT_NAMING": "true", "FORCE_PUSH": "false"} {
+		if got := os.Getenv(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestApplyDefaults_KeepsSetVariables(t *testing.T) {
+	t.Setenv("TEMP_BRANCH_PREFIX", "i18n")
+	t.Setenv("GIT_COMMIT_MESSAGE", "")
+	os.Unsetenv("GIT_COMMIT_MESSAGE")
+
+	if err := applyDefaults(commitVars); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := os.Getenv("TEMP_BRANCH_PREFIX"); got != "i18n" {
+		t.Fatalf("TEMP_BRANCH_PREFIX = %q, want i18n", got)
+	}
+	if got := os.Getenv("GIT_COMMIT_MESSAGE"); got != "Translations update" {
+		t.Fatalf("GIT_COMMIT_MESSAGE = %q, want the action default", got)
+	}
+}
+
+func TestMergeVars_KeepsFirstDefinition(t *testing.T) {
+	t.Parallel()
+
+	vars := mergeVars(commitVars, prVars)
+
+	seen := map[string]int{}
+	for _, v := range vars {
+		seen[v.Name]++
+		if v.Name == "BASE_REF" && v.Usage != commitVars[2].Usage {
+			t.Fatalf("BASE_REF should keep the commit usage, got %q", v.Usage)
+		}
+	}
+	for name, n := range seen {
+		if n > 1 {
+			t.Errorf("%s defined %d times", name, n)
+		}
+	}
+}
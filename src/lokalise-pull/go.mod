@@ -0,0 +1,36 @@
+module github.com/lokalise/lokalise-pull-action/src/lokalise-pull
+
+go 1.26
+
+toolchain go1.26.4
+
+require (
+	github.com/lokalise/lokalise-pull-action/src/actionlog v0.0.0
+	github.com/lokalise/lokalise-pull-action/src/cienv v0.0.0
+	github.com/lokalise/lokalise-pull-action/src/commit_changes v0.0.0
+	github.com/lokalise/lokalise-pull-action/src/detect_changed_files v0.0.0
+	github.com/lokalise/lokalise-pull-action/src/lokalise_download v0.0.0
+	github.com/lokalise/lokalise-pull-action/src/pull_request v0.0.0
+)
+
+require (
+	github.com/bodrovis/lokalise-actions-common/v2 v2.15.0 // indirect
+	github.com/bodrovis/lokex/v2 v2.3.1 // indirect
+	github.com/lokalise/lokalise-pull-action/src/command v0.0.0 // indirect
+	github.com/lokalise/lokalise-pull-action/src/errkind v0.0.0 // indirect
+	github.com/lokalise/lokalise-pull-action/src/lockfile v0.0.0 // indirect
+	go.yaml.in/yaml/v4 v4.0.0-rc.6 // indirect
+	golang.org/x/sync v0.21.0 // indirect
+)
+
+replace (
+	github.com/lokalise/lokalise-pull-action/src/actionlog => ../actionlog
+	github.com/lokalise/lokalise-pull-action/src/cienv => ../cienv
+	github.com/lokalise/lokalise-pull-action/src/command => ../command
+	github.com/lokalise/lokalise-pull-action/src/commit_changes => ../commit_changes
+	github.com/lokalise/lokalise-pull-action/src/detect_changed_files => ../detect_changed_files
+	github.com/lokalise/lokalise-pull-action/src/errkind => ../errkind
+	github.com/lokalise/lokalise-pull-action/src/lockfile => ../lockfile
+	github.com/lokalise/lokalise-pull-action/src/lokalise_download => ../lokalise_download
+	github.com/lokalise/lokalise-pull-action/src/pull_request => ../pull_request
+)
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+const localFlag = "local"
+
+// gitFunc runs git with args and returns its trimmed stdout.
+type gitFunc func(args ...string) (string, error)
+
+func runGit(args ...string) (string, error) {
+	out, err := exec.Command("git", args...).Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// setupLocalEnv fills the variables GitHub Actions would provide from the git
+// repository in the working directory: GITHUB_SHA from HEAD, GITHUB_REF_NAME,
+// GITHUB_HEAD_REF and BASE_REF from the current branch, GITHUB_ACTOR and the
+// commit identity from the git user ($USER when git has no user.name). The identity is scoped to the commit so the developer's git
+// config stays as it is. Variables that are already set, e.g. by flags, are kept.
+//
+// GITHUB_OUTPUT, OUTPUT_FILE and GITHUB_STEP_SUMMARY are cleared: outputs are printed
+// instead, and a file left over in the shell must not receive them.
+func setupLocalEnv(git gitFunc) error {
+	sha, err := git("rev-parse", "HEAD")
+	if err != nil {
+		return fmt.Errorf("local mode needs a git repository with at least one commit: %w", err)
+	}
+
+	branch, err := git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil || branch == "HEAD" {
+		slog.Warn("cannot determine the current branch (detached HEAD?), pass --github-ref-name and --base-ref")
+		branch = ""
+	}
+
+	// Both are optional: the commit step falls back to the actor.
+	name, _ := git("config", "user.name")
+	email, _ := git("config", "user.email")
+	actor := name
+	if actor == "" {
+		actor = strings.TrimSpace(os.Getenv("USER"))
+	}
+
+	values := []struct{ key, value string }{
+		{"GITHUB_SHA", sha},
+		{"GITHUB_REF_NAME", branch},
+		{"GITHUB_HEAD_REF", branch},
+		{"BASE_REF", branch},
+		{"GITHUB_ACTOR", actor},
+		{"GIT_USER_NAME", name},
+		{"GIT_USER_EMAIL", email},
+		{"GIT_IDENTITY_SCOPE", "commit"},
+	}
+	for _, v := range values {
+		if v.value == "" || os.Getenv(v.key) != "" {
+			continue
+		}
+		if err := os.Setenv(v.key, v.value); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range []string{"GITHUB_OUTPUT", "OUTPUT_FILE", "GITHUB_STEP_SUMMARY"} {
+		if err := os.Unsetenv(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// printOutputs writes the outputs as sorted name=value lines.
+func printOutputs(w io.Writer, values map[string]string) {
+	if len(values) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "Outputs:")
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		fmt.Fprintf(w, "%s=%s\n", name, values[name])
+	}
+}
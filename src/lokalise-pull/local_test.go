@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func fakeGit(answers map[string]string) gitFunc {
+	return func(args ...string) (string, error) {
+		out, ok := answers[strings.Join(args, " ")]
+		if !ok {
+			return "", errors.New("exit status 1")
+		}
+		return out, nil
+	}
+}
+
+func clearLocalEnv(t *testing.T) {
+	t.Helper()
+
+	for _, key := range []string{"GITHUB_SHA", "GITHUB_REF_NAME", "GITHUB_HEAD_REF", "BASE_REF", "GITHUB_ACTOR", "GIT_USER_NAME", "GIT_USER_EMAIL", "GIT_IDENTITY_SCOPE", "GITHUB_OUTPUT", "OUTPUT_FILE", "GITHUB_STEP_SUMMARY"} {
+		t.Setenv(key, "")
+	}
+}
+
+func TestSetupLocalEnv(t *testing.T) {
+	clearLocalEnv(t)
+	t.Setenv("BASE_REF", "develop")
+	t.Setenv("GITHUB_OUTPUT", "/tmp/stale-output")
+
+	git := fakeGit(map[string]string{
+		"rev-parse HEAD":              "0123456789abcdef",
+		"rev-parse --abbrev-ref HEAD": "feature/i18n",
+		"config user.name":            "Jane Doe",
+		"config user.email":           "jane@example.com",
+	})
+	if err := setupLocalEnv(git); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"GITHUB_SHA":         "0123456789abcdef",
+		"GITHUB_REF_NAME":    "feature/i18n",
+		"GITHUB_HEAD_REF":    "feature/i18n",
+		"BASE_REF":           "develop",
+		"GITHUB_ACTOR":       "Jane Doe",
+		"GIT_USER_NAME":      "Jane Doe",
+		"GIT_USER_EMAIL":     "jane@example.com",
+		"GIT_IDENTITY_SCOPE": "commit",
+	}
+	for key, value := range want {
+		if got := os.Getenv(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+	if _, ok := os.LookupEnv("GITHUB_OUTPUT"); ok {
+		t.Error("GITHUB_OUTPUT should be unset in local mode")
+	}
+}
+
+func TestSetupLocalEnv_DetachedHead(t *testing.T) {
+	clearLocalEnv(t)
+
+	git := fakeGit(map[string]string{
+		"rev-parse HEAD":              "0123456789abcdef",
+		"rev-parse --abbrev-ref HEAD": "HEAD",
+	})
+	if err := setupLocalEnv(git); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := os.Getenv("GITHUB_REF_NAME"); got != "" {
+		t.Fatalf("GITHUB_REF_NAME = %q, want empty on a detached HEAD", got)
+	}
+}
+
+func TestSetupLocalEnv_ActorFromUser(t *testing.T) {
+	clearLocalEnv(t)
+	t.Setenv("USER", "jdoe")
+
+	git := fakeGit(map[string]string{
+		"rev-parse HEAD":              "0123456789abcdef",
+		"rev-parse --abbrev-ref HEAD": "main",
+	})
+	if err := setupLocalEnv(git); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := os.Getenv("GITHUB_ACTOR"); got != "jdoe" {
+		t.Fatalf("GITHUB_ACTOR = %q, want the $USER fallback", got)
+	}
+	if got := os.Getenv("GIT_USER_NAME"); got != "" {
+		t.Fatalf("GIT_USER_NAME = %q, want empty without a git user.name", got)
+	}
+}
+
+func TestSetupLocalEnv_NotARepository(t *testing.T) {
+	clearLocalEnv(t)
+
+	if err := setupLocalEnv(fakeGit(nil)); err == nil {
+		t.Fatal("expected an error outside a git repository")
+	}
+}
+
+func TestPrintOutputs(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	printOutputs(&buf, map[string]string{"has_changes": "true", "branch_name": "lok"})
+
+	if want := "Outputs:\nbranch_name=lok\nhas_changes=true\n"; buf.String() != want {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestRunCLI_LocalDetect(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	clearLocalEnv(t)
+	t.Setenv("LOG_FORMAT", "text")
+	for _, key := range []string{"TRANSLATIONS_PATH", "FLAT_NAMING", "FILE_FORMAT", "FILE_EXT", "BASE_LANG", "ALWAYS_PULL_BASE", "STRICT_CONFIG"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	write("locales/en.json", "{}")
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+	write("locales/fr.json", "{}")
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"detect", "--local", "--flat-naming"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code = %d\nstdout:\n%s\nstderr:\n%s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Outputs:\nhas_changes=true\n") {
+		t.Fatalf("expected has_changes=true in the outputs:\n%s", stdout.String())
+	}
+}
@@ -0,0 +1,166 @@
+// Command lokalise-pull runs the steps of the Lokalise pull action: download
+// translations, detect changed files, commit them and open a pull request.
+//
+// Each step is a subcommand configured by the same environment variables the
+// action sets; every variable is also available as a flag, so the pipeline can
+// be reproduced outside GitHub Actions:
+//
+//	lokalise-pull run --lokalise-api-key=... --lokalise-project-id=... --base-ref=main
+//
+// With --local, the values GitHub Actions provides (GITHUB_SHA, GITHUB_ACTOR,
+// GITHUB_REF_NAME, ...) are taken from the git repository in the working
+// directory and the outputs are printed to stdout:
+//
+//	lokalise-pull run --local --skip-push --lokalise-api-key=... --lokalise-project-id=...
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/lokalise/lokalise-pull-action/src/actionlog"
+	commitchanges "github.com/lokalise/lokalise-pull-action/src/commit_changes"
+	detectchangedfiles "github.com/lokalise/lokalise-pull-action/src/detect_changed_files"
+	lokalisedownload "github.com/lokalise/lokalise-pull-action/src/lokalise_download"
+	pullrequest "github.com/lokalise/lokalise-pull-action/src/pull_request"
+)
+
+// stepFunc runs one step and returns its exit code; outputs go to write.
+type stepFunc func(write func(string, string) bool) int
+
+// command is a subcommand of the CLI.
+type command struct {
+	name    string
+	summary string
+	vars    []envVar
+	run     func(fs *flag.FlagSet, out *outputs) int
+}
+
+var commands = []command{
+	{
+		name:    "download",
+		summary: "Download translation files from Lokalise",
+		vars:    mergeVars(translationVars, downloadVars, ciVars, logVars),
+		run:     runStep(lokalisedownload.Main),
+	},
+	{
+		name:    "detect",
+		summary: "Detect changed translation files",
+		vars:    mergeVars(translationVars, detectVars, ciVars, logVars),
+		run:     runStep(detectchangedfiles.Main),
+	},
+	{
+		name:    "commit",
+		summary: "Commit changed translation files and push the branch",
+		vars:    mergeVars(translationVars, commitVars, ciVars, logVars),
+		run:     runStep(commitchanges.Main),
+	},
+	{
+		name:    "pr",
+		summary: "Create or update the pull/merge request for the pushed branch",
+		vars:    mergeVars(prVars, ciVars, logVars),
+		run:     runStep(pullrequest.Main),
+	},
+	{
+		name:    "run",
+		summary: "Run download, detect, commit and pr in sequence",
+		vars:    mergeVars(translationVars, downloadVars, commitVars, prVars, ciVars, logVars),
+		run:     runPipelineCommand,
+	},
+}
+
+func main() {
+	os.Exit(runCLI(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// runCLI parses args, configures the environment and logging, and runs the subcommand.
+// Logs and, in local mode, the outputs go to stdout; usage errors go to stderr.
+func runCLI(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		printUsage(stderr)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n", args[0])
+		printUsage(stderr)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("lokalise-pull "+cmd.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	bindEnvFlags(fs, cmd.vars)
+	local := fs.Bool(localFlag, false, "run outside GitHub Actions: read GITHUB_* values from the local git repository and print outputs")
+	if cmd.name == "run" {
+		bindPipelineFlags(fs)
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "unexpected arguments: %s\n", strings.Join(fs.Args(), " "))
+		return 2
+	}
+
+	if err := applyDefaults(cmd.vars); err != nil {
+		fmt.Fprintf(stderr, "cannot set defaults: %v\n", err)
+		return 1
+	}
+
+	actionlog.Setup(stdout)
+
+	if *local {
+		if err := setupLocalEnv(runGit); err != nil {
+			slog.Error(err.Error())
+			return 1
+		}
+	}
+
+	out := newOutputs()
+	code := cmd.run(fs, out)
+	if *local {
+		printOutputs(stdout, out.values)
+	}
+
+	return code
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func runStep(step stepFunc) func(*flag.FlagSet, *outputs) int {
+	return func(_ *flag.FlagSet, out *outputs) int {
+		return step(out.write)
+	}
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: lokalise-pull <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-9s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Every flag mirrors an environment variable (--base-lang sets BASE_LANG).")
+	fmt.Fprintln(w, "Run 'lokalise-pull <command> -h' to list the flags of a command.")
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunCLI_Usage(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if code := runCLI(nil, &buf, &buf); code != 2 {
+		t.Fatalf("exit code = %d, want 2", code)
+	}
+	for _, cmd := range commands {
+		if !strings.Contains(buf.String(), cmd.name) {
+			t.Fatalf("usage does not list %q:\n%s", cmd.name, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if code := runCLI([]string{"help"}, &buf, &buf); code != 0 {
+		t.Fatalf("help exit code = %d, want 0", code)
+	}
+}
+
+func TestRunCLI_BadArguments(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string][]string{
+		"unknown command": {"deploy"},
+		"unknown flag":    {"detect", "--no-such-flag"},
+		"extra argument":  {"detect", "locales"},
+	}
+
+	for name, args := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			if code := runCLI(args, &buf, &buf); code != 2 {
+				t.Fatalf("exit code = %d, want 2 (%s)", code, buf.String())
+			}
+		})
+	}
+}
+
+func TestRunCLI_CommandHelpListsEnvFlags(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if code := runCLI([]string{"run", "-h"}, &buf, &buf); code != 0 {
+		t.Fatalf("exit code = %d, want 0", code)
+	}
+
+	for _, want := range []string{"-lokalise-api-key", "(LOKALISE_API_KEY)", "-temp-branch-prefix", "-pr-draft", "-skip-pr"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("help is missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestRunCLI_ReportsStepExitCode(t *testing.T) {
+	// Flags and defaults are applied with os.Setenv; t.Setenv restores them afterwards.
+	for _, key := range []string{"GITHUB_OUTPUT", "GITHUB_TOKEN", "GITHUB_REPOSITORY", "BRANCH_NAME", "PR_DRAFT"} {
+		t.Setenv(key, "")
+	}
+	t.Setenv("LOG_FORMAT", "text")
+
+	var buf bytes.Buffer
+	if code := runCLI([]string{"pr", "--github-repository=acme/app", "--branch-name=lok"}, &buf, &buf); code != 2 {
+		t.Fatalf("exit code = %d, want 2 for a missing token", code)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import "github.com/lokalise/lokalise-pull-action/src/cienv"
+
+// outputs collects step outputs, so the run command can decide which step
+// comes next. When the CI provides an output file (GITHUB_OUTPUT, or OUTPUT_FILE
+// elsewhere) they are also written to it.
+type outputs struct {
+	values  map[string]string
+	forward func(string, string) bool
+}
+
+func newOutputs() *outputs {
+	out := &outputs{values: map[string]string{}}
+	if cienv.OutputPath() != "" {
+		out.forward = cienv.WriteOutput
+	}
+	return out
+}
+
+// write has the signature of cienv.WriteOutput.
+func (o *outputs) write(name, value string) bool {
+	o.values[name] = value
+	if o.forward == nil {
+		return true
+	}
+	return o.forward(name, value)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutputs_RecordsAndForwards(t *testing.T) {
+	t.Parallel()
+
+	var forwarded []string
+	out := &outputs{values: map[string]string{}, forward: func(name, value string) bool {
+		forwarded = append(forwarded, name+"="+value)
+		return value != "fail"
+	}}
+
+	if !out.write("has_changes", "true") {
+		t.Fatal("write should succeed")
+	}
+	if out.write("branch_name", "fail") {
+		t.Fatal("a failing forward should fail the write")
+	}
+
+	if out.values["has_changes"] != "true" || out.values["branch_name"] != "fail" || len(forwarded) != 2 {
+		t.Fatalf("unexpected state: %v %v", out.values, forwarded)
+	}
+}
+
+func TestNewOutputs_WithoutGitHubOutput(t *testing.T) {
+	t.Setenv("CI_PROVIDER", "github")
+	t.Setenv("GITHUB_OUTPUT", "")
+
+	out := newOutputs()
+	if out.forward != nil || !out.write("has_changes", "false") {
+		t.Fatal("outputs should only be recorded outside GitHub Actions")
+	}
+}
+
+func TestNewOutputs_OutputFileOutsideGitHub(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lokalise.env")
+	t.Setenv("CI_PROVIDER", "gitlab")
+	t.Setenv("OUTPUT_FILE", path)
+
+	out := newOutputs()
+	if !out.write("has_changes", "true") {
+		t.Fatal("write should succeed")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "has_changes=true\n" {
+		t.Fatalf("unexpected output file: %q", data)
+	}
+}
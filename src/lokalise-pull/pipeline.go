@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"os"
+	"strconv"
+
+	commitchanges "github.com/lokalise/lokalise-pull-action/src/commit_changes"
+	detectchangedfiles "github.com/lokalise/lokalise-pull-action/src/detect_changed_files"
+	lokalisedownload "github.com/lokalise/lokalise-pull-action/src/lokalise_download"
+	pullrequest "github.com/lokalise/lokalise-pull-action/src/pull_request"
+)
+
+const skipPRFlag = "skip-pr"
+
+// pipeline chains the steps the way action.yml does: each step only runs
+// when the outputs of the previous one say there is work left.
+type pipeline struct {
+	download stepFunc
+	detect   stepFunc
+	commit   stepFunc
+	pr       stepFunc
+	skipPR   bool
+}
+
+func bindPipelineFlags(fs *flag.FlagSet) {
+	fs.Bool(skipPRFlag, false, "stop after pushing the branch, without creating a pull request")
+}
+
+func runPipelineCommand(fs *flag.FlagSet, out *outputs) int {
+	return newPipeline(fs).run(out)
+}
+
+func newPipeline(fs *flag.FlagSet) pipeline {
+	p := pipeline{
+		download: lokalisedownload.Main,
+		detect:   detectchangedfiles.Main,
+		commit:   commitchanges.Main,
+		pr:       pullrequest.Main,
+		skipPR:   flagIsSet(fs, skipPRFlag),
+	}
+
+	// Without a pushed branch or a token there is nothing to open a pull request from.
+	if pushSkipped, _ := strconv.ParseBool(os.Getenv("SKIP_PUSH")); pushSkipped {
+		p.skipPR = true
+	}
+	if tokenVar := pullrequest.TokenVar(); flagIsSet(fs, localFlag) && os.Getenv(tokenVar) == "" {
+		slog.Info(tokenVar + " is not set, the pull request step will be skipped.")
+		p.skipPR = true
+	}
+
+	return p
+}
+
+func flagIsSet(fs *flag.FlagSet, name string) bool {
+	f := fs.Lookup(name)
+	return f != nil && f.Value.String() == "true"
+}
+
+// run returns the exit code of the first failing step, or 0.
+func (p pipeline) run(out *outputs) int {
+	if code := p.download(out.write); code != 0 {
+		return code
+	}
+	if out.values["download_skipped"] == "true" {
+		slog.Info("Lokalise project unchanged, skipping change detection.")
+		return 0
+	}
+
+	if code := p.detect(out.write); code != 0 {
+		return code
+	}
+	if out.values["has_changes"] != "true" {
+		slog.Info("No translation changes, nothing to commit.")
+		return 0
+	}
+
+	if code := p.commit(out.write); code != 0 {
+		return code
+	}
+	if out.values["commit_created"] != "true" {
+		slog.Info("No commit was created, skipping the pull request.")
+		return 0
+	}
+
+	if p.skipPR {
+		slog.Info("Commit created, skipping the pull request.", "branch", out.values["branch_name"])
+		return 0
+	}
+	if err := os.Setenv("BRANCH_NAME", out.values["branch_name"]); err != nil {
+		slog.Error("cannot set BRANCH_NAME", "error", err)
+		return 1
+	}
+
+	return p.pr(out.write)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"slices"
+	"testing"
+)
+
+// fakeSteps records the order of the steps and writes the given outputs.
+type fakeSteps struct {
+	calls []string
+}
+
+func (f *fakeSteps) step(name string, code int, outputs map[string]string) stepFunc {
+	return func(write func(string, string) bool) int {
+		f.calls = append(f.calls, name)
+		for k, v := range outputs {
+			write(k, v)
+		}
+		return code
+	}
+}
+
+func TestPipeline_RunsAllSteps(t *testing.T) {
+	t.Setenv("BRANCH_NAME", "stale")
+
+	f := &fakeSteps{}
+	p := pipeline{
+		download: f.step("download", 0, nil),
+		detect:   f.step("detect", 0, map[string]string{"has_changes": "true"}),
+		commit:   f.step("commit", 0, map[string]string{"commit_created": "true", "branch_name": "lok_main_1"}),
+		pr: func(write func(string, string) bool) int {
+			if got := os.Getenv("BRANCH_NAME"); got != "lok_main_1" {
+				t.Errorf("BRANCH_NAME = %q, want the committed branch", got)
+			}
+			return f.step("pr", 0, nil)(write)
+		},
+	}
+
+	if code := p.run(&outputs{values: map[string]string{}}); code != 0 {
+		t.Fatalf("unexpected exit code %d", code)
+	}
+	if want := []string{"download", "detect", "commit", "pr"}; !slices.Equal(f.calls, want) {
+		t.Fatalf("calls = %v, want %v", f.calls, want)
+	}
+}
+
+func TestPipeline_StopsEarly(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		download map[string]string
+		detect   map[string]string
+		commit   map[string]string
+		skipPR   bool
+		want     []string
+	}{
+		{
+			name:     "project unchanged",
+			download: map[string]string{"download_skipped": "true"},
+			want:     []string{"download"},
+		},
+		{
+			name:   "no changes",
+			detect: map[string]string{"has_changes": "false"},
+			want:   []string{"download", "detect"},
+		},
+		{
+			name:   "nothing committed",
+			detect: map[string]string{"has_changes": "true"},
+			commit: map[string]string{"error_kind": "nothing_to_commit"},
+			want:   []string{"download", "detect", "commit"},
+		},
+		{
+			name:   "pull request skipped",
+			detect: map[string]string{"has_changes": "true"},
+			commit: map[string]string{"commit_created": "true", "branch_name": "lok"},
+			skipPR: true,
+			want:   []string{"download", "detect", "commit"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f := &fakeSteps{}
+			p := pipeline{
+				download: f.step("download", 0, tt.download),
+				detect:   f.step("detect", 0, tt.detect),
+				commit:   f.step("commit", 0, tt.commit),
+				pr:       f.step("pr", 0, nil),
+				skipPR:   tt.skipPR,
+			}
+
+			if code := p.run(&outputs{values: map[string]string{}}); code != 0 {
+				t.Fatalf("unexpected exit code %d", code)
+			}
+			if !slices.Equal(f.calls, tt.want) {
+				t.Fatalf("calls = %v, want %v", f.calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestPipeline_ReturnsFailingExitCode(t *testing.T) {
+	t.Parallel()
+
+	f := &fakeSteps{}
+	p := pipeline{
+		download: f.step("download", 0, nil),
+		detect:   f.step("detect", 7, nil),
+		commit:   f.step("commit", 0, nil),
+		pr:       f.step("pr", 0, nil),
+	}
+
+	if code := p.run(&outputs{values: map[string]string{}}); code != 7 {
+		t.Fatalf("exit code = %d, want 7", code)
+	}
+	if want := []string{"download", "detect"}; !slices.Equal(f.calls, want) {
+		t.Fatalf("calls = %v, want %v", f.calls, want)
+	}
+}
+
+func TestNewPipeline_SkipsPullRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		wantSkip bool
+	}{
+		{name: "default", wantSkip: false},
+		{name: "flag", args: []string{"--skip-pr"}, wantSkip: true},
+		{name: "push skipped", env: map[string]string{"SKIP_PUSH": "true"}, wantSkip: true},
+		{name: "local without token", args: []string{"--local"}, wantSkip: true},
+		{name: "local with token", args: []string{"--local"}, env: map[string]string{"GITHUB_TOKEN": "tok"}, wantSkip: false},
+		{name: "local with the token of another provider", args: []string{"--local"}, env: map[string]string{"GITHUB_TOKEN": "tok", "PR_PROVIDER": "gitlab"}, wantSkip: true},
+		{name: "local on GitLab", args: []string{"--local"}, env: map[string]string{"GITLAB_TOKEN": "glpat", "PR_PROVIDER": "gitlab"}, wantSkip: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SKIP_PUSH", tt.env["SKIP_PUSH"])
+			t.Setenv("GITHUB_TOKEN", tt.env["GITHUB_TOKEN"])
+			t.Setenv("GITLAB_TOKEN", tt.env["GITLAB_TOKEN"])
+			t.Setenv("PR_PROVIDER", tt.env["PR_PROVIDER"])
+			t.Setenv("CI_PROVIDER", "generic")
+
+			fs := flag.NewFlagSet("run", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			fs.Bool(localFlag, false, "")
+			bindPipelineFlags(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := newPipeline(fs).skipPR; got != tt.wantSkip {
+				t.Fatalf("skipPR = %v, want %v", got, tt.wantSkip)
+			}
+		})
+	}
+}
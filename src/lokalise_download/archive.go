@@ -0,0 +1,131 @@
+package lokalisedownload
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Extraction limits. They mirror the caps lokex applies to bundles it unzips itself.
+const (
+	maxArchiveFiles      = 20000
+	maxArchiveTotalBytes = 2 << 30   // 2 GiB
+	maxArchiveFileBytes  = 512 << 20 // 512 MiB
+)
+
+// extractArchive unzips a Lokalise bundle into destDir.
+// Used for archives that do not come straight from lokex (cache hits, local bundles),
+// so it applies the same safety rules: no zip-slip, no symlinks or devices, size caps.
+func extractArchive(archivePath, destDir string) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("cannot open archive %s: %w", archivePath, err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	if len(zr.File) > maxArchiveFiles {
+		return fmt.Errorf("archive has too many entries: %d > %d", len(zr.File), maxArchiveFiles)
+	}
+
+	root, err := filepath.Abs(destDir)
+	if err != nil {
+		return fmt.Errorf("cannot resolve destination %s: %w", destDir, err)
+	}
+
+	var total int64
+	for _, f := range zr.File {
+		n, err := extractArchiveEntry(f, root, maxArchiveTotalBytes-total)
+		if err != nil {
+			return err
+		}
+		total += n
+	}
+
+	return nil
+}
+
+// extractArchiveEntry writes a single entry and returns the number of bytes written.
+// budget is what is left of the total size cap.
+func extractArchiveEntry(f *zip.File, root string, budget int64) (int64, error) {
+	target, err := archiveEntryPath(root, f.Name)
+	if err != nil || target == "" {
+		return 0, err
+	}
+
+	mode := f.Mode()
+	switch {
+	case mode.IsDir():
+		return 0, os.MkdirAll(target, 0o755)
+	case !mode.IsRegular():
+		return 0, fmt.Errorf("archive entry %q is not a regular file", f.Name)
+	}
+
+	limit := min(int64(maxArchiveFileBytes), budget)
+	if f.UncompressedSize64 > uint64(limit) {
+		return 0, fmt.Errorf("archive entry %q is too large", f.Name)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return 0, err
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return 0, fmt.Errorf("cannot read archive entry %q: %w", f.Name, err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return 0, err
+	}
+
+	// Declared sizes can lie, so the copy itself is capped as well.
+	n, err := io.Copy(out, io.LimitReader(rc, limit+1))
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return n, fmt.Errorf("cannot extract archive entry %q: %w", f.Name, err)
+	}
+	if n > limit {
+		return n, fmt.Errorf("archive entry %q is too large", f.Name)
+	}
+
+	return n, nil
+}
+
+// archiveEntryPath resolves an entry name below root and rejects anything escaping it.
+// Leading "/" and "./" are dropped: bundles exported with directory_prefix "/" use them.
+// An empty result means the entry is the root itself.
+func archiveEntryPath(root, name string) (string, error) {
+	name = strings.ReplaceAll(name, `\`, "/")
+	if strings.IndexByte(name, 0) != -1 {
+		return "", fmt.Errorf("invalid archive entry name %q", name)
+	}
+
+	rel := path.Clean(name)
+	for strings.HasPrefix(rel, "/") || strings.HasPrefix(rel, "./") {
+		rel = strings.TrimPrefix(strings.TrimPrefix(rel, "/"), "./")
+	}
+	if rel == "" || rel == "." {
+		return "", nil
+	}
+
+	for seg := range strings.SplitSeq(rel, "/") {
+		if seg == ".." {
+			return "", fmt.Errorf("archive entry %q escapes the destination directory", name)
+		}
+	}
+
+	target := filepath.FromSlash(rel)
+	if filepath.IsAbs(target) || filepath.VolumeName(target) != "" {
+		return "", fmt.Errorf("archive entry %q escapes the destination directory", name)
+	}
+
+	return filepath.Join(root, target), nil
+}
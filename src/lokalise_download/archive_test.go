@@ -0,0 +1,109 @@
+package lokalisedownload
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// zipEntry describes a file to put into a test archive.
+type zipEntry struct {
+	name    string
+	content string
+	mode    os.FileMode
+}
+
+func writeTestZip(t *testing.T, path string, entries ...zipEntry) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create %s: %v", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	zw := zip.NewWriter(f)
+	for _, e := range entries {
+		hdr := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		if e.mode != 0 {
+			hdr.SetMode(e.mode)
+		}
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatalf("zip header %s: %v", e.name, err)
+		}
+		if _, err := w.Write([]byte(e.content)); err != nil {
+			t.Fatalf("zip write %s: %v", e.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip close: %v", err)
+	}
+}
+
+func TestExtractArchive_WritesFilesBelowDest(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "bundle.zip")
+	writeTestZip(t, archive,
+		zipEntry{name: "/"},
+		zipEntry{name: "locales/", mode: os.ModeDir | 0o755},
+		zipEntry{name: "/locales/en.json", content: `{"a":"b"}`},
+		zipEntry{name: "./locales/fr/app.json", content: `{}`},
+	)
+
+	dest := filepath.Join(dir, "out")
+	if err := extractArchive(archive, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dest, "locales", "en.json"))
+	if err != nil || string(got) != `{"a":"b"}` {
+		t.Fatalf("unexpected en.json: %q, %v", got, err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "locales", "fr", "app.json")); err != nil {
+		t.Fatalf("fr/app.json not extracted: %v", err)
+	}
+}
+
+func TestExtractArchive_RejectsUnsafeEntries(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry zipEntry
+		want  string
+	}{
+		{"parent traversal", zipEntry{name: "../evil.json", content: "x"}, "escapes"},
+		{"nested traversal", zipEntry{name: "locales/../../evil.json", content: "x"}, "escapes"},
+		{"symlink", zipEntry{name: "link", content: "/etc/passwd", mode: os.ModeSymlink | 0o777}, "not a regular file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			archive := filepath.Join(dir, "bundle.zip")
+			writeTestZip(t, archive, tt.entry)
+
+			err := extractArchive(archive, filepath.Join(dir, "out"))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got %v", tt.want, err)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "evil.json")); !os.IsNotExist(err) {
+				t.Fatal("file escaped the destination")
+			}
+		})
+	}
+}
+
+func TestExtractArchive_InvalidArchive(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "bundle.zip")
+	writeTestFile(t, archive, "not a zip")
+
+	if err := extractArchive(archive, dir); err == nil {
+		t.Fatal("expected error for invalid archive")
+	}
+}
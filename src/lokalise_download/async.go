@@ -0,0 +1,511 @@
+package lokalisedownload
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bodrovis/lokex/v2/client"
+	"github.com/bodrovis/lokex/v2/client/download"
+
+	"github.com/lokalise/lokalise-pull-action/src/actionlog"
+)
+
+// Statuses of a Lokalise background process.
+const (
+	processQueued    = "queued"
+	processFinished  = "finished"
+	processFailed    = "failed"
+	processCancelled = "cancelled"
+)
+
+// Async export progress event kinds, in the order they usually occur.
+const (
+	asyncStarted    = "started"    // the export was queued, ProcessID is known
+	asyncResumed    = "resumed"    // polling continues a process started by an earlier run
+	asyncPolled     = "poll"       // a poll returned the same status as before
+	asyncStatus     = "status"     // a poll returned a new status
+	asyncDownloaded = "downloaded" // the bundle archive was downloaded, Bytes is set
+	asyncFinished   = "finished"   // the export is done, Err is set on failure
+)
+
+// asyncEvent is a progress update from an async export.
+type asyncEvent struct {
+	Kind       string
+	ProcessID  string
+	Status     string
+	PrevStatus string
+	Poll       int
+	Elapsed    time.Duration
+	Bytes      int64
+	Err        error
+}
+
+type processResponse struct {
+	Process struct {
+		ProcessID string `json:"process_id"`
+		Status    string `json:"status"`
+		Message   string `json:"message"`
+		Details   struct {
+			DownloadURL string `json:"download_url"`
+		} `json:"details"`
+	} `json:"process"`
+}
+
+type asyncKickoffResponse struct {
+	ProcessID string `json:"process_id"`
+}
+
+// asyncTracker turns polling steps into asyncEvents for the progress callback.
+type asyncTracker struct {
+	emit      func(asyncEvent)
+	started   time.Time
+	processID string
+	status    string
+	polls     int
+}
+
+func newAsyncTracker(emit func(asyncEvent)) *asyncTracker {
+	if emit == nil {
+		emit = func(asyncEvent) {}
+	}
+	return &asyncTracker{emit: emit, started: time.Now()}
+}
+
+func (t *asyncTracker) event(kind string) asyncEvent {
+	return asyncEvent{
+		Kind:      kind,
+		ProcessID: t.processID,
+		Status:    t.status,
+		Poll:      t.polls,
+		Elapsed:   time.Since(t.started),
+	}
+}
+
+// begin starts reporting a new or resumed process; the status of a resumed
+// process is unknown until the first poll.
+func (t *asyncTracker) begin(kind, processID string) {
+	t.processID = processID
+	t.polls = 0
+	t.status = ""
+	if kind == asyncStarted {
+		t.status = processQueued
+	}
+	t.emit(t.event(kind))
+}
+
+func (t *asyncTracker) poll(status string) {
+	t.polls++
+	prev := t.status
+	t.status = status
+
+	if status == prev {
+		t.emit(t.event(asyncPolled))
+		return
+	}
+	ev := t.event(asyncStatus)
+	ev.PrevStatus = prev
+	t.emit(ev)
+}
+
+func (t *asyncTracker) downloaded(n int64) {
+	ev := t.event(asyncDownloaded)
+	ev.Bytes = n
+	t.emit(ev)
+}
+
+func (t *asyncTracker) finish(err error) {
+	ev := t.event(asyncFinished)
+	ev.Err = err
+	t.emit(ev)
+}
+
+// pending reports whether the process was still running when the export stopped.
+func (t *asyncTracker) pending() bool {
+	switch t.status {
+	case "", processFinished, processFailed, processCancelled:
+		return false
+	}
+	return true
+}
+
+// DownloadAsync runs the lokex async download and reports every step of it:
+// kickoff, each poll and the archive download.
+func (d *lokaliseDownloader) DownloadAsync(ctx context.Context, dest string, params download.DownloadParams) (string, error) {
+	return d.exportAsync(ctx, params, func() (string, error) {
+		return d.Downloader.DownloadAsync(ctx, dest, params)
+	}, func(bundleURL string) error {
+		return d.DownloadAndUnzip(ctx, bundleURL, dest)
+	})
+}
+
+// exportAsync runs export, a lokex async export, with progress reporting.
+// A process recorded by an earlier run is resumed instead of queuing a new export:
+// it is polled until it ends and fetch gets its bundle. If it cannot be resumed
+// (unknown or failed), a new export is started, unless the process ID was given explicitly.
+func (d *lokaliseDownloader) exportAsync(
+	ctx context.Context,
+	params download.DownloadParams,
+	export func() (string, error),
+	fetch func(bundleURL string) error,
+) (bundleURL string, err error) {
+	tracker := newAsyncTracker(d.progress)
+	defer func() { tracker.finish(err) }()
+
+	if processID, explicit := d.state.resumable(d.client.ProjectID, params); processID != "" {
+		tracker.begin(asyncResumed, processID)
+
+		bundleURL, err = d.resumeExport(ctx, tracker, processID, fetch)
+		err = d.settleExport(ctx, tracker, err)
+		if err == nil || explicit || !resumeFailed(ctx, err) {
+			return bundleURL, err
+		}
+		slog.Warn("cannot resume async export, starting a new one", "process_id", processID, "error", err)
+	}
+
+	started := func(processID string) { d.state.save(d.client.ProjectID, processID, params) }
+	bundleURL, err = d.watcher.watch(&asyncSession{tracker: tracker, started: started}, export)
+
+	return bundleURL, d.settleExport(ctx, tracker, err)
+}
+
+// resumeExport waits for a process started by an earlier run and fetches its bundle.
+func (d *lokaliseDownloader) resumeExport(
+	ctx context.Context,
+	tracker *asyncTracker,
+	processID string,
+	fetch func(bundleURL string) error,
+) (string, error) {
+	bundleURL, err := d.pollProcess(ctx, tracker, processID)
+	if err != nil {
+		return "", err
+	}
+
+	// The watcher only reports the size of the downloaded bundle here.
+	return d.watcher.watch(&asyncSession{tracker: tracker, downloadURL: bundleURL}, func() (string, error) {
+		return bundleURL, fetch(bundleURL)
+	})
+}
+
+// pollProcess polls GET /processes/{id} with the poll settings of the client
+// until the process finishes, and returns its download URL. A process the API
+// refuses to return (not found, forbidden) counts as failed; rate limits and
+// server errors are retried until the poll budget runs out.
+func (d *lokaliseDownloader) pollProcess(ctx context.Context, tracker *asyncTracker, processID string) (string, error) {
+	path := fmt.Sprintf("projects/%s/processes/%s", url.PathEscape(d.client.ProjectID), url.PathEscape(processID))
+	deadline := time.Now().Add(d.client.PollMaxWait)
+	wait := d.client.PollInitialWait
+
+	for {
+		var resp processResponse
+		err := getJSON(ctx, d.client, path, nil, &resp)
+		switch {
+		case ctx.Err() != nil:
+			return "", ctx.Err()
+		case err != nil && !isRetryableAPICall(err):
+			tracker.poll(processFailed)
+			return "", fmt.Errorf("cannot read async export process %s: %w", processID, err)
+		case err == nil:
+			status := strings.ToLower(strings.TrimSpace(resp.Process.Status))
+			tracker.poll(status)
+
+			switch status {
+			case processFinished:
+				if u := strings.TrimSpace(resp.Process.Details.DownloadURL); u != "" {
+					return u, nil
+				}
+				return "", fmt.Errorf("async export process %s finished without a download URL", processID)
+			case processFailed, processCancelled:
+				return "", processEndError(processID, status, resp.Process.Message)
+			}
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return "", fmt.Errorf("async export process %s did not finish (status=%q)", processID, tracker.status)
+		}
+		if err := sleepContext(ctx, min(wait, remaining)); err != nil {
+			return "", err
+		}
+		wait *= 2
+	}
+}
+
+func processEndError(processID, status, message string) error {
+	if message = strings.TrimSpace(message); message != "" {
+		return fmt.Errorf("async export process %s %s: %s", processID, status, message)
+	}
+	return fmt.Errorf("async export process %s %s", processID, status)
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// settleExport updates the state with the outcome of an export and marks the
+// error of a process that is still running. The state keeps a process that is
+// still running, or that finished but whose bundle was not downloaded, so a
+// later run can pick it up.
+func (d *lokaliseDownloader) settleExport(ctx context.Context, tracker *asyncTracker, err error) error {
+	projectID := d.client.ProjectID
+
+	switch {
+	case err == nil:
+		d.state.clear(projectID)
+	case ctx.Err() != nil, tracker.status == processFinished:
+	case tracker.pending():
+		err = fmt.Errorf("%w: %w", err, errExportPending)
+	default:
+		d.state.clear(projectID)
+	}
+
+	return err
+}
+
+// resumeFailed reports whether err ended the process for good. A process that
+// is still running, or a run that was cancelled, can be resumed later.
+func resumeFailed(ctx context.Context, err error) bool {
+	return !errors.Is(err, errExportPending) && ctx.Err() == nil
+}
+
+// asyncWatcher wraps the transport of the lokex client. lokex runs the async
+// export; the watcher reads the answers it gets (the kickoff, every process
+// status and the bundle archive) and reports them to the tracker of the
+// current session. It never changes a request or an answer. Outside a session
+// requests pass through untouched.
+type asyncWatcher struct {
+	next http.RoundTripper
+
+	mu      sync.Mutex
+	session *asyncSession
+}
+
+// asyncSession is one export watched by asyncWatcher.
+type asyncSession struct {
+	tracker     *asyncTracker
+	started     func(processID string) // called when a new export was queued
+	downloadURL string                 // set once the process finished
+}
+
+// watchAsyncExports installs an asyncWatcher on the HTTP client of c.
+func watchAsyncExports(c *client.Client) *asyncWatcher {
+	w := &asyncWatcher{next: c.HTTPClient.Transport}
+	c.HTTPClient.Transport = w
+	return w
+}
+
+// watch runs export with s as the current session.
+func (w *asyncWatcher) watch(s *asyncSession, export func() (string, error)) (string, error) {
+	w.mu.Lock()
+	w.session = s
+	w.mu.Unlock()
+
+	defer func() {
+		w.mu.Lock()
+		w.session = nil
+		w.mu.Unlock()
+	}()
+
+	return export()
+}
+
+func (w *asyncWatcher) RoundTrip(req *http.Request) (*http.Response, error) {
+	w.mu.Lock()
+	s := w.session
+	w.mu.Unlock()
+
+	if s == nil {
+		return w.transport().RoundTrip(req)
+	}
+
+	switch {
+	case req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/files/async-download"):
+		return w.observe(req, func(body []byte) {
+			var kickoff asyncKickoffResponse
+			if json.Unmarshal(body, &kickoff) == nil && kickoff.ProcessID != "" {
+				w.mu.Lock()
+				defer w.mu.Unlock()
+				s.tracker.begin(asyncStarted, kickoff.ProcessID)
+				s.started(kickoff.ProcessID)
+			}
+		}, nil)
+	case req.Method == http.MethodGet && strings.Contains(req.URL.Path, "/processes/"):
+		return w.observeProcess(req, s)
+	}
+
+	resp, err := w.transport().RoundTrip(req)
+	if err == nil && resp.StatusCode/100 == 2 && w.isBundle(req, s) {
+		resp.Body = &countingBody{ReadCloser: resp.Body, done: func(n int64) {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			s.tracker.downloaded(n)
+		}}
+	}
+	return resp, err
+}
+
+func (w *asyncWatcher) transport() http.RoundTripper {
+	if w.next != nil {
+		return w.next
+	}
+	return http.DefaultTransport
+}
+
+// observeProcess reports a process status answer. A process the API refuses
+// to return (not found, forbidden) counts as failed: lokex gives up on it.
+// Rate limits and server errors are retried by lokex and not reported.
+func (w *asyncWatcher) observeProcess(req *http.Request, s *asyncSession) (*http.Response, error) {
+	return w.observe(req, func(body []byte) {
+		var resp processResponse
+		if json.Unmarshal(body, &resp) != nil {
+			return
+		}
+
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		s.tracker.poll(strings.ToLower(strings.TrimSpace(resp.Process.Status)))
+		if u := strings.TrimSpace(resp.Process.Details.DownloadURL); u != "" {
+			s.downloadURL = u
+		}
+	}, func(status int) {
+		if status == http.StatusTooManyRequests || status >= 500 {
+			return
+		}
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		s.tracker.poll(processFailed)
+	})
+}
+
+// observe sends req and passes the body of a successful answer to ok, or the
+// status of an error answer to failed (optional). The body is restored for lokex.
+func (w *asyncWatcher) observe(req *http.Request, ok func([]byte), failed func(int)) (*http.Response, error) {
+	resp, err := w.transport().RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.StatusCode/100 != 2 {
+		if failed != nil {
+			failed(resp.StatusCode)
+		}
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return resp, err
+	}
+
+	ok(body)
+	return resp, nil
+}
+
+// isBundle reports whether req downloads the bundle of the finished process.
+func (w *asyncWatcher) isBundle(req *http.Request, s *asyncSession) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if s.downloadURL == "" {
+		return false
+	}
+	u, err := url.Parse(s.downloadURL)
+	return err == nil && u.String() == req.URL.String()
+}
+
+// countingBody counts the bytes read from a response body and reports the
+// total once, when the body is closed.
+type countingBody struct {
+	io.ReadCloser
+	n    int64
+	done func(int64)
+	once sync.Once
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	b.once.Do(func() { b.done(b.n) })
+	return b.ReadCloser.Close()
+}
+
+// logAsyncProgress logs async export events as "async_export" records. Polling
+// details go into a collapsible log group; the final record is logged after
+// the group so the outcome stays visible.
+func logAsyncProgress(logger *slog.Logger) func(asyncEvent) {
+	grouped := false
+
+	return func(ev asyncEvent) {
+		elapsed := ev.Elapsed.Round(100 * time.Millisecond)
+
+		switch ev.Kind {
+		case asyncStarted, asyncResumed:
+			if grouped {
+				// A resumed process could not be used; groups do not nest.
+				actionlog.EndGroup(logger)
+			}
+			actionlog.StartGroup(logger, "Async export "+ev.ProcessID)
+			grouped = true
+			logger.Info("async_export", "event", ev.Kind, "process_id", ev.ProcessID)
+		case asyncPolled:
+			logger.Info("async_export", "event", "poll", "process_id", ev.ProcessID,
+				"status", ev.Status, "poll", ev.Poll, "elapsed", elapsed)
+		case asyncStatus:
+			if ev.PrevStatus == "" {
+				logger.Info("async_export", "event", "status", "process_id", ev.ProcessID,
+					"to", ev.Status, "poll", ev.Poll, "elapsed", elapsed)
+				return
+			}
+			logger.Info("async_export", "event", "status", "process_id", ev.ProcessID,
+				"from", ev.PrevStatus, "to", ev.Status, "poll", ev.Poll, "elapsed", elapsed)
+		case asyncDownloaded:
+			logger.Info("async_export", "event", "downloaded", "process_id", ev.ProcessID,
+				"bytes", ev.Bytes, "elapsed", elapsed)
+		case asyncFinished:
+			if ev.ProcessID == "" {
+				// The kickoff failed; the error is reported by the caller.
+				return
+			}
+			if grouped {
+				actionlog.EndGroup(logger)
+				grouped = false
+			}
+			if ev.Err != nil {
+				// A process that is still running can be resumed by a later run.
+				log := logger.Error
+				if errors.Is(ev.Err, errExportPending) {
+					log = logger.Warn
+				}
+				log("async_export", "event", "failed", "process_id", ev.ProcessID,
+					"status", ev.Status, "polls", ev.Poll, "elapsed", elapsed, "error", ev.Err)
+				return
+			}
+			logger.Info("async_export", "event", "finished", "process_id", ev.ProcessID,
+				"polls", ev.Poll, "elapsed", elapsed)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package lokalisedownload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+)
+
+// asyncModeAuto is the ASYNC_MODE value that lets the action choose the mode.
+const asyncModeAuto = "auto"
+
+// defaultAsyncAutoThreshold is the project size (keys × languages) from which
+// Lokalise recommends async exports; sync exports of bigger projects tend to fail.
+const defaultAsyncAutoThreshold = 10000
+
+// ProjectSizer is an optional extension used when ASYNC_MODE=auto.
+// It reports the project size without running an export.
+type ProjectSizer interface {
+	ProjectSize(ctx context.Context) (projectSize, error)
+}
+
+// projectSize is taken from the project statistics.
+type projectSize struct {
+	Keys      int
+	Languages int
+}
+
+// pairs returns the number of key-language pairs, the unit Lokalise limits sync exports by.
+func (s projectSize) pairs() int {
+	return s.Keys * s.Languages
+}
+
+type projectResponse struct {
+	Statistics struct {
+		KeysTotal int `json:"keys_total"`
+		Languages []struct {
+			LanguageISO string `json:"language_iso"`
+		} `json:"languages"`
+	} `json:"statistics"`
+}
+
+// ProjectSize reads keys_total and the language list from the project statistics.
+func (d *lokaliseDownloader) ProjectSize(ctx context.Context) (projectSize, error) {
+	path := fmt.Sprintf("projects/%s", url.PathEscape(d.client.ProjectID))
+
+	var resp projectResponse
+	if err := getJSON(ctx, d.client, path, nil, &resp); err != nil {
+		return projectSize{}, fmt.Errorf("cannot read project statistics: %w", err)
+	}
+
+	return projectSize{Keys: resp.Statistics.KeysTotal, Languages: len(resp.Statistics.Languages)}, nil
+}
+
+// resolveAsyncMode picks the export mode up front when ASYNC_MODE=auto: projects
+// at or above the threshold go async right away. Smaller projects, and projects
+// whose size is unknown, start with a sync export that falls back to async.
+func resolveAsyncMode(ctx context.Context, cfg DownloadConfig, dl Downloader) DownloadConfig {
+	if !cfg.AsyncAuto || cfg.AsyncMode {
+		return cfg
+	}
+
+	ps, ok := dl.(ProjectSizer)
+	if !ok {
+		return cfg
+	}
+
+	size, err := ps.ProjectSize(ctx)
+	if err != nil {
+		slog.Warn("cannot determine project size, starting with a sync download", "error", err)
+		return cfg
+	}
+
+	threshold := asyncAutoThresholdOf(cfg)
+	if size.pairs() >= threshold {
+		slog.Info("Project is large, using async mode",
+			"keys", size.Keys, "languages", size.Languages, "pairs", size.pairs(), "threshold", threshold)
+		cfg.AsyncMode = true
+		return cfg
+	}
+
+	slog.Info("Project is small, using sync mode", "pairs", size.pairs(), "threshold", threshold)
+	return cfg
+}
+
+// asyncAutoThresholdOf returns ASYNC_AUTO_THRESHOLD, 10000 when not set.
+func asyncAutoThresholdOf(cfg DownloadConfig) int {
+	if cfg.AsyncAutoThreshold < 1 {
+		return defaultAsyncAutoThreshold
+	}
+	return cfg.AsyncAutoThreshold
+}
+
+// shouldRetryAsync reports whether a failed sync export should be repeated in
+// async mode: only in auto mode, only while the run deadline has not passed,
+// and only for errors that point at the size of the export.
+func shouldRetryAsync(ctx context.Context, cfg DownloadConfig, err error) bool {
+	if !cfg.AsyncAuto || cfg.AsyncMode || ctx.Err() != nil {
+		return false
+	}
+	return isExportTooLargeError(err)
+}
+
+// isExportTooLargeError recognizes sync export failures caused by the export size:
+// the sync request timing out, and the API answering 408 Request Timeout,
+// 413 Payload Too Large or 504 Gateway Timeout. Other errors, whatever their
+// message says, are not retried.
+func isExportTooLargeError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	type timeoutError interface {
+		error
+		Timeout() bool
+	}
+	if te, ok := errors.AsType[timeoutError](err); ok && te.Timeout() {
+		return true
+	}
+
+	if status, ok := apiStatusOf(err); ok {
+		switch status {
+		case http.StatusRequestTimeout, http.StatusRequestEntityTooLarge, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,135 @@
+package lokalisedownload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bodrovis/lokex/v2/client/download"
+)
+
+// autoDownloader fails sync downloads with syncErr and reports a fixed project size.
+type autoDownloader struct {
+	syncErr    error
+	size       projectSize
+	sizeErr    error
+	syncCalls  int
+	asyncCalls int
+}
+
+func (a *autoDownloader) Download(context.Context, string, download.DownloadParams) (string, error) {
+	a.syncCalls++
+	return "https://example.com/sync.zip", a.syncErr
+}
+
+func (a *autoDownloader) DownloadAsync(context.Context, string, download.DownloadParams) (string, error) {
+	a.asyncCalls++
+	return "https://example.com/async.zip", nil
+}
+
+func (a *autoDownloader) ProjectSize(context.Context) (projectSize, error) {
+	return a.size, a.sizeErr
+}
+
+func TestIsExportTooLargeError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{fmt.Errorf("download: %w", context.DeadlineExceeded), true},
+		{netTimeout{}, true},
+		{&APIError{Status: http.StatusRequestEntityTooLarge}, true},
+		{fmt.Errorf("download: %w", &APIError{Status: http.StatusGatewayTimeout}), true},
+		{&APIError{Status: http.StatusRequestTimeout}, true},
+		{&APIError{Status: http.StatusUnauthorized, Message: "Invalid token"}, false},
+		{&APIError{Status: http.StatusBadRequest, Message: "Use async export for this request"}, false},
+		{&APIError{Status: http.StatusBadGateway, Message: "upstream timed out"}, false},
+		{errors.New("Project too big for sync export, please use async export"), false},
+		{errors.New("Invalid `format` parameter"), false},
+		{nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := isExportTooLargeError(tt.err); got != tt.want {
+			t.Errorf("isExportTooLargeError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestResolveAsyncMode(t *testing.T) {
+	t.Parallel()
+
+	cfg := DownloadConfig{AsyncAuto: true, AsyncAutoThreshold: 1000}
+
+	big := &autoDownloader{size: projectSize{Keys: 250, Languages: 4}}
+	if got := resolveAsyncMode(context.Background(), cfg, big); !got.AsyncMode {
+		t.Fatal("projects at the threshold should go async")
+	}
+
+	small := &autoDownloader{size: projectSize{Keys: 100, Languages: 4}}
+	if got := resolveAsyncMode(context.Background(), cfg, small); got.AsyncMode {
+		t.Fatal("small projects should start with sync")
+	}
+
+	unknown := &autoDownloader{sizeErr: errors.New("boom")}
+	if got := resolveAsyncMode(context.Background(), cfg, unknown); got.AsyncMode {
+		t.Fatal("unknown size should start with sync")
+	}
+
+	cfg.AsyncAuto = false
+	if got := resolveAsyncMode(context.Background(), cfg, big); got.AsyncMode {
+		t.Fatal("without auto mode the size must not matter")
+	}
+}
+
+func TestFetchBundle_AutoFallsBackToAsync(t *testing.T) {
+	t.Parallel()
+
+	tooBig := &APIError{Status: http.StatusRequestEntityTooLarge, Message: "Project too big for sync export"}
+	cfg := DownloadConfig{AsyncAuto: true}
+
+	dl := &autoDownloader{syncErr: tooBig}
+	bundleURL, err := fetchBundle(context.Background(), cfg, dl, download.DownloadParams{})
+	if err != nil || bundleURL != "https://example.com/async.zip" || dl.syncCalls != 1 || dl.asyncCalls != 1 {
+		t.Fatalf("expected async retry, got %q %v (sync=%d async=%d)", bundleURL, err, dl.syncCalls, dl.asyncCalls)
+	}
+
+	other := &autoDownloader{syncErr: errors.New("Invalid token")}
+	if _, err := fetchBundle(context.Background(), cfg, other, download.DownloadParams{}); err == nil || other.asyncCalls != 0 {
+		t.Fatalf("unrelated errors must not retry async, got %v (async=%d)", err, other.asyncCalls)
+	}
+
+	manual := &autoDownloader{syncErr: tooBig}
+	if _, err := fetchBundle(context.Background(), DownloadConfig{}, manual, download.DownloadParams{}); err == nil || manual.asyncCalls != 0 {
+		t.Fatalf("without auto mode there is no fallback, got %v (async=%d)", err, manual.asyncCalls)
+	}
+
+	expired, cancel := context.WithCancel(context.Background())
+	cancel()
+	late := &autoDownloader{syncErr: tooBig}
+	if _, err := fetchBundle(expired, cfg, late, download.DownloadParams{}); err == nil || late.asyncCalls != 0 {
+		t.Fatalf("a finished run must not retry, got %v (async=%d)", err, late.asyncCalls)
+	}
+}
+
+func TestProjectSize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/projects/proj:main" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"project_id":"proj","statistics":{"keys_total":1200,"languages":[{"language_iso":"en"},{"language_iso":"fr"}]}}`))
+	}))
+	defer srv.Close()
+
+	d := &lokaliseDownloader{client: newTestClient(t, srv.URL, "proj:main")}
+
+	size, err := d.ProjectSize(context.Background())
+	if err != nil || size.Keys != 1200 || size.Languages != 2 || size.pairs() != 2400 {
+		t.Fatalf("unexpected size: %+v %v", size, err)
+	}
+}
@@ -0,0 +1,171 @@
+package lokalisedownload
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/bodrovis/lokex/v2/client/download"
+)
+
+// asyncProcessOutput is the step output that carries the process ID of the
+// async export, so a later run can pass it back as ASYNC_PROCESS_ID.
+const asyncProcessOutput = "async_process_id"
+
+// errExportPending means the export process is still running on the Lokalise
+// side: polling gave up, but the process can be resumed later.
+var errExportPending = errors.New("export is still running")
+
+// asyncState remembers in-flight async exports so a retried job polls the
+// existing process instead of queuing a new export.
+type asyncState struct {
+	path      string                    // ASYNC_STATE_FILE, empty when not persisted
+	processID string                    // ASYNC_PROCESS_ID, resumes this process unconditionally
+	write     func(string, string) bool // GitHub output writer
+}
+
+// asyncStateFile is the content of ASYNC_STATE_FILE, keyed by project ID
+// (including the ":branch" suffix) so several projects can share one file.
+type asyncStateFile struct {
+	Exports map[string]asyncStateEntry `json:"exports"`
+}
+
+type asyncStateEntry struct {
+	ProcessID  string `json:"process_id"`
+	ParamsHash string `json:"params_hash"`
+	StartedAt  string `json:"started_at"`
+}
+
+// newAsyncState returns nil when neither resuming nor persisting is configured.
+func newAsyncState(cfg DownloadConfig, write func(string, string) bool) *asyncState {
+	if !cfg.AsyncMode && !cfg.AsyncAuto {
+		return nil
+	}
+	return &asyncState{path: cfg.AsyncStateFile, processID: cfg.AsyncProcessID, write: write}
+}
+
+// resumable returns the process to resume for projectID and params, if any.
+// explicit is true when the ID came from ASYNC_PROCESS_ID rather than the state file.
+func (s *asyncState) resumable(projectID string, params download.DownloadParams) (processID string, explicit bool) {
+	if s == nil {
+		return "", false
+	}
+	if s.processID != "" {
+		return s.processID, true
+	}
+	if s.path == "" {
+		return "", false
+	}
+
+	state, err := readAsyncStateFile(s.path)
+	if err != nil {
+		slog.Warn("ignoring async state file", "error", err)
+		return "", false
+	}
+
+	entry, ok := state.Exports[projectID]
+	if !ok {
+		return "", false
+	}
+
+	hash, err := hashParams(params)
+	if err != nil || hash != entry.ParamsHash {
+		slog.Info("Async export was started with different params, starting a new one", "process_id", entry.ProcessID)
+		return "", false
+	}
+
+	return entry.ProcessID, false
+}
+
+// save records a freshly started process in the state file and the step output.
+// Failures only warn: persisting is a convenience, the export itself is running.
+func (s *asyncState) save(projectID, processID string, params download.DownloadParams) {
+	if s == nil {
+		return
+	}
+	if s.write != nil && !s.write(asyncProcessOutput, processID) {
+		slog.Warn("cannot write output", "output", asyncProcessOutput)
+	}
+	if s.path == "" {
+		return
+	}
+
+	hash, err := hashParams(params)
+	if err == nil {
+		err = s.update(func(state *asyncStateFile) {
+			state.Exports[projectID] = asyncStateEntry{
+				ProcessID:  processID,
+				ParamsHash: hash,
+				StartedAt:  now().UTC().Format(time.RFC3339),
+			}
+		})
+	}
+	if err != nil {
+		slog.Warn("cannot save async export state", "error", err)
+	}
+}
+
+// clear forgets the process of projectID once its bundle has been downloaded,
+// or when it can no longer be resumed.
+func (s *asyncState) clear(projectID string) {
+	if s == nil || s.path == "" {
+		return
+	}
+
+	err := s.update(func(state *asyncStateFile) {
+		delete(state.Exports, projectID)
+	})
+	if err != nil {
+		slog.Warn("cannot update async export state", "error", err)
+	}
+}
+
+// update applies fn to the state file; an empty state removes the file.
+func (s *asyncState) update(fn func(*asyncStateFile)) error {
+	state, err := readAsyncStateFile(s.path)
+	if err != nil {
+		// A corrupt file is replaced rather than blocking every later run.
+		state = asyncStateFile{Exports: map[string]asyncStateEntry{}}
+	}
+	fn(&state)
+
+	if len(state.Exports) == 0 {
+		if err := os.Remove(s.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	raw, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(s.path, bytes.NewReader(append(raw, '\n')))
+}
+
+// readAsyncStateFile returns an empty state when the file does not exist.
+func readAsyncStateFile(path string) (asyncStateFile, error) {
+	state := asyncStateFile{Exports: map[string]asyncStateEntry{}}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return asyncStateFile{Exports: map[string]asyncStateEntry{}}, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+	if state.Exports == nil {
+		state.Exports = map[string]asyncStateEntry{}
+	}
+
+	return state, nil
+}
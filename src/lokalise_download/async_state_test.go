@@ -0,0 +1,209 @@
+package lokalisedownload
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bodrovis/lokex/v2/client/download"
+)
+
+func TestAsyncState_SaveResumeClear(t *testing.T) {
+	freezeNow(t, time.Date(2025, 10, 9, 12, 0, 0, 0, time.UTC))
+
+	var outputs []string
+	s := &asyncState{
+		path:  filepath.Join(t.TempDir(), "state", "async.json"),
+		write: func(name, value string) bool { outputs = append(outputs, name+"="+value); return true },
+	}
+	params := download.DownloadParams{"format": "json"}
+
+	if id, _ := s.resumable("proj", params); id != "" {
+		t.Fatalf("nothing to resume yet, got %q", id)
+	}
+
+	s.save("proj", "pid-1", params)
+	s.save("other", "pid-2", params)
+
+	if len(outputs) != 2 || outputs[0] != "async_process_id=pid-1" {
+		t.Fatalf("unexpected outputs: %v", outputs)
+	}
+
+	id, explicit := s.resumable("proj", params)
+	if id != "pid-1" || explicit {
+		t.Fatalf("expected pid-1 from the state file, got %q explicit=%v", id, explicit)
+	}
+	if id, _ := s.resumable("proj", download.DownloadParams{"format": "yaml"}); id != "" {
+		t.Fatalf("different params must not resume, got %q", id)
+	}
+
+	state, err := readAsyncStateFile(s.path)
+	if err != nil || state.Exports["proj"].StartedAt != "2025-10-09T12:00:00Z" {
+		t.Fatalf("unexpected state: %+v %v", state, err)
+	}
+
+	s.clear("proj")
+	if id, _ := s.resumable("proj", params); id != "" {
+		t.Fatalf("cleared process must not resume, got %q", id)
+	}
+	if id, _ := s.resumable("other", params); id != "pid-2" {
+		t.Fatalf("other projects must be kept, got %q", id)
+	}
+
+	s.clear("other")
+	if _, err := os.Stat(s.path); !os.IsNotExist(err) {
+		t.Fatalf("empty state file should be removed, got %v", err)
+	}
+}
+
+func TestAsyncState_ExplicitProcessIDAndCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "async.json")
+	writeTestFile(t, path, "{not json")
+
+	s := &asyncState{path: path}
+	if id, _ := s.resumable("proj", download.DownloadParams{}); id != "" {
+		t.Fatalf("corrupt state must be ignored, got %q", id)
+	}
+
+	s.save("proj", "pid-1", download.DownloadParams{})
+	if id, _ := s.resumable("proj", download.DownloadParams{}); id != "pid-1" {
+		t.Fatalf("corrupt state should be replaced, got %q", id)
+	}
+
+	s.processID = "pid-9"
+	if id, explicit := s.resumable("proj", download.DownloadParams{}); id != "pid-9" || !explicit {
+		t.Fatalf("ASYNC_PROCESS_ID must win, got %q explicit=%v", id, explicit)
+	}
+
+	if newAsyncState(DownloadConfig{AsyncStateFile: path}, nil) != nil {
+		t.Fatal("state must be disabled without async mode")
+	}
+}
+
+func TestDownloadAsync_ResumesFromStateFile(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "bundle.zip")
+	writeTestZip(t, archive, zipEntry{name: "fr.json", content: "{}"})
+
+	srv, calls := asyncTestServer(t, []string{"running", "finished"}, archive)
+	d, events := newAsyncTestDownloader(t, srv, time.Minute)
+
+	params := download.DownloadParams{"format": "json"}
+	d.state = &asyncState{path: filepath.Join(t.TempDir(), "async.json")}
+	d.state.save("proj", "pid-1", params)
+
+	if _, err := d.DownloadAsync(context.Background(), t.TempDir(), params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.kickoffs.Load() != 0 || calls.polls.Load() != 2 {
+		t.Fatalf("expected resume without kickoff, got kickoffs=%d polls=%d", calls.kickoffs.Load(), calls.polls.Load())
+	}
+	want := []string{asyncResumed, asyncStatus, asyncStatus, asyncDownloaded, asyncFinished}
+	if got := eventKinds(*events); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("events mismatch: %v", got)
+	}
+	if (*events)[1].PrevStatus != "" || (*events)[3].Bytes == 0 {
+		t.Fatalf("unexpected events: %+v", *events)
+	}
+	if _, err := os.Stat(d.state.path); !os.IsNotExist(err) {
+		t.Fatal("state should be cleared after a successful download")
+	}
+}
+
+func TestFetchBundleURL_ResumesWithoutDownloading(t *testing.T) {
+	srv, calls := asyncTestServer(t, []string{"finished"}, "")
+	d, events := newAsyncTestDownloader(t, srv, time.Minute)
+
+	params := download.DownloadParams{"format": "json"}
+	d.state = &asyncState{processID: "pid-1"}
+
+	bundleURL, err := d.FetchBundleURL(context.Background(), params, true)
+	if err != nil || bundleURL != testBundleURL {
+		t.Fatalf("unexpected result: %q %v", bundleURL, err)
+	}
+	if calls.kickoffs.Load() != 0 || calls.polls.Load() != 1 {
+		t.Fatalf("expected a single poll, got kickoffs=%d polls=%d", calls.kickoffs.Load(), calls.polls.Load())
+	}
+
+	// The bundle is left to the caller (the cache), so nothing is downloaded here.
+	want := []string{asyncResumed, asyncStatus, asyncFinished}
+	if got := eventKinds(*events); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("events mismatch: %v", got)
+	}
+}
+
+func TestDownloadAsync_ResumedProcessStillRunning(t *testing.T) {
+	srv, calls := asyncTestServer(t, []string{"running"}, "")
+	d, _ := newAsyncTestDownloader(t, srv, 20*time.Millisecond)
+
+	params := download.DownloadParams{"format": "json"}
+	d.state = &asyncState{path: filepath.Join(t.TempDir(), "async.json")}
+	d.state.save("proj", "pid-1", params)
+
+	_, err := d.DownloadAsync(context.Background(), t.TempDir(), params)
+	if !errors.Is(err, errExportPending) || !strings.Contains(err.Error(), `status="running"`) {
+		t.Fatalf("expected a pending export error, got %v", err)
+	}
+	if calls.kickoffs.Load() != 0 || calls.polls.Load() < 2 {
+		t.Fatalf("a running process must be polled, not replaced: kickoffs=%d polls=%d", calls.kickoffs.Load(), calls.polls.Load())
+	}
+	if id, _ := d.state.resumable("proj", params); id != "pid-1" {
+		t.Fatalf("a running export must stay resumable, got %q", id)
+	}
+}
+
+func TestDownloadAsync_UnknownProcessStartsNewExport(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "bundle.zip")
+	writeTestZip(t, archive, zipEntry{name: "fr.json", content: "{}"})
+
+	srv, calls := asyncTestServer(t, []string{"finished"}, archive)
+	d, events := newAsyncTestDownloader(t, srv, time.Minute)
+
+	params := download.DownloadParams{"format": "json"}
+	d.state = &asyncState{path: filepath.Join(t.TempDir(), "async.json")}
+	d.state.save("proj", "gone", params)
+
+	if _, err := d.DownloadAsync(context.Background(), t.TempDir(), params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.kickoffs.Load() != 1 {
+		t.Fatalf("expected a new export, got %d kickoffs", calls.kickoffs.Load())
+	}
+
+	want := []string{asyncResumed, asyncStatus, asyncStarted, asyncStatus, asyncDownloaded, asyncFinished}
+	if got := eventKinds(*events); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("events mismatch: %v", got)
+	}
+	if gone := (*events)[1]; gone.ProcessID != "gone" || gone.Status != processFailed {
+		t.Fatalf("the unknown process should be reported as failed, got %+v", gone)
+	}
+}
+
+func TestDownloadAsync_ExplicitProcessIDDoesNotFallBack(t *testing.T) {
+	srv, calls := asyncTestServer(t, []string{"finished"}, "")
+	d, _ := newAsyncTestDownloader(t, srv, time.Minute)
+	d.state = &asyncState{processID: "gone"}
+
+	_, err := d.DownloadAsync(context.Background(), t.TempDir(), download.DownloadParams{"format": "json"})
+	if err == nil || !strings.Contains(err.Error(), "gone") || calls.kickoffs.Load() != 0 {
+		t.Fatalf("expected an error for the unknown process, got %v (kickoffs=%d)", err, calls.kickoffs.Load())
+	}
+}
+
+func TestDownloadAsync_PendingExportKeepsState(t *testing.T) {
+	srv, _ := asyncTestServer(t, []string{"queued"}, "")
+	d, _ := newAsyncTestDownloader(t, srv, 10*time.Millisecond)
+	d.state = &asyncState{path: filepath.Join(t.TempDir(), "async.json")}
+
+	params := download.DownloadParams{"format": "json"}
+	if _, err := d.DownloadAsync(context.Background(), t.TempDir(), params); err == nil {
+		t.Fatal("expected timeout error")
+	}
+
+	if id, _ := d.state.resumable("proj", params); id != "pid-1" {
+		t.Fatalf("a running export must stay resumable, got %q", id)
+	}
+}
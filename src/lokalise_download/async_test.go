@@ -0,0 +1,222 @@
+package lokalisedownload
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bodrovis/lokex/v2/client/download"
+
+	"github.com/lokalise/lokalise-pull-action/src/actionlog"
+)
+
+// asyncCalls counts the requests served by asyncTestServer.
+type asyncCalls struct {
+	kickoffs atomic.Int32
+	polls    atomic.Int32
+}
+
+// testBundleURL is the download URL of the test exports. lokex only downloads
+// bundles from public https URLs; toServer routes it to the test server.
+const testBundleURL = "https://bundles.example.com/bundle.zip"
+
+// toServer sends every request to srv, whatever its host.
+type toServer struct{ srv *httptest.Server }
+
+func (t toServer) RoundTrip(req *http.Request) (*http.Response, error) {
+	target, _ := url.Parse(t.srv.URL)
+	r := req.Clone(req.Context())
+	r.URL.Scheme, r.URL.Host, r.Host = target.Scheme, target.Host, ""
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// asyncTestServer serves the kickoff, a scripted sequence of process statuses and the bundle.
+// Only process "pid-1" exists; other process IDs get a 404.
+func asyncTestServer(t *testing.T, statuses []string, archive string) (*httptest.Server, *asyncCalls) {
+	t.Helper()
+
+	calls := &asyncCalls{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/projects/proj/files/async-download":
+			var params map[string]any
+			if err := json.NewDecoder(r.Body).Decode(&params); err != nil || params["format"] != "json" {
+				t.Errorf("unexpected kickoff body: %v %v", params, err)
+			}
+			calls.kickoffs.Add(1)
+			_, _ = w.Write([]byte(`{"process_id":"pid-1"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/projects/proj/processes/pid-1":
+			n := int(calls.polls.Add(1))
+			status := statuses[min(n, len(statuses))-1]
+			resp := map[string]any{"process": map[string]any{
+				"process_id": "pid-1",
+				"status":     status,
+				"details":    map[string]any{"download_url": testBundleURL},
+			}}
+			_ = json.NewEncoder(w).Encode(resp)
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/projects/proj/processes/"):
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"message":"Not found","code":404}}`))
+		case r.URL.Path == "/bundle.zip":
+			http.ServeFile(w, r, archive)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, calls
+}
+
+func newAsyncTestDownloader(t *testing.T, srv *httptest.Server, maxWait time.Duration) (*lokaliseDownloader, *[]asyncEvent) {
+	t.Helper()
+
+	c := newTestClient(t, srv.URL, "proj")
+	c.PollInitialWait = time.Millisecond
+	c.PollMaxWait = maxWait
+	c.HTTPClient.Transport = toServer{srv: srv}
+
+	var events []asyncEvent
+	d := &lokaliseDownloader{
+		Downloader: download.NewDownloader(c),
+		client:     c,
+		watcher:    watchAsyncExports(c),
+		progress:   func(ev asyncEvent) { events = append(events, ev) },
+	}
+	return d, &events
+}
+
+func eventKinds(events []asyncEvent) []string {
+	kinds := make([]string, 0, len(events))
+	for _, ev := range events {
+		kinds = append(kinds, ev.Kind)
+	}
+	return kinds
+}
+
+func TestDownloadAsync_ReportsProgress(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "bundle.zip")
+	writeTestZip(t, archive, zipEntry{name: "locales/fr.json", content: `{"a":"b"}`})
+
+	srv, calls := asyncTestServer(t, []string{"queued", "running", "running", "finished"}, archive)
+	d, events := newAsyncTestDownloader(t, srv, time.Minute)
+
+	dest := t.TempDir()
+	bundleURL, err := d.DownloadAsync(context.Background(), dest, download.DownloadParams{"format": "json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bundleURL != testBundleURL || calls.polls.Load() != 4 {
+		t.Fatalf("unexpected result: %q after %d polls", bundleURL, calls.polls.Load())
+	}
+	if got, err := os.ReadFile(filepath.Join(dest, "locales", "fr.json")); err != nil || string(got) != `{"a":"b"}` {
+		t.Fatalf("bundle not extracted: %q %v", got, err)
+	}
+
+	want := []string{asyncStarted, asyncPolled, asyncStatus, asyncPolled, asyncStatus, asyncDownloaded, asyncFinished}
+	if got := eventKinds(*events); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("events mismatch:\n got: %v\nwant: %v", got, want)
+	}
+
+	transition := (*events)[2]
+	if transition.PrevStatus != "queued" || transition.Status != "running" || transition.Poll != 2 {
+		t.Fatalf("unexpected transition: %+v", transition)
+	}
+
+	info, _ := os.Stat(archive)
+	if dl := (*events)[5]; dl.Bytes != info.Size() || dl.ProcessID != "pid-1" {
+		t.Fatalf("unexpected download event: %+v", dl)
+	}
+	if last := (*events)[6]; last.Err != nil || last.Poll != 4 {
+		t.Fatalf("unexpected final event: %+v", last)
+	}
+}
+
+func TestDownloadAsync_FailedProcess(t *testing.T) {
+	srv, _ := asyncTestServer(t, []string{"queued", "failed"}, "")
+	d, events := newAsyncTestDownloader(t, srv, time.Minute)
+
+	_, err := d.DownloadAsync(context.Background(), t.TempDir(), download.DownloadParams{"format": "json"})
+	if err == nil || !strings.Contains(err.Error(), "process pid-1 failed") {
+		t.Fatalf("expected failed process error, got %v", err)
+	}
+
+	last := (*events)[len(*events)-1]
+	if last.Kind != asyncFinished || !errors.Is(last.Err, err) {
+		t.Fatalf("final event should carry the error, got %+v", last)
+	}
+}
+
+func TestDownloadAsync_PollBudgetExhausted(t *testing.T) {
+	srv, calls := asyncTestServer(t, []string{"queued"}, "")
+	d, _ := newAsyncTestDownloader(t, srv, 20*time.Millisecond)
+
+	_, err := d.DownloadAsync(context.Background(), t.TempDir(), download.DownloadParams{"format": "json"})
+	if err == nil || !strings.Contains(err.Error(), `did not finish (status="queued")`) || !errors.Is(err, errExportPending) {
+		t.Fatalf("expected a pending export error, got %v", err)
+	}
+	if calls.polls.Load() < 2 {
+		t.Fatalf("expected several polls, got %d", calls.polls.Load())
+	}
+}
+
+func TestFetchBundleURL_AsyncReportsProgress(t *testing.T) {
+	srv, _ := asyncTestServer(t, []string{"finished"}, "")
+	d, events := newAsyncTestDownloader(t, srv, time.Minute)
+
+	bundleURL, err := d.FetchBundleURL(context.Background(), download.DownloadParams{"format": "json"}, true)
+	if err != nil || bundleURL != testBundleURL {
+		t.Fatalf("unexpected result: %q %v", bundleURL, err)
+	}
+
+	want := []string{asyncStarted, asyncStatus, asyncFinished}
+	if got := eventKinds(*events); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("events mismatch: %v", got)
+	}
+}
+
+func TestLogAsyncProgress(t *testing.T) {
+	var buf bytes.Buffer
+	log := logAsyncProgress(actionlog.New(&buf, actionlog.Options{Format: actionlog.FormatGitHub}))
+
+	log(asyncEvent{Kind: asyncStarted, ProcessID: "pid-1", Status: "queued"})
+	log(asyncEvent{Kind: asyncStatus, ProcessID: "pid-1", PrevStatus: "queued", Status: "running", Poll: 2, Elapsed: 1500 * time.Millisecond})
+	log(asyncEvent{Kind: asyncDownloaded, ProcessID: "pid-1", Bytes: 2048, Elapsed: 3 * time.Second})
+	log(asyncEvent{Kind: asyncFinished, ProcessID: "pid-1", Status: "finished", Poll: 3, Elapsed: 3 * time.Second})
+
+	want := "::group::Async export pid-1\n" +
+		"async_export event=started process_id=pid-1\n" +
+		"async_export event=status process_id=pid-1 from=queued to=running poll=2 elapsed=1.5s\n" +
+		"async_export event=downloaded process_id=pid-1 bytes=2048 elapsed=3s\n" +
+		"::endgroup::\n" +
+		"async_export event=finished process_id=pid-1 polls=3 elapsed=3s\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	log(asyncEvent{Kind: asyncFinished, ProcessID: "pid-1", Status: "running", Poll: 5, Err: fmt.Errorf("timed out: %w", errExportPending)})
+	log(asyncEvent{Kind: asyncFinished, ProcessID: "pid-2", Status: "failed", Poll: 2, Err: errors.New("process failed")})
+	if out := buf.String(); !strings.HasPrefix(out, "::warning::async_export event=failed process_id=pid-1") ||
+		!strings.Contains(out, "\n::error::async_export event=failed process_id=pid-2") {
+		t.Fatalf("a pending export should warn and a failed one error, got:\n%s", out)
+	}
+
+	buf.Reset()
+	log(asyncEvent{Kind: asyncFinished, Err: errors.New("kickoff failed")})
+	if buf.Len() != 0 {
+		t.Fatalf("a failed kickoff should not be logged, got %q", buf.String())
+	}
+}
@@ -0,0 +1,215 @@
+package lokalisedownload
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// defaultBranchFallback is the Lokalise main branch used when the mapped branch does not exist.
+const defaultBranchFallback = "master"
+
+// branchesPageLimit is the page size used when listing project branches.
+const branchesPageLimit = 500
+
+// Branch mapping rule kinds. Rules are written one per line as "<kind>:<from>=><to>".
+const (
+	branchRuleExact  = "exact"  // exact:main=>master
+	branchRulePrefix = "prefix" // prefix:feature/ (strip) or prefix:feature/=>feat- (replace)
+	branchRuleRegex  = "regex"  // regex:^release/(.+)$=>rel-$1
+)
+
+// branchRule maps a git branch name to a Lokalise branch name.
+type branchRule struct {
+	kind string
+	from string
+	to   string
+	re   *regexp.Regexp
+}
+
+type projectBranch struct {
+	Name string `json:"name"`
+}
+
+type branchesPage struct {
+	Branches []projectBranch `json:"branches"`
+}
+
+// ProjectBranches lists the branch names of the (base) project.
+func (d *lokaliseDownloader) ProjectBranches(ctx context.Context) ([]string, error) {
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(branchesPageLimit))
+
+	path := fmt.Sprintf("projects/%s/branches", url.PathEscape(d.client.ProjectID))
+
+	var names []string
+	for page := 1; ; page++ {
+		query.Set("page", strconv.Itoa(page))
+
+		var resp branchesPage
+		if err := getJSON(ctx, d.client, path, query, &resp); err != nil {
+			return nil, fmt.Errorf("cannot list project branches: %w", err)
+		}
+
+		for _, b := range resp.Branches {
+			names = append(names, b.Name)
+		}
+		if len(resp.Branches) < branchesPageLimit {
+			break
+		}
+	}
+
+	return names, nil
+}
+
+// parseBranchMapping parses PROJECT_BRANCH_MAPPING: one rule per line,
+// empty lines and lines starting with "#" are ignored.
+func parseBranchMapping(raw string) ([]branchRule, error) {
+	var rules []branchRule
+
+	for line := range strings.SplitSeq(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule, err := parseBranchRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PROJECT_BRANCH_MAPPING rule %q: %w", line, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func parseBranchRule(line string) (branchRule, error) {
+	kind, spec, ok := strings.Cut(line, ":")
+	if !ok {
+		return branchRule{}, fmt.Errorf("expected <kind>:<rule>")
+	}
+
+	from, to, hasTo := strings.Cut(spec, "=>")
+	rule := branchRule{
+		kind: strings.ToLower(strings.TrimSpace(kind)),
+		from: strings.TrimSpace(from),
+		to:   strings.TrimSpace(to),
+	}
+	if rule.from == "" {
+		return branchRule{}, fmt.Errorf("empty pattern")
+	}
+
+	switch rule.kind {
+	case branchRuleExact:
+		if !hasTo || rule.to == "" {
+			return branchRule{}, fmt.Errorf("exact rules need a target, e.g. exact:main=>master")
+		}
+	case branchRulePrefix:
+		// Target is optional: without it the prefix is stripped.
+	case branchRuleRegex:
+		if !hasTo {
+			return branchRule{}, fmt.Errorf("regex rules need a replacement, e.g. regex:^release/(.+)$=>rel-$1")
+		}
+		re, err := regexp.Compile(rule.from)
+		if err != nil {
+			return branchRule{}, err
+		}
+		rule.re = re
+	default:
+		return branchRule{}, fmt.Errorf("unknown rule kind %q (expected exact, prefix or regex)", rule.kind)
+	}
+
+	return rule, nil
+}
+
+// apply returns the mapped name and whether the rule matched.
+func (r branchRule) apply(branch string) (string, bool) {
+	switch r.kind {
+	case branchRuleExact:
+		if branch == r.from {
+			return r.to, true
+		}
+	case branchRulePrefix:
+		if rest, ok := strings.CutPrefix(branch, r.from); ok {
+			return r.to + rest, true
+		}
+	case branchRuleRegex:
+		if r.re.MatchString(branch) {
+			return r.re.ReplaceAllString(branch, r.to), true
+		}
+	}
+
+	return "", false
+}
+
+// mapGitBranch applies the first matching rule; unmatched branches keep their name.
+func mapGitBranch(branch string, rules []branchRule) string {
+	for _, r := range rules {
+		if mapped, ok := r.apply(branch); ok && mapped != "" {
+			return mapped
+		}
+	}
+
+	return branch
+}
+
+// branchFallbackOf returns the configured fallback branch, "master" when empty.
+func branchFallbackOf(cfg DownloadConfig) string {
+	if cfg.BranchFallback == "" {
+		return defaultBranchFallback
+	}
+	return cfg.BranchFallback
+}
+
+// withProjectBranch turns cfg.ProjectID into "projectID:branch" when project
+// branches are enabled. The git ref is mapped to a Lokalise branch, and the
+// fallback branch is used when the mapped one does not exist in the project.
+func withProjectBranch(ctx context.Context, cfg DownloadConfig, factory ClientFactory) (DownloadConfig, error) {
+	if !cfg.ProjectBranches || cfg.LocalBundle != "" {
+		return cfg, nil
+	}
+
+	rules, err := parseBranchMapping(cfg.BranchMapping)
+	if err != nil {
+		return cfg, err
+	}
+
+	branch := mapGitBranch(cfg.RefName, rules)
+
+	base, err := factory.NewDownloader(cfg)
+	if err != nil {
+		return cfg, fmt.Errorf("cannot create Lokalise API client: %w", err)
+	}
+	branch = existingBranch(ctx, base, branch, branchFallbackOf(cfg))
+
+	cfg.ProjectID = cfg.ProjectID + ":" + branch
+	slog.Info("Using Lokalise project branch", "project_id", cfg.ProjectID)
+	return cfg, nil
+}
+
+// existingBranch returns branch when the project has it, otherwise fallback.
+// If branches cannot be listed, the mapped branch is used as is and the export reports any problem.
+func existingBranch(ctx context.Context, dl Downloader, branch, fallback string) string {
+	bl, ok := dl.(BranchLister)
+	if !ok {
+		return branch
+	}
+
+	names, err := bl.ProjectBranches(ctx)
+	if err != nil {
+		slog.Warn("cannot verify Lokalise branch, using it as is", "branch", branch, "error", err)
+		return branch
+	}
+
+	if slices.Contains(names, branch) {
+		return branch
+	}
+
+	slog.Info("Lokalise branch does not exist, falling back", "branch", branch, "fallback", fallback)
+	return fallback
+}
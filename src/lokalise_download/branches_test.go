@@ -0,0 +1,189 @@
+package lokalisedownload
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fakeBranchDownloader struct {
+	*fakeDownloader
+	branches []string
+	err      error
+}
+
+func (f *fakeBranchDownloader) ProjectBranches(context.Context) ([]string, error) {
+	return f.branches, f.err
+}
+
+func TestParseBranchMapping(t *testing.T) {
+	rules, err := parseBranchMapping(`
+# comments and blank lines are ignored
+
+exact: main => master
+prefix:feature/
+prefix:hotfix/=>fix-
+regex:^release/(\d+)\.(\d+)$=>rel-$1-$2
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 4 {
+		t.Fatalf("expected 4 rules, got %d", len(rules))
+	}
+
+	tests := map[string]string{
+		"main":          "master",
+		"feature/login": "login",
+		"hotfix/crash":  "fix-crash",
+		"release/2.10":  "rel-2-10",
+		"release/next":  "release/next",
+		"develop":       "develop",
+	}
+	for in, want := range tests {
+		if got := mapGitBranch(in, rules); got != want {
+			t.Fatalf("mapGitBranch(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseBranchMapping_Errors(t *testing.T) {
+	for _, raw := range []string{
+		"main=>master",
+		"exact:main",
+		"exact:=>master",
+		"regex:^(x$=>y",
+		"regex:^x$",
+		"glob:*=>x",
+	} {
+		if _, err := parseBranchMapping(raw); err == nil {
+			t.Fatalf("%q: expected error", raw)
+		}
+	}
+}
+
+func TestWithProjectBranch(t *testing.T) {
+	tests := []struct {
+		name string
+		dl   Downloader
+		want string
+	}{
+		{"branch exists", &fakeBranchDownloader{fakeDownloader: &fakeDownloader{}, branches: []string{"master", "login"}}, "proj:login"},
+		{"falls back", &fakeBranchDownloader{fakeDownloader: &fakeDownloader{}, branches: []string{"master"}}, "proj:develop"},
+		{"listing fails", &fakeBranchDownloader{fakeDownloader: &fakeDownloader{}, err: errors.New("boom")}, "proj:login"},
+		{"no lister", &fakeDownloader{}, "proj:login"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DownloadConfig{
+				ProjectID:       "proj",
+				RefName:         "feature/login",
+				ProjectBranches: true,
+				BranchMapping:   "prefix:feature/",
+				BranchFallback:  "develop",
+			}
+
+			got, err := withProjectBranch(context.Background(), cfg, &fakeFactory{downloader: tt.dl})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.ProjectID != tt.want {
+				t.Fatalf("ProjectID = %q, want %q", got.ProjectID, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloadFiles_UsesProjectBranch(t *testing.T) {
+	fd := &fakeBranchDownloader{fakeDownloader: &fakeDownloader{}, branches: []string{"master"}}
+	f := &fakeFactory{downloader: fd}
+
+	cfg := DownloadConfig{
+		ProjectID:       "proj",
+		Token:           "tok",
+		FileFormat:      "json",
+		RefName:         "feature/x",
+		ProjectBranches: true,
+	}
+
+	if err := downloadFiles(context.Background(), cfg, f); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.gotProjectID != "proj:master" {
+		t.Fatalf("expected fallback branch, got %q", f.gotProjectID)
+	}
+	// include_tags still follows the git ref.
+	if tags, _ := fd.gotParams["include_tags"].([]string); len(tags) != 1 || tags[0] != "feature/x" {
+		t.Fatalf("unexpected include_tags: %#v", fd.gotParams["include_tags"])
+	}
+}
+
+func TestLokaliseDownloader_ProjectBranches_Paginates(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/projects/proj/branches" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+
+		count := branchesPageLimit
+		if r.URL.Query().Get("page") == "2" {
+			count = 1
+		}
+
+		resp := branchesPage{}
+		for i := range count {
+			resp.Branches = append(resp.Branches, projectBranch{Name: fmt.Sprintf("b%s-%d", r.URL.Query().Get("page"), i)})
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	d := &lokaliseDownloader{client: newTestClient(t, srv.URL, "proj")}
+
+	names, err := d.ProjectBranches(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(names) != branchesPageLimit+1 || names[len(names)-1] != "b2-0" {
+		t.Fatalf("unexpected branches: %d, last %q", len(names), names[len(names)-1])
+	}
+}
+
+func TestValidateDownloadConfig_ProjectBranches(t *testing.T) {
+	base := DownloadConfig{
+		ProjectID:       "proj",
+		Token:           "tok",
+		FileFormat:      "json",
+		RefName:         "main",
+		ProjectBranches: true,
+	}
+	if err := validateDownloadConfig(base); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := map[string]func(*DownloadConfig){
+		"branch in project ID": func(c *DownloadConfig) { c.ProjectID = "proj:main" },
+		"branch in list":       func(c *DownloadConfig) { c.Projects = "- project_id: a:dev" },
+		"bad mapping":          func(c *DownloadConfig) { c.BranchMapping = "exact:main" },
+		"no ref": func(c *DownloadConfig) {
+			c.RefName = ""
+			c.SkipIncludeTags = true
+		},
+	}
+	for name, mutate := range tests {
+		cfg := base
+		mutate(&cfg)
+		err := validateDownloadConfig(cfg)
+		if err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+		if name == "no ref" && !strings.Contains(err.Error(), "USE_PROJECT_BRANCHES") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package lokalisedownload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bodrovis/lokex/v2/client/download"
+)
+
+// bundleCache keeps downloaded export archives on disk so jobs running with
+// identical params (e.g. a matrix sharing the runner cache) export only once.
+// Each entry is <key>.zip plus a <key>.json sidecar with its metadata.
+type bundleCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// cacheEntry is the sidecar stored next to every cached archive.
+type cacheEntry struct {
+	ProjectID  string `json:"project_id"`
+	ParamsHash string `json:"params_hash"`
+	BundleURL  string `json:"bundle_url,omitempty"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// FetchBundleURL runs the export and returns the bundle URL without downloading it.
+func (d *lokaliseDownloader) FetchBundleURL(ctx context.Context, params download.DownloadParams, async bool) (string, error) {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("cannot encode download params: %w", err)
+	}
+
+	if async {
+		// The bundle of a resumed export is downloaded by the caller, like any other.
+		return d.exportAsync(ctx, params, func() (string, error) {
+			return d.FetchBundleAsync(ctx, bytes.NewReader(body))
+		}, func(string) error { return nil })
+	}
+	return d.FetchBundle(ctx, bytes.NewReader(body))
+}
+
+// DownloadArchive stores the bundle archive at destPath as is.
+func (d *lokaliseDownloader) DownloadArchive(ctx context.Context, bundleURL, destPath string) error {
+	return downloadArchive(ctx, d.client, bundleURL, destPath)
+}
+
+// fetchBundleCached serves the export from the cache when a fresh entry exists,
+// otherwise exports, stores the archive in the cache and extracts it.
+// Cache problems never fail the run: the action falls back to a regular download.
+func fetchBundleCached(ctx context.Context, cfg DownloadConfig, dl Downloader, params download.DownloadParams) (string, error) {
+	bf, ok := dl.(BundleFetcher)
+	if !ok {
+		slog.Warn("downloader doesn't support bundle caching, downloading without cache")
+		return fetchBundle(ctx, cfg, dl, params)
+	}
+
+	paramsHash, err := hashParams(params)
+	if err != nil {
+		return "", err
+	}
+
+	cache := bundleCache{dir: cfg.CacheDir, ttl: cfg.CacheTTL}
+	key := cacheKey(cfg.ProjectID, paramsHash)
+
+	if entry, ok := cache.lookup(key); ok {
+		err := extractArchive(cache.archivePath(key), downloadDestOf(cfg))
+		if err == nil {
+			slog.Info("Using cached bundle", "key", key, "created_at", entry.CreatedAt)
+			return entry.BundleURL, nil
+		}
+		slog.Warn("cannot use cached bundle, downloading a fresh one", "error", err)
+	}
+
+	if err := os.MkdirAll(cache.dir, 0o755); err != nil {
+		slog.Warn("cannot create cache directory, downloading without cache", "error", err)
+		return fetchBundle(ctx, cfg, dl, params)
+	}
+	cache.pruneExpired()
+
+	bundleURL, err := bf.FetchBundleURL(ctx, params, cfg.AsyncMode)
+	if err != nil && shouldRetryAsync(ctx, cfg, err) {
+		slog.Info("Sync export failed, retrying in async mode", "error", err)
+		bundleURL, err = bf.FetchBundleURL(ctx, params, true)
+	}
+	if err != nil {
+		return "", fmt.Errorf("download failed: %w", err)
+	}
+
+	archive := cache.archivePath(key)
+	if err := bf.DownloadArchive(ctx, bundleURL, archive); err != nil {
+		return "", fmt.Errorf("download failed: %w", err)
+	}
+
+	entry := cacheEntry{
+		ProjectID:  cfg.ProjectID,
+		ParamsHash: paramsHash,
+		BundleURL:  redactBundleURL(bundleURL),
+		CreatedAt:  now().UTC().Format(time.RFC3339),
+	}
+	if err := cache.store(key, entry); err != nil {
+		slog.Warn("cannot record cache entry", "error", err)
+	}
+
+	if err := extractArchive(archive, downloadDestOf(cfg)); err != nil {
+		return "", fmt.Errorf("download failed: %w", err)
+	}
+
+	return bundleURL, nil
+}
+
+// cacheKey identifies an export by project and params. The params hash is
+// already stable (sorted JSON), the project ID keeps projects apart.
+func cacheKey(projectID, paramsHash string) string {
+	sum := sha256.Sum256([]byte(projectID + "\n" + paramsHash))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c bundleCache) archivePath(key string) string {
+	return filepath.Join(c.dir, key+".zip")
+}
+
+func (c bundleCache) entryPath(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// lookup returns the entry for key when both files exist and the entry is not expired.
+func (c bundleCache) lookup(key string) (cacheEntry, bool) {
+	entry, err := readCacheEntry(c.entryPath(key))
+	if err != nil || c.expired(entry) {
+		return cacheEntry{}, false
+	}
+
+	if _, err := os.Stat(c.archivePath(key)); err != nil {
+		return cacheEntry{}, false
+	}
+
+	return entry, true
+}
+
+// store writes the sidecar. The archive is already in place at this point,
+// so a sidecar on disk always points to a complete archive.
+func (c bundleCache) store(key string, entry cacheEntry) error {
+	raw, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomically(c.entryPath(key), bytes.NewReader(append(raw, '\n')))
+}
+
+func (c bundleCache) expired(entry cacheEntry) bool {
+	created, err := time.Parse(time.RFC3339, entry.CreatedAt)
+	if err != nil {
+		return true
+	}
+
+	return now().Sub(created) >= c.ttl
+}
+
+// pruneExpired removes stale entries so a long-lived runner cache does not grow forever.
+// Failures are ignored: another job may be pruning the same directory.
+func (c bundleCache) pruneExpired() {
+	sidecars, _ := filepath.Glob(filepath.Join(c.dir, "*.json"))
+
+	for _, sidecar := range sidecars {
+		entry, err := readCacheEntry(sidecar)
+		if err == nil && !c.expired(entry) {
+			continue
+		}
+
+		key := strings.TrimSuffix(filepath.Base(sidecar), ".json")
+		_ = os.Remove(sidecar)
+		_ = os.Remove(c.archivePath(key))
+	}
+}
+
+func readCacheEntry(path string) (cacheEntry, error) {
+	var entry cacheEntry
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return entry, err
+	}
+
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return entry, fmt.Errorf("cannot parse cache entry %s: %w", path, err)
+	}
+
+	return entry, nil
+}
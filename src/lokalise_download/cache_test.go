@@ -0,0 +1,246 @@
+package lokalisedownload
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bodrovis/lokex/v2/client/download"
+)
+
+// fakeBundleFetcher serves a fixed archive and counts exports.
+type fakeBundleFetcher struct {
+	*fakeDownloader
+	entries   []zipEntry
+	t         *testing.T
+	exports   int
+	fetchErr  error
+	gotAsync  bool
+	bundleURL string
+}
+
+func (f *fakeBundleFetcher) FetchBundleURL(_ context.Context, _ download.DownloadParams, async bool) (string, error) {
+	f.exports++
+	f.gotAsync = async
+	return f.bundleURL, f.fetchErr
+}
+
+func (f *fakeBundleFetcher) DownloadArchive(_ context.Context, _ string, destPath string) error {
+	writeTestZip(f.t, destPath, f.entries...)
+	return nil
+}
+
+func cacheConfig(dir string) DownloadConfig {
+	return DownloadConfig{
+		ProjectID:       "proj_123",
+		Token:           "tok",
+		FileFormat:      "json",
+		SkipIncludeTags: true,
+		CacheDir:        dir,
+		CacheTTL:        time.Hour,
+	}
+}
+
+func newFakeBundleFetcher(t *testing.T) *fakeBundleFetcher {
+	return &fakeBundleFetcher{
+		fakeDownloader: &fakeDownloader{},
+		t:              t,
+		bundleURL:      "https://bucket.example.com/b.zip?X-Amz-Signature=abc",
+		entries:        []zipEntry{{name: "locales/en.json", content: `{"k":"v"}`}},
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	base := cacheKey("proj_1", "sha256:a")
+
+	if base != cacheKey("proj_1", "sha256:a") {
+		t.Fatal("cache key must be stable")
+	}
+	if base == cacheKey("proj_2", "sha256:a") || base == cacheKey("proj_1", "sha256:b") {
+		t.Fatal("cache key must depend on project and params")
+	}
+}
+
+func TestDownloadFiles_CacheMissThenHit(t *testing.T) {
+	t.Chdir(t.TempDir())
+	freezeNow(t, time.Date(2025, 10, 9, 11, 30, 0, 0, time.UTC))
+
+	cfg := cacheConfig(".cache/lokalise")
+	fb := newFakeBundleFetcher(t)
+
+	if err := downloadFiles(context.Background(), cfg, &fakeFactory{downloader: fb}); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	if fb.exports != 1 || fb.called {
+		t.Fatalf("expected one export through the cache, got exports=%d download=%v", fb.exports, fb.called)
+	}
+
+	// Remove the extracted files: the second run must restore them from the cache.
+	if err := os.RemoveAll("locales"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := downloadFiles(context.Background(), cfg, &fakeFactory{downloader: fb}); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if fb.exports != 1 {
+		t.Fatalf("expected cache hit, got %d exports", fb.exports)
+	}
+
+	got, err := os.ReadFile(filepath.Join("locales", "en.json"))
+	if err != nil || string(got) != `{"k":"v"}` {
+		t.Fatalf("unexpected extracted file: %q, %v", got, err)
+	}
+
+	sidecars, _ := filepath.Glob(filepath.Join(cfg.CacheDir, "*.json"))
+	if len(sidecars) != 1 {
+		t.Fatalf("expected one cache entry, got %v", sidecars)
+	}
+	raw, _ := os.ReadFile(sidecars[0])
+	if strings.Contains(string(raw), "Signature") {
+		t.Fatalf("cache entry must not keep the pre-signed query: %s", raw)
+	}
+}
+
+func TestDownloadFiles_CacheExpiredEntryIsRefreshed(t *testing.T) {
+	t.Chdir(t.TempDir())
+	start := time.Date(2025, 10, 9, 11, 30, 0, 0, time.UTC)
+	freezeNow(t, start)
+
+	cfg := cacheConfig(".cache")
+	fb := newFakeBundleFetcher(t)
+
+	if err := downloadFiles(context.Background(), cfg, &fakeFactory{downloader: fb}); err != nil {
+		t.Fatal(err)
+	}
+
+	freezeNow(t, start.Add(2*time.Hour))
+	if err := downloadFiles(context.Background(), cfg, &fakeFactory{downloader: fb}); err != nil {
+		t.Fatal(err)
+	}
+
+	if fb.exports != 2 {
+		t.Fatalf("expired entry must trigger a new export, got %d exports", fb.exports)
+	}
+}
+
+func TestDownloadFiles_CacheUsesAsyncFlagAndReportsErrors(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	cfg := cacheConfig(".cache")
+	cfg.AsyncMode = true
+	fb := newFakeBundleFetcher(t)
+	fb.fetchErr = errors.New("boom")
+
+	err := downloadFiles(context.Background(), cfg, &fakeFactory{downloader: fb})
+	if err == nil || !strings.Contains(err.Error(), "download failed: boom") {
+		t.Fatalf("expected wrapped export error, got %v", err)
+	}
+	if !fb.gotAsync {
+		t.Fatal("expected async export")
+	}
+}
+
+func TestDownloadFiles_CacheFallsBackWithoutBundleFetcher(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	fd := &fakeDownloader{}
+	if err := downloadFiles(context.Background(), cacheConfig(".cache"), &fakeFactory{downloader: fd}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !fd.called || fd.gotDest != defaultDownloadDest {
+		t.Fatalf("expected regular download, got %#v", fd)
+	}
+}
+
+func TestBundleCache_PruneExpired(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2025, 10, 9, 11, 30, 0, 0, time.UTC)
+	freezeNow(t, start)
+
+	cache := bundleCache{dir: dir, ttl: time.Hour}
+	for key, created := range map[string]time.Time{"old": start.Add(-2 * time.Hour), "fresh": start} {
+		writeTestFile(t, cache.archivePath(key), "zip")
+		if err := cache.store(key, cacheEntry{CreatedAt: created.Format(time.RFC3339)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTestFile(t, cache.entryPath("broken"), "{")
+
+	cache.pruneExpired()
+
+	if _, ok := cache.lookup("fresh"); !ok {
+		t.Fatal("fresh entry must survive")
+	}
+	for _, path := range []string{cache.archivePath("old"), cache.entryPath("old"), cache.entryPath("broken")} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("%s should be removed", path)
+		}
+	}
+}
+
+func TestDownloadArchive_RetriesWithoutToken(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Token") != "" {
+			t.Error("API token must not be sent to the bundle host")
+		}
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("archive"))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv.URL, "proj")
+	c.HTTPClient.Transport = toServer{srv: srv}
+	d := &lokaliseDownloader{client: c}
+	dest := filepath.Join(t.TempDir(), "cache", "bundle.zip")
+
+	if err := d.DownloadArchive(context.Background(), testBundleURL, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := os.ReadFile(dest)
+	if err != nil || string(got) != "archive" || calls.Load() != 2 {
+		t.Fatalf("unexpected result: %q, %v, calls=%d", got, err, calls.Load())
+	}
+}
+
+func TestDownloadArchive_RejectsUnsafeURLs(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte("archive"))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv.URL, "proj")
+	c.HTTPClient.Transport = toServer{srv: srv}
+	d := &lokaliseDownloader{client: c}
+
+	for _, bundleURL := range []string{
+		"http://bundles.example.com/bundle.zip",
+		"https://user@bundles.example.com/bundle.zip",
+		"https://127.0.0.1/bundle.zip",
+	} {
+		dest := filepath.Join(t.TempDir(), "bundle.zip")
+		if err := d.DownloadArchive(context.Background(), bundleURL, dest); err == nil {
+			t.Fatalf("%s must be rejected", bundleURL)
+		}
+		if _, err := os.Stat(dest); !os.IsNotExist(err) {
+			t.Fatalf("nothing must be written for %s", bundleURL)
+		}
+	}
+	if calls.Load() != 0 {
+		t.Fatalf("rejected URLs must not be requested, got %d calls", calls.Load())
+	}
+}
@@ -0,0 +1,92 @@
+package lokalisedownload
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/lokalise/lokalise-pull-action/src/errkind"
+)
+
+// classifyError returns the kind of err: an explicit kind first, then timeouts,
+// then the HTTP status of a Lokalise API error.
+func classifyError(err error) errkind.Kind {
+	if kind := errkind.Of(err); kind != errkind.Unknown {
+		return kind
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, errExportPending) {
+		return errkind.Timeout
+	}
+	type timeoutError interface {
+		error
+		Timeout() bool
+	}
+	if te, ok := errors.AsType[timeoutError](err); ok && te.Timeout() {
+		return errkind.Timeout
+	}
+
+	if status, ok := apiStatusOf(err); ok {
+		return errorKindForStatus(status)
+	}
+
+	return errkind.Unknown
+}
+
+// errorKindForStatus maps an HTTP status returned by the Lokalise API.
+func errorKindForStatus(status int) errkind.Kind {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return errkind.Auth
+	case http.StatusTooManyRequests:
+		return errkind.RateLimited
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return errkind.Timeout
+	default:
+		return errkind.API
+	}
+}
+
+// apiStatusOf finds the HTTP status of an API error in err's tree.
+func apiStatusOf(err error) (int, bool) {
+	return apiErrorField(err, "Status")
+}
+
+// apiCodeOf finds the Lokalise error code (error.code in the answer body) of an
+// API error in err's tree. Only lokex errors carry it.
+func apiCodeOf(err error) (int, bool) {
+	return apiErrorField(err, "Code")
+}
+
+// apiErrorField returns the int field name of the first API error in err's tree.
+// lokex keeps its APIError type internal and exports no accessor, so errors of
+// other packages are matched by shape: a pointer to a struct named APIError with
+// that field. TestClassifyError_LokexAPIError pins this to real lokex errors.
+func apiErrorField(err error, name string) (int, bool) {
+	if err == nil {
+		return 0, false
+	}
+
+	if v := reflect.ValueOf(err); v.Kind() == reflect.Pointer && !v.IsNil() {
+		if elem := v.Elem(); elem.Kind() == reflect.Struct && elem.Type().Name() == "APIError" {
+			if f := elem.FieldByName(name); f.IsValid() && f.CanInt() {
+				return int(f.Int()), true
+			}
+			return 0, false
+		}
+	}
+
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		return apiErrorField(u.Unwrap(), name)
+	case interface{ Unwrap() []error }:
+		for _, inner := range u.Unwrap() {
+			if value, ok := apiErrorField(inner, name); ok {
+				return value, true
+			}
+		}
+	}
+
+	return 0, false
+}
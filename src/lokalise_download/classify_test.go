@@ -0,0 +1,119 @@
+package lokalisedownload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bodrovis/lokex/v2/client/download"
+
+	"github.com/lokalise/lokalise-pull-action/src/errkind"
+)
+
+type netTimeout struct{}
+
+func (netTimeout) Error() string { return "i/o timeout" }
+func (netTimeout) Timeout() bool { return true }
+
+func TestClassifyError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want errkind.Kind
+	}{
+		{"explicit kind", errkind.With(errkind.Config, errors.New("bad input")), errkind.Config},
+		{"inner kind wins", errkind.With(errkind.API, errkind.With(errkind.Config, errors.New("x"))), errkind.Config},
+		{"unauthorized", fmt.Errorf("download: %w", &APIError{Status: http.StatusUnauthorized}), errkind.Auth},
+		{"forbidden", &APIError{Status: http.StatusForbidden}, errkind.Auth},
+		{"rate limited", &APIError{Status: http.StatusTooManyRequests}, errkind.RateLimited},
+		{"gateway timeout", &APIError{Status: http.StatusGatewayTimeout}, errkind.Timeout},
+		{"other status", &APIError{Status: http.StatusNotFound}, errkind.API},
+		{"deadline", fmt.Errorf("run: %w", context.DeadlineExceeded), errkind.Timeout},
+		{"net timeout", netTimeout{}, errkind.Timeout},
+		{"pending export", fmt.Errorf("poll: %w", errExportPending), errkind.Timeout},
+		{"joined", errors.Join(errors.New("a"), &APIError{Status: http.StatusTooManyRequests}), errkind.RateLimited},
+		{"plain", errors.New("disk full"), errkind.Unknown},
+	}
+
+	for _, tt := range tests {
+		if got := classifyError(tt.err); got != tt.want {
+			t.Errorf("%s: classifyError(%v) = %q, want %q", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+// TestClassifyError_LokexAPIError pins apiErrorField to the errors lokex
+// really returns. lokex keeps its APIError type internal, so a change of its
+// name or fields breaks classification without breaking the build; this test
+// catches it. Both lokex request paths are covered: the JSON requester and
+// the bundle export.
+func TestClassifyError_LokexAPIError(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantCode int
+		wantKind errkind.Kind
+	}{
+		{"unauthorized", http.StatusUnauthorized, `{"error":{"message":"Invalid token","code":401}}`, 401, errkind.Auth},
+		{"not found", http.StatusNotFound, `{"error":{"message":"Not found","code":404}}`, 404, errkind.API},
+		{"no keys", http.StatusNotAcceptable, `{"error":{"message":"No keys for export with current export settings","code":406}}`, 406, errkind.API},
+		{"rate limited", http.StatusTooManyRequests, `{"error":{"message":"Too many requests","code":429}}`, 429, errkind.RateLimited},
+		{"code differs from status", http.StatusBadRequest, `{"error":{"message":"Invalid filter","code":4001}}`, 4001, errkind.API},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			c := newTestClient(t, srv.URL, "proj")
+
+			var out map[string]any
+			requestErr := c.DoJSONWithRetry(context.Background(), http.MethodGet, "projects/proj", nil, &out)
+			_, exportErr := download.NewDownloader(c).FetchBundle(context.Background(), strings.NewReader(`{"format":"json"}`))
+
+			for path, err := range map[string]error{"request": requestErr, "export": exportErr} {
+				if err == nil {
+					t.Fatalf("%s: expected an error", path)
+				}
+				wrapped := fmt.Errorf("download: %w", err)
+
+				if status, ok := apiStatusOf(wrapped); !ok || status != tt.status {
+					t.Fatalf("%s: apiStatusOf = %d, %v, want %d (lokex APIError changed shape? %T)", path, status, ok, tt.status, err)
+				}
+				if code, ok := apiCodeOf(wrapped); !ok || code != tt.wantCode {
+					t.Fatalf("%s: apiCodeOf = %d, %v, want %d", path, code, ok, tt.wantCode)
+				}
+				if got := classifyError(wrapped); got != tt.wantKind {
+					t.Fatalf("%s: classifyError = %q, want %q (%v)", path, got, tt.wantKind, err)
+				}
+			}
+		})
+	}
+}
+
+func TestReportError(t *testing.T) {
+	t.Parallel()
+
+	outputs := map[string]string{}
+	write := func(name, value string) bool { outputs[name] = value; return true }
+
+	err := &APIError{Status: http.StatusTooManyRequests}
+	if code := errkind.Report(err, classifyError(err), write); code != 4 || outputs["error_kind"] != "rate_limited" {
+		t.Fatalf("unexpected result: code=%d outputs=%v", code, outputs)
+	}
+
+	if code := classifyError(errors.New("boom")).ExitCode(); code != 1 {
+		t.Fatalf("unknown errors should exit with 1, got %d", code)
+	}
+}
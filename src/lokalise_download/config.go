@@ -1,23 +1,29 @@
-package main
+package lokalisedownload
 
 import (
+	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/bodrovis/lokalise-actions-common/v2/fileexts"
+	"github.com/bodrovis/lokalise-actions-common/v2/managedpaths"
 	"github.com/bodrovis/lokalise-actions-common/v2/parsers"
+	"github.com/lokalise/lokalise-pull-action/src/cienv"
 )
 
 // Defaults for retry/backoff and timeouts. These are sane, production-leaning values.
 // Note: actual HTTP/backoff implementation is delegated to lokex client.
 const (
-	defaultMaxRetries      = 3   // Default number of retries for rate-limited requests
-	defaultSleepTime       = 1   // Default initial sleep time (seconds) before retrying
-	maxSleepTime           = 60  // Backoff cap (seconds). Prevents unbounded sleeps.
-	defaultDownloadTimeout = 600 // Overall operation deadline (seconds) incl. async polling
-	defaultHTTPTimeout     = 120 // Per-request HTTP timeout (seconds)
-	defaultPollInitialWait = 1   // Initial async poll delay (seconds)
-	defaultPollMaxWait     = 120 // Async polling deadline (seconds)
+	defaultMaxRetries      = 3    // Default number of retries for rate-limited requests
+	defaultSleepTime       = 1    // Default initial sleep time (seconds) before retrying
+	maxSleepTime           = 60   // Backoff cap (seconds). Prevents unbounded sleeps.
+	defaultDownloadTimeout = 600  // Overall operation deadline (seconds) incl. async polling
+	defaultHTTPTimeout     = 120  // Per-request HTTP timeout (seconds)
+	defaultPollInitialWait = 1    // Initial async poll delay (seconds)
+	defaultPollMaxWait     = 120  // Async polling deadline (seconds)
+	defaultCacheTTL        = 3600 // Lifetime of cached bundles (seconds)
 )
 
 // DownloadConfig encapsulates all runtime knobs pulled from env.
@@ -25,7 +31,7 @@ type DownloadConfig struct {
 	ProjectID             string
 	Token                 string
 	FileFormat            string
-	GitHubRefName         string
+	RefName               string
 	AdditionalParams      string
 	SkipIncludeTags       bool
 	SkipOriginalFilenames bool
@@ -34,55 +40,149 @@ type DownloadConfig struct {
 	MaxSleepTime          time.Duration
 	HTTPTimeout           time.Duration
 	DownloadTimeout       time.Duration
-	AsyncMode             bool
+	AsyncMode             bool // true when ASYNC_MODE=true, or when auto mode picked async
 	AsyncPollInitialWait  time.Duration
 	AsyncPollMaxWait      time.Duration
+	SkipUnchanged         bool                          // skip the export when the project matches the lockfile
+	LockfilePath          string                        // optional repo-relative path of the export lockfile
+	TranslationScope      managedpaths.TranslationScope // files covered by lockfile checksums
+	CacheDir              string                        // optional directory for cached export archives
+	CacheTTL              time.Duration                 // how long a cached archive may be reused
+	LocalBundle           string                        // optional local archive (path or file:// URL) used instead of the API
+	DownloadDest          string                        // repo-relative extraction root, "./" when empty
+	DirectoryPrefix       string                        // directory_prefix for original filenames, "/" when empty
+	Projects              string                        // optional LOKALISE_PROJECTS list (YAML or JSON), see parseProjects
+	ConflictPolicy        string                        // how files produced by several projects are handled
+	ProjectBranches       bool                          // download from the Lokalise branch mapped from the git ref
+	BranchMapping         string                        // git -> Lokalise branch rules, see parseBranchMapping
+	BranchFallback        string                        // Lokalise branch used when the mapped one is missing
+	IncludeTags           []string                      // include_tags templates, "{{ref}}" when empty
+	ExcludeTags           []string                      // exclude_tags templates
+	TagFallback           bool                          // retry without include_tags when they match no keys
+	StrictParams          bool                          // fail on unknown additional_params keys and enum values
+	AsyncStateFile        string                        // optional file remembering in-flight async exports
+	AsyncProcessID        string                        // optional async process to resume instead of starting an export
+	AsyncAuto             bool                          // ASYNC_MODE=auto: choose by project size, fall back to async on size errors
+	AsyncAutoThreshold    int                           // key-language pairs from which auto mode goes async right away
+	StrictConfig          bool                          // fail on malformed inputs instead of warning
+	InputProblems         []string                      // malformed inputs replaced by defaults, see envReader
 }
 
 // prepareConfig reads env, applies defaults, and normalizes whitespace.
-// Tolerate bad booleans by falling back to false instead of failing early.
+// Malformed booleans and numbers fall back to their defaults, but every such
+// value is recorded in InputProblems; validateDownloadConfig reports them.
 func prepareConfig() DownloadConfig {
-	skipIncludeTags, err := parsers.ParseBoolEnv("SKIP_INCLUDE_TAGS")
-	if err != nil {
-		skipIncludeTags = false
-	}
-
-	skipOriginalFilenames, err := parsers.ParseBoolEnv("SKIP_ORIGINAL_FILENAMES")
-	if err != nil {
-		skipOriginalFilenames = false
-	}
-
-	asyncMode, err := parsers.ParseBoolEnv("ASYNC_MODE")
-	if err != nil {
-		asyncMode = false
-	}
+	env := &envReader{}
+	asyncMode, asyncAuto := readAsyncMode(env)
 
-	return DownloadConfig{
+	cfg := DownloadConfig{
 		ProjectID:             strings.TrimSpace(os.Getenv("LOKALISE_PROJECT_ID")),
 		Token:                 strings.TrimSpace(os.Getenv("LOKALISE_API_KEY")),
 		FileFormat:            strings.TrimSpace(os.Getenv("FILE_FORMAT")),
-		GitHubRefName:         resolveGitHubRefName(),
+		RefName:               resolveRefName(),
 		AdditionalParams:      strings.TrimSpace(os.Getenv("ADDITIONAL_PARAMS")),
-		SkipIncludeTags:       skipIncludeTags,
-		SkipOriginalFilenames: skipOriginalFilenames,
+		SkipIncludeTags:       env.boolean("SKIP_INCLUDE_TAGS"),
+		SkipOriginalFilenames: env.boolean("SKIP_ORIGINAL_FILENAMES"),
 		AsyncMode:             asyncMode,
-		MaxRetries:            parsers.ParseUintEnv("MAX_RETRIES", defaultMaxRetries),
-		InitialSleepTime:      time.Duration(parsers.ParseUintEnv("SLEEP_TIME", defaultSleepTime)) * time.Second,
+		MaxRetries:            env.positiveInt("MAX_RETRIES", defaultMaxRetries),
+		InitialSleepTime:      time.Duration(env.positiveInt("SLEEP_TIME", defaultSleepTime)) * time.Second,
 		MaxSleepTime:          time.Duration(maxSleepTime) * time.Second,
-		HTTPTimeout:           time.Duration(parsers.ParseUintEnv("HTTP_TIMEOUT", defaultHTTPTimeout)) * time.Second,
-		DownloadTimeout:       time.Duration(parsers.ParseUintEnv("DOWNLOAD_TIMEOUT", defaultDownloadTimeout)) * time.Second,
-		AsyncPollInitialWait:  time.Duration(parsers.ParseUintEnv("ASYNC_POLL_INITIAL_WAIT", defaultPollInitialWait)) * time.Second,
-		AsyncPollMaxWait:      time.Duration(parsers.ParseUintEnv("ASYNC_POLL_MAX_WAIT", defaultPollMaxWait)) * time.Second,
+		HTTPTimeout:           time.Duration(env.positiveInt("HTTP_TIMEOUT", defaultHTTPTimeout)) * time.Second,
+		DownloadTimeout:       time.Duration(env.positiveInt("DOWNLOAD_TIMEOUT", defaultDownloadTimeout)) * time.Second,
+		AsyncPollInitialWait:  time.Duration(env.positiveInt("ASYNC_POLL_INITIAL_WAIT", defaultPollInitialWait)) * time.Second,
+		AsyncPollMaxWait:      time.Duration(env.positiveInt("ASYNC_POLL_MAX_WAIT", defaultPollMaxWait)) * time.Second,
+		SkipUnchanged:         env.boolean("SKIP_UNCHANGED"),
+		LockfilePath:          strings.TrimSpace(os.Getenv("LOCKFILE_PATH")),
+		TranslationScope:      readTranslationScope(env),
+		CacheDir:              strings.TrimSpace(os.Getenv("DOWNLOAD_CACHE_DIR")),
+		CacheTTL:              time.Duration(env.positiveInt("DOWNLOAD_CACHE_TTL", defaultCacheTTL)) * time.Second,
+		LocalBundle:           strings.TrimSpace(os.Getenv("LOCAL_BUNDLE")),
+		DownloadDest:          strings.TrimSpace(os.Getenv("DOWNLOAD_DEST")),
+		DirectoryPrefix:       strings.TrimSpace(os.Getenv("DIRECTORY_PREFIX")),
+		Projects:              strings.TrimSpace(os.Getenv("LOKALISE_PROJECTS")),
+		ConflictPolicy:        strings.ToLower(strings.TrimSpace(os.Getenv("PROJECT_CONFLICT_POLICY"))),
+		ProjectBranches:       env.boolean("USE_PROJECT_BRANCHES"),
+		BranchMapping:         strings.TrimSpace(os.Getenv("PROJECT_BRANCH_MAPPING")),
+		BranchFallback:        strings.TrimSpace(os.Getenv("PROJECT_BRANCH_FALLBACK")),
+		IncludeTags:           splitTags(parsers.ParseStringArrayEnv("INCLUDE_TAGS")),
+		ExcludeTags:           splitTags(parsers.ParseStringArrayEnv("EXCLUDE_TAGS")),
+		TagFallback:           env.boolean("INCLUDE_TAGS_FALLBACK"),
+		StrictParams:          env.boolean("STRICT_PARAMS"),
+		AsyncStateFile:        strings.TrimSpace(os.Getenv("ASYNC_STATE_FILE")),
+		AsyncProcessID:        strings.TrimSpace(os.Getenv("ASYNC_PROCESS_ID")),
+		AsyncAuto:             asyncAuto,
+		AsyncAutoThreshold:    env.positiveInt("ASYNC_AUTO_THRESHOLD", defaultAsyncAutoThreshold),
 	}
+
+	cfg.StrictConfig = env.boolean("STRICT_CONFIG")
+	cfg.InputProblems = env.problems
+	return cfg
 }
 
-// Determine the branch/tag name used for include_tags.
-// On PR events, prefer GITHUB_HEAD_REF because GITHUB_REF_NAME may be "<pr_number>/merge".
-// On push/tag events, fall back to GITHUB_REF_NAME.
-func resolveGitHubRefName() string {
-	if v := strings.TrimSpace(os.Getenv("GITHUB_HEAD_REF")); v != "" {
-		return v
+// readTranslationScope collects the translation layout inputs shared with the
+// other steps. It is lenient on purpose: the scope only matters when a lockfile
+// is requested, and validateDownloadConfig reports missing pieces in that case.
+func readTranslationScope(env *envReader) managedpaths.TranslationScope {
+	flatNaming := env.boolean("FLAT_NAMING")
+	alwaysPullBase := env.boolean("ALWAYS_PULL_BASE")
+
+	// Both helpers fail on missing input; an empty value is checked later.
+	paths, _ := parsers.ParseRepoRelativePathsEnv("TRANSLATIONS_PATH")
+	fileExts, _ := fileexts.ResolveFromEnv("FILE_EXT", "FILE_FORMAT")
+
+	return managedpaths.TranslationScope{
+		Paths:          paths,
+		FileExts:       fileExts,
+		FlatNaming:     flatNaming,
+		AlwaysPullBase: alwaysPullBase,
+		BaseLang:       strings.TrimSpace(os.Getenv("BASE_LANG")),
 	}
+}
+
+// resolveRefName returns the branch or tag used for include_tags and project
+// branches. For pull and merge requests it is the source branch, since the built
+// ref may be synthetic (GITHUB_REF_NAME is "<pr_number>/merge").
+func resolveRefName() string {
+	return cienv.Detect().Branch()
+}
 
-	return strings.TrimSpace(os.Getenv("GITHUB_REF_NAME"))
+// readAsyncMode reads ASYNC_MODE, which is a boolean or "auto".
+func readAsyncMode(env *envReader) (asyncMode, asyncAuto bool) {
+	raw := strings.TrimSpace(os.Getenv("ASYNC_MODE"))
+	if strings.EqualFold(raw, asyncModeAuto) {
+		return false, true
+	}
+
+	value, err := parsers.ParseBoolEnv("ASYNC_MODE")
+	if err != nil {
+		env.problems = append(env.problems, fmt.Sprintf("ASYNC_MODE has incorrect value %q, expected true, false or auto", raw))
+		return false, false
+	}
+	return value, false
+}
+
+// envReader reads optional typed inputs. A malformed value falls back to the
+// default, and the problem is recorded so all of them can be reported at once.
+type envReader struct {
+	problems []string
+}
+
+func (r *envReader) boolean(name string) bool {
+	value, err := parsers.ParseBoolEnv(name)
+	if err != nil {
+		r.problems = append(r.problems, fmt.Sprintf("%s has incorrect value %q, expected true or false", name, os.Getenv(name)))
+		return false
+	}
+	return value
+}
+
+// positiveInt mirrors parsers.ParseUintEnv and records values it would ignore.
+func (r *envReader) positiveInt(name string, defaultVal int) int {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw != "" {
+		if n, err := strconv.Atoi(raw); err != nil || n < 1 {
+			r.problems = append(r.problems, fmt.Sprintf("%s has incorrect value %q, expected a positive integer (using %d)", name, raw, defaultVal))
+		}
+	}
+	return parsers.ParseUintEnv(name, defaultVal)
 }
@@ -1,6 +1,7 @@
-package main
+package lokalisedownload
 
 import (
+	"reflect"
 	"testing"
 	"time"
 )
@@ -34,8 +35,8 @@ func TestPrepareConfig_Basic(t *testing.T) {
 	if cfg.FileFormat != "json" {
 		t.Fatalf("FileFormat mismatch: %q", cfg.FileFormat)
 	}
-	if cfg.GitHubRefName != "release/2025-10-09" {
-		t.Fatalf("GitHubRefName mismatch: %q", cfg.GitHubRefName)
+	if cfg.RefName != "release/2025-10-09" {
+		t.Fatalf("RefName mismatch: %q", cfg.RefName)
 	}
 	if cfg.AdditionalParams != `{"placeholder_format":"icu"}` {
 		t.Fatalf("AdditionalParams mismatch: %q", cfg.AdditionalParams)
@@ -88,8 +89,8 @@ func TestPrepareConfig_TokenAndRefFallbacks(t *testing.T) {
 	if cfg.Token != "secret" {
 		t.Fatalf("Token check failed, got %q", cfg.Token)
 	}
-	if cfg.GitHubRefName != "feature/sweet-stuff" {
-		t.Fatalf("Ref fallback failed, got %q", cfg.GitHubRefName)
+	if cfg.RefName != "feature/sweet-stuff" {
+		t.Fatalf("Ref fallback failed, got %q", cfg.RefName)
 	}
 	if cfg.FileFormat != "yaml" {
 		t.Fatalf("FileFormat mismatch: %q", cfg.FileFormat)
@@ -142,15 +143,15 @@ func TestPrepareConfig_WhitespaceTrim(t *testing.T) {
 	if cfg.FileFormat != "json_structured" {
 		t.Fatalf("FileFormat not trimmed: %q", cfg.FileFormat)
 	}
-	if cfg.GitHubRefName != "refs/heads/release-1" {
-		t.Fatalf("GitHubRefName not trimmed: %q", cfg.GitHubRefName)
+	if cfg.RefName != "refs/heads/release-1" {
+		t.Fatalf("RefName not trimmed: %q", cfg.RefName)
 	}
 	if cfg.AdditionalParams != `{ "bundle_structure": "ICU" }` {
 		t.Fatalf("AdditionalParams not trimmed: %q", cfg.AdditionalParams)
 	}
 }
 
-func TestResolveGitHubRefName(t *testing.T) {
+func TestResolveRefName(t *testing.T) {
 	tests := []struct {
 		name     string
 		refName  string
@@ -191,13 +192,201 @@ func TestResolveGitHubRefName(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CI_PROVIDER", "generic")
 			t.Setenv("GITHUB_REF_NAME", tt.refName)
 			t.Setenv("GITHUB_HEAD_REF", tt.headRef)
 
-			got := resolveGitHubRefName()
+			got := resolveRefName()
 			if got != tt.expected {
 				t.Fatalf("expected %q, got %q", tt.expected, got)
 			}
 		})
 	}
 }
+
+func TestResolveRefName_GitLab(t *testing.T) {
+	t.Setenv("CI_PROVIDER", "")
+	t.Setenv("GITHUB_ACTIONS", "")
+	t.Setenv("GITHUB_REF_NAME", "")
+	t.Setenv("GITHUB_HEAD_REF", "")
+	t.Setenv("GITLAB_CI", "true")
+	t.Setenv("CI_COMMIT_REF_NAME", "refs/merge-requests/7/head")
+	t.Setenv("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME", "feature/i18n")
+
+	if got := resolveRefName(); got != "feature/i18n" {
+		t.Fatalf("expected the merge request source branch, got %q", got)
+	}
+}
+
+func TestPrepareConfig_LockfileAndTranslationScope(t *testing.T) {
+	t.Setenv("LOCKFILE_PATH", "  locales/.lokalise.lock ")
+	t.Setenv("TRANSLATIONS_PATH", "locales\npackages/app/locales\n")
+	t.Setenv("FILE_FORMAT", "json")
+	t.Setenv("FILE_EXT", "")
+	t.Setenv("FLAT_NAMING", "true")
+	t.Setenv("ALWAYS_PULL_BASE", "false")
+	t.Setenv("BASE_LANG", " en ")
+	t.Setenv("SKIP_UNCHANGED", "true")
+
+	cfg := prepareConfig()
+
+	if cfg.LockfilePath != "locales/.lokalise.lock" {
+		t.Fatalf("LockfilePath mismatch: %q", cfg.LockfilePath)
+	}
+	if !cfg.SkipUnchanged {
+		t.Fatal("expected SkipUnchanged to be true")
+	}
+
+	scope := cfg.TranslationScope
+	if !reflect.DeepEqual(scope.Paths, []string{"locales", "packages/app/locales"}) {
+		t.Fatalf("Paths mismatch: %#v", scope.Paths)
+	}
+	if !reflect.DeepEqual(scope.FileExts, []string{"json"}) {
+		t.Fatalf("FileExts mismatch: %#v", scope.FileExts)
+	}
+	if !scope.FlatNaming || scope.AlwaysPullBase {
+		t.Fatalf("unexpected naming flags: %#v", scope)
+	}
+	if scope.BaseLang != "en" {
+		t.Fatalf("BaseLang mismatch: %q", scope.BaseLang)
+	}
+}
+
+func TestPrepareConfig_TranslationScopeIsLenient(t *testing.T) {
+	t.Setenv("TRANSLATIONS_PATH", "../outside")
+	t.Setenv("FILE_FORMAT", "")
+	t.Setenv("FILE_EXT", "")
+
+	cfg := prepareConfig()
+
+	if cfg.LockfilePath != "" {
+		t.Fatalf("LockfilePath should be empty, got %q", cfg.LockfilePath)
+	}
+	if cfg.TranslationScope.Paths != nil || cfg.TranslationScope.FileExts != nil {
+		t.Fatalf("invalid inputs should leave scope empty, got %#v", cfg.TranslationScope)
+	}
+}
+
+func TestPrepareConfig_Cache(t *testing.T) {
+	t.Setenv("DOWNLOAD_CACHE_DIR", " /tmp/lokalise-cache ")
+	t.Setenv("DOWNLOAD_CACHE_TTL", "120")
+
+	cfg := prepareConfig()
+	if cfg.CacheDir != "/tmp/lokalise-cache" || cfg.CacheTTL != 2*time.Minute {
+		t.Fatalf("unexpected cache settings: %q %s", cfg.CacheDir, cfg.CacheTTL)
+	}
+
+	t.Setenv("DOWNLOAD_CACHE_TTL", "")
+	if cfg := prepareConfig(); cfg.CacheTTL != defaultCacheTTL*time.Second {
+		t.Fatalf("expected default TTL, got %s", cfg.CacheTTL)
+	}
+}
+
+func TestPrepareConfig_DownloadLayout(t *testing.T) {
+	t.Setenv("DOWNLOAD_DEST", " packages/web ")
+	t.Setenv("DIRECTORY_PREFIX", " /i18n/ ")
+
+	cfg := prepareConfig()
+	if cfg.DownloadDest != "packages/web" || cfg.DirectoryPrefix != "/i18n/" {
+		t.Fatalf("unexpected layout: %q %q", cfg.DownloadDest, cfg.DirectoryPrefix)
+	}
+}
+
+func TestPrepareConfig_Projects(t *testing.T) {
+	t.Setenv("LOKALISE_PROJECTS", "\n- project_id: p1\n")
+	t.Setenv("PROJECT_CONFLICT_POLICY", " Merge-JSON ")
+
+	cfg := prepareConfig()
+	if cfg.Projects != "- project_id: p1" || cfg.ConflictPolicy != conflictMergeJSON {
+		t.Fatalf("unexpected projects config: %q %q", cfg.Projects, cfg.ConflictPolicy)
+	}
+}
+
+func TestPrepareConfig_ProjectBranches(t *testing.T) {
+	t.Setenv("USE_PROJECT_BRANCHES", "true")
+	t.Setenv("PROJECT_BRANCH_MAPPING", "exact:main=>master\n")
+	t.Setenv("PROJECT_BRANCH_FALLBACK", " develop ")
+
+	cfg := prepareConfig()
+	if !cfg.ProjectBranches || cfg.BranchMapping != "exact:main=>master" || cfg.BranchFallback != "develop" {
+		t.Fatalf("unexpected branch config: %#v", cfg)
+	}
+}
+
+func TestPrepareConfig_TagFilters(t *testing.T) {
+	t.Setenv("INCLUDE_TAGS", "release-{{ref}}\nshared, mobile")
+	t.Setenv("EXCLUDE_TAGS", "deprecated")
+	t.Setenv("INCLUDE_TAGS_FALLBACK", "true")
+
+	cfg := prepareConfig()
+	if !reflect.DeepEqual(cfg.IncludeTags, []string{"release-{{ref}}", "shared", "mobile"}) ||
+		!reflect.DeepEqual(cfg.ExcludeTags, []string{"deprecated"}) ||
+		!cfg.TagFallback {
+		t.Fatalf("unexpected tag config: %#v %#v %v", cfg.IncludeTags, cfg.ExcludeTags, cfg.TagFallback)
+	}
+}
+
+func TestPrepareConfig_AsyncResume(t *testing.T) {
+	t.Setenv("ASYNC_STATE_FILE", " .lokalise/async.json ")
+	t.Setenv("ASYNC_PROCESS_ID", " pid-1 ")
+
+	cfg := prepareConfig()
+	if cfg.AsyncStateFile != ".lokalise/async.json" || cfg.AsyncProcessID != "pid-1" {
+		t.Fatalf("unexpected async resume config: %q %q", cfg.AsyncStateFile, cfg.AsyncProcessID)
+	}
+}
+
+func TestPrepareConfig_AsyncAuto(t *testing.T) {
+	t.Setenv("ASYNC_MODE", " Auto ")
+	t.Setenv("ASYNC_AUTO_THRESHOLD", "5000")
+
+	cfg := prepareConfig()
+	if cfg.AsyncMode || !cfg.AsyncAuto || cfg.AsyncAutoThreshold != 5000 || len(cfg.InputProblems) != 0 {
+		t.Fatalf("unexpected async config: %v %v %d %v", cfg.AsyncMode, cfg.AsyncAuto, cfg.AsyncAutoThreshold, cfg.InputProblems)
+	}
+
+	t.Setenv("ASYNC_MODE", "true")
+	if cfg := prepareConfig(); !cfg.AsyncMode || cfg.AsyncAuto {
+		t.Fatalf("ASYNC_MODE=true should not enable auto mode: %v %v", cfg.AsyncMode, cfg.AsyncAuto)
+	}
+}
+
+func TestPrepareConfig_CollectsMalformedInputs(t *testing.T) {
+	t.Setenv("ASYNC_MODE", "yes")
+	t.Setenv("SKIP_INCLUDE_TAGS", "nope")
+	t.Setenv("SKIP_ORIGINAL_FILENAMES", "true")
+	t.Setenv("MAX_RETRIES", "-2")
+	t.Setenv("HTTP_TIMEOUT", "soon")
+	t.Setenv("STRICT_CONFIG", "true")
+
+	cfg := prepareConfig()
+
+	if cfg.AsyncMode || cfg.SkipIncludeTags || !cfg.SkipOriginalFilenames {
+		t.Fatalf("unexpected booleans: %#v", cfg)
+	}
+	if cfg.MaxRetries != defaultMaxRetries || cfg.HTTPTimeout != defaultHTTPTimeout*time.Second {
+		t.Fatalf("malformed numbers should fall back to defaults, got %d %s", cfg.MaxRetries, cfg.HTTPTimeout)
+	}
+	if !cfg.StrictConfig {
+		t.Fatal("StrictConfig should be true")
+	}
+
+	want := []string{
+		`ASYNC_MODE has incorrect value "yes", expected true, false or auto`,
+		`SKIP_INCLUDE_TAGS has incorrect value "nope", expected true or false`,
+		`MAX_RETRIES has incorrect value "-2", expected a positive integer (using 3)`,
+		`HTTP_TIMEOUT has incorrect value "soon", expected a positive integer (using 120)`,
+	}
+	if !reflect.DeepEqual(cfg.InputProblems, want) {
+		t.Fatalf("InputProblems mismatch:\n got: %#v\nwant: %#v", cfg.InputProblems, want)
+	}
+}
+
+func TestPrepareConfig_NoProblemsForValidInputs(t *testing.T) {
+	t.Setenv("ASYNC_MODE", "TRUE")
+	t.Setenv("MAX_RETRIES", " 5 ")
+
+	if cfg := prepareConfig(); len(cfg.InputProblems) != 0 {
+		t.Fatalf("expected no problems, got %v", cfg.InputProblems)
+	}
+}
@@ -1,12 +1,14 @@
-package main
+package lokalisedownload
 
 import (
 	"context"
 	"fmt"
+	"log/slog"
 
 	"github.com/bodrovis/lokalise-actions-common/v2/parsers"
 	"github.com/bodrovis/lokex/v2/client"
 	"github.com/bodrovis/lokex/v2/client/download"
+	"github.com/lokalise/lokalise-pull-action/src/cienv"
 )
 
 // Downloader abstracts the concrete lokex downloader. Useful for tests.
@@ -15,11 +17,37 @@ type Downloader interface {
 	Download(ctx context.Context, dest string, params download.DownloadParams) (string, error)
 }
 
-// AsyncDownloader is an optional extension used when AsyncMode = true.
+// AsyncDownloader is an optional extension used when AsyncMode = true,
+// and as the fallback for failed sync downloads when ASYNC_MODE=auto.
 type AsyncDownloader interface {
 	DownloadAsync(ctx context.Context, dest string, params download.DownloadParams) (string, error)
 }
 
+// ProjectFingerprinter is an optional extension used when SkipUnchanged = true.
+// It summarizes the current project state without running an export.
+type ProjectFingerprinter interface {
+	ProjectFingerprint(ctx context.Context, params download.DownloadParams) (string, error)
+}
+
+// BundleFetcher is an optional extension used when CacheDir is set.
+// It splits the export from the archive download so the archive can be cached.
+type BundleFetcher interface {
+	FetchBundleURL(ctx context.Context, params download.DownloadParams, async bool) (string, error)
+	DownloadArchive(ctx context.Context, bundleURL, destPath string) error
+}
+
+// BranchLister is an optional extension used when ProjectBranches = true.
+// It lists the branch names of the base project.
+type BranchLister interface {
+	ProjectBranches(ctx context.Context) ([]string, error)
+}
+
+// UntaggedKeyLister is an optional extension used when TagFallback = true.
+// It lists the IDs of the keys without any tag.
+type UntaggedKeyLister interface {
+	UntaggedKeyIDs(ctx context.Context) ([]int64, error)
+}
+
 // ClientFactory allows injecting a fake client in tests and keeping main() thin.
 type ClientFactory interface {
 	NewDownloader(cfg DownloadConfig) (Downloader, error)
@@ -28,11 +56,50 @@ type ClientFactory interface {
 // LokaliseFactory builds a real lokex client with configured backoff/timeouts.
 type LokaliseFactory struct{}
 
-const downloadDest = "./"
+// lokaliseDownloader keeps the lokex client around for the API calls
+// the download package does not cover (e.g. project fingerprints).
+// watcher reports the async exports lokex runs to progress, which receives
+// async export events; nil disables reporting.
+// state tracks in-flight async exports; nil disables resuming.
+type lokaliseDownloader struct {
+	*download.Downloader
+	client   *client.Client
+	watcher  *asyncWatcher
+	progress func(asyncEvent)
+	state    *asyncState
+}
+
+// Defaults used when DOWNLOAD_DEST / DIRECTORY_PREFIX are not set:
+// bundles are extracted into the repo root and keep their original paths.
+const (
+	defaultDownloadDest    = "./"
+	defaultDirectoryPrefix = "/"
+)
+
+// downloadDestOf returns the directory the bundle is extracted into.
+func downloadDestOf(cfg DownloadConfig) string {
+	if cfg.DownloadDest == "" {
+		return defaultDownloadDest
+	}
+	return cfg.DownloadDest
+}
+
+// directoryPrefixOf returns the directory_prefix sent with original_filenames=true.
+func directoryPrefixOf(cfg DownloadConfig) string {
+	if cfg.DirectoryPrefix == "" {
+		return defaultDirectoryPrefix
+	}
+	return cfg.DirectoryPrefix
+}
 
 // NewDownloader wires lokex client with timeouts, retries, UA and polling knobs.
 // All resilience (retry/backoff) is delegated to the lokex library.
+// When LocalBundle is set, no API client is created at all.
 func (f *LokaliseFactory) NewDownloader(cfg DownloadConfig) (Downloader, error) {
+	if cfg.LocalBundle != "" {
+		return newLocalBundleDownloader(cfg.LocalBundle)
+	}
+
 	lokaliseClient, err := client.NewClient(
 		cfg.Token,
 		cfg.ProjectID,
@@ -46,13 +113,28 @@ func (f *LokaliseFactory) NewDownloader(cfg DownloadConfig) (Downloader, error)
 	if err != nil {
 		return nil, err
 	}
-	return download.NewDownloader(lokaliseClient), nil
+	return &lokaliseDownloader{
+		Downloader: download.NewDownloader(lokaliseClient),
+		client:     lokaliseClient,
+		watcher:    watchAsyncExports(lokaliseClient),
+		progress:   logAsyncProgress(slog.Default()),
+		state:      newAsyncState(cfg, cienv.WriteOutput),
+	}, nil
 }
 
 // downloadFiles orchestrates the vendor call respecting AsyncMode.
 // The actual HTTP, backoff and archive handling live inside the lokex client.
 func downloadFiles(ctx context.Context, cfg DownloadConfig, factory ClientFactory) error {
-	fmt.Println("Starting download from Lokalise")
+	slog.Info("Starting download from Lokalise")
+
+	if cfg.Projects != "" {
+		return downloadProjects(ctx, cfg, factory)
+	}
+
+	cfg, err := withProjectBranch(ctx, cfg, factory)
+	if err != nil {
+		return err
+	}
 
 	dl, err := factory.NewDownloader(cfg)
 	if err != nil {
@@ -64,29 +146,70 @@ func downloadFiles(ctx context.Context, cfg DownloadConfig, factory ClientFactor
 		return err
 	}
 
+	cfg = resolveAsyncMode(ctx, cfg, dl)
+
+	var fingerprint string
+	if cfg.SkipUnchanged {
+		var unchanged bool
+		fingerprint, unchanged = checkProjectUnchanged(ctx, cfg, dl, params)
+		if unchanged {
+			return ErrProjectUnchanged
+		}
+	}
+
+	bundleURL, err := exportWithTagFallback(ctx, cfg, dl, params)
+	if err != nil {
+		return err
+	}
+
+	if cfg.LockfilePath != "" {
+		return writeExportLockfile(cfg, params, bundleURL, fingerprint)
+	}
+
+	return nil
+}
+
+// exportBundle downloads and extracts the bundle, going through the cache when configured.
+func exportBundle(ctx context.Context, cfg DownloadConfig, dl Downloader, params download.DownloadParams) (string, error) {
+	if cfg.CacheDir != "" {
+		return fetchBundleCached(ctx, cfg, dl, params)
+	}
+	return fetchBundle(ctx, cfg, dl, params)
+}
+
+// fetchBundle runs the export in the requested mode and returns the bundle URL.
+func fetchBundle(ctx context.Context, cfg DownloadConfig, dl Downloader, params download.DownloadParams) (string, error) {
 	if cfg.AsyncMode {
 		if ad, ok := dl.(AsyncDownloader); ok {
-			if _, err := ad.DownloadAsync(ctx, downloadDest, params); err != nil {
-				return fmt.Errorf("download failed: %w", err)
+			bundleURL, err := ad.DownloadAsync(ctx, downloadDestOf(cfg), params)
+			if err != nil {
+				return "", fmt.Errorf("download failed: %w", err)
 			}
-			return nil
+			return bundleURL, nil
 		}
 		// Defensive: ensures miswired factories don't silently change behavior.
-		return fmt.Errorf("async mode requested, but downloader doesn't support DownloadAsync")
+		return "", fmt.Errorf("async mode requested, but downloader doesn't support DownloadAsync")
 	}
 
 	// Sync path (default). Client handles retries/backoff/unzip internally.
-	if _, err := dl.Download(ctx, downloadDest, params); err != nil {
-		return fmt.Errorf("download failed: %w", err)
+	bundleURL, err := dl.Download(ctx, downloadDestOf(cfg), params)
+	if err != nil {
+		if shouldRetryAsync(ctx, cfg, err) {
+			slog.Info("Sync download failed, retrying in async mode", "error", err)
+			cfg.AsyncMode = true
+			return fetchBundle(ctx, cfg, dl, params)
+		}
+		return "", fmt.Errorf("download failed: %w", err)
 	}
 
-	return nil
+	return bundleURL, nil
 }
 
 // buildDownloadParams assembles the payload for the vendor API.
 // Notes:
 // - When original_filenames=true, Lokalise exports per-original path and directory_prefix is respected.
 // - include_tags narrows the export to keys tagged with the current branch/tag (git-driven workflows).
+// - INCLUDE_TAGS/EXCLUDE_TAGS customize the tag filter (templates, exclusions).
 // - AdditionalParams allows advanced overrides (JSON object or YAML mapping).
 func buildDownloadParams(config DownloadConfig) (download.DownloadParams, error) {
 	params := download.DownloadParams{
@@ -98,13 +221,13 @@ func buildDownloadParams(config DownloadConfig) (download.DownloadParams, error)
 	} else {
 		// Preserve original bundle structure.
 		params["original_filenames"] = true
-		// "/" makes Lokalise export into repo root relative structure.
-		params["directory_prefix"] = "/"
+		// "/" (default) keeps the original paths relative to the download destination.
+		params["directory_prefix"] = directoryPrefixOf(config)
 	}
 
-	if !config.SkipIncludeTags && config.GitHubRefName != "" {
-		// Only pull keys tagged with the current ref.
-		params["include_tags"] = []string{config.GitHubRefName}
+	// By default only keys tagged with the current ref are pulled.
+	if err := applyTagFilters(params, config); err != nil {
+		return nil, err
 	}
 
 	if err := parsers.ParseAdditionalParamsAndMerge(params, config.AdditionalParams); err != nil {
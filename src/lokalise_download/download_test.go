@@ -1,4 +1,4 @@
-package main
+package lokalisedownload
 
 import (
 	"context"
@@ -14,7 +14,7 @@ import (
 func TestBuildDownloadParams_JSON_MergesAndOverrides(t *testing.T) {
 	cfg := DownloadConfig{
 		FileFormat:            "json",
-		GitHubRefName:         "release-2025-08-19",
+		RefName:               "release-2025-08-19",
 		SkipIncludeTags:       false,
 		SkipOriginalFilenames: false,
 		AsyncMode:             true,
@@ -81,7 +81,7 @@ func TestBuildDownloadParams_SkipOriginalFilenames_Enabled(t *testing.T) {
 func TestBuildDownloadParams_YAML_MergesAndOverrides(t *testing.T) {
 	cfg := DownloadConfig{
 		FileFormat:            "json",
-		GitHubRefName:         "release-2025-08-19",
+		RefName:               "release-2025-08-19",
 		SkipIncludeTags:       false,
 		SkipOriginalFilenames: false,
 		AsyncMode:             true,
@@ -120,7 +120,7 @@ include_tags:
 func TestBuildDownloadParams_JSON_EmptyAdditional_UsesDefaults(t *testing.T) {
 	cfg := DownloadConfig{
 		FileFormat:            "yaml",
-		GitHubRefName:         "release-2025-08-19",
+		RefName:               "release-2025-08-19",
 		SkipIncludeTags:       false,
 		SkipOriginalFilenames: false,
 		AsyncMode:             false,
@@ -159,7 +159,7 @@ func TestBuildDownloadParams_JSON_EmptyAdditional_UsesDefaults(t *testing.T) {
 func TestBuildDownloadParams_JSON_Invalid_ReturnsError(t *testing.T) {
 	cfg := DownloadConfig{
 		FileFormat:       "json",
-		GitHubRefName:    "ref",
+		RefName:          "ref",
 		AdditionalParams: `{"indentation": "2sp",`,
 	}
 
@@ -179,8 +179,8 @@ func TestBuildDownloadParams_JSON_Invalid_ReturnsError(t *testing.T) {
 
 func TestBuildDownloadParams_YAML_Invalid_Aborts(t *testing.T) {
 	cfg := DownloadConfig{
-		FileFormat:    "json",
-		GitHubRefName: "ref",
+		FileFormat: "json",
+		RefName:    "ref",
 		// invalid input
 		AdditionalParams: "~~~?? invalid !!",
 	}
@@ -202,7 +202,7 @@ func TestBuildDownloadParams_YAML_Invalid_Aborts(t *testing.T) {
 func TestBuildDownloadParams_LegacyFlags_Aborts(t *testing.T) {
 	cfg := DownloadConfig{
 		FileFormat:       "json",
-		GitHubRefName:    "ref",
+		RefName:          "ref",
 		AdditionalParams: `--indentation=2sp`,
 	}
 
@@ -227,7 +227,7 @@ func TestDownloadFiles_AsyncSuccess(t *testing.T) {
 		ProjectID:             "proj_123",
 		Token:                 "tok_abc",
 		FileFormat:            "json",
-		GitHubRefName:         "v1.2.3",
+		RefName:               "v1.2.3",
 		SkipIncludeTags:       false,
 		SkipOriginalFilenames: false,
 		AsyncMode:             true,
@@ -300,7 +300,7 @@ func TestDownloadFiles_SyncSuccess(t *testing.T) {
 		ProjectID:             "proj_123",
 		Token:                 "tok_abc",
 		FileFormat:            "json",
-		GitHubRefName:         "v1.2.3",
+		RefName:               "v1.2.3",
 		SkipIncludeTags:       false,
 		SkipOriginalFilenames: false,
 		AsyncMode:             false,
@@ -368,7 +368,7 @@ func TestDownloadFiles_FactoryError(t *testing.T) {
 		ProjectID:        "proj_123",
 		Token:            "tok_abc",
 		FileFormat:       "json",
-		GitHubRefName:    "main",
+		RefName:          "main",
 		MaxRetries:       3,
 		InitialSleepTime: time.Duration(1) * time.Second,
 		HTTPTimeout:      time.Duration(10) * time.Second,
@@ -386,7 +386,7 @@ func TestDownloadFiles_DownloadError(t *testing.T) {
 		ProjectID:        "proj_123",
 		Token:            "tok_abc",
 		FileFormat:       "json",
-		GitHubRefName:    "main",
+		RefName:          "main",
 		MaxRetries:       3,
 		InitialSleepTime: time.Duration(1) * time.Second,
 		HTTPTimeout:      time.Duration(10) * time.Second,
@@ -459,3 +459,40 @@ func (f *fakeFactory) NewDownloader(cfg DownloadConfig) (Downloader, error) {
 	}
 	return f.downloader, nil
 }
+
+func TestBuildDownloadParams_CustomDirectoryPrefix(t *testing.T) {
+	cfg := DownloadConfig{
+		FileFormat:      "json",
+		SkipIncludeTags: true,
+		DirectoryPrefix: "/app/%LANG_ISO%/",
+	}
+
+	params, err := buildDownloadParams(cfg)
+	if err != nil {
+		t.Fatalf("buildDownloadParams returned error: %v", err)
+	}
+	if params["directory_prefix"] != "/app/%LANG_ISO%/" {
+		t.Fatalf("unexpected directory_prefix: %#v", params["directory_prefix"])
+	}
+}
+
+func TestDownloadFiles_CustomDestination(t *testing.T) {
+	fd := &fakeAsyncDownloader{fakeDownloader: &fakeDownloader{}}
+	cfg := DownloadConfig{
+		ProjectID:       "p",
+		Token:           "t",
+		FileFormat:      "json",
+		SkipIncludeTags: true,
+		DownloadDest:    "packages/web",
+	}
+
+	for _, async := range []bool{false, true} {
+		cfg.AsyncMode = async
+		if err := downloadFiles(context.Background(), cfg, &fakeFactory{downloader: fd}); err != nil {
+			t.Fatalf("async=%v: unexpected error: %v", async, err)
+		}
+		if fd.gotDest != "packages/web" {
+			t.Fatalf("async=%v: expected dest packages/web, got %s", async, fd.gotDest)
+		}
+	}
+}
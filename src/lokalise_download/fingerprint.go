@@ -0,0 +1,164 @@
+package lokalisedownload
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bodrovis/lokex/v2/client/download"
+)
+
+// ErrProjectUnchanged is returned when the Lokalise project matches the state
+// recorded by the previous run, so the export was skipped.
+var ErrProjectUnchanged = errors.New("lokalise project unchanged since the last export")
+
+// keysPageLimit is the largest page size the keys endpoint accepts.
+const keysPageLimit = 5000
+
+// projectKey holds the key fields the fingerprint is taken from.
+// TranslationsModifiedAt moves whenever a translation of the key is edited,
+// including a reworded string that leaves every project count as it was.
+type projectKey struct {
+	KeyID                  int64    `json:"key_id"`
+	ModifiedAt             int64    `json:"modified_at_timestamp"`
+	TranslationsModifiedAt int64    `json:"translations_modified_at_timestamp"`
+	Tags                   []string `json:"tags"`
+}
+
+type keysPage struct {
+	Keys []projectKey `json:"keys"`
+}
+
+// ProjectFingerprint hashes the ID and the key and translation modification
+// times of every key in the export scope, so an added, removed, renamed or
+// retranslated key changes it. The keys are listed without their translations,
+// a page of up to 5000 keys per call.
+//
+// Only include_tags is mirrored, as filter_tags; the other params are covered by
+// the params hash. When the tags match no key the export either fails or, with
+// INCLUDE_TAGS_FALLBACK, downloads the untagged keys, so those are fingerprinted.
+func (d *lokaliseDownloader) ProjectFingerprint(ctx context.Context, params download.DownloadParams) (string, error) {
+	tags := paramStrings(params["include_tags"])
+
+	keys, err := d.listKeys(ctx, tags)
+	if err != nil {
+		return "", err
+	}
+	if len(keys) == 0 && len(tags) > 0 {
+		if keys, err = d.listKeys(ctx, nil); err != nil {
+			return "", err
+		}
+		keys = untaggedKeys(keys)
+	}
+
+	return fingerprintKeys(keys), nil
+}
+
+// listKeys pages through the project keys, filtered by tags when there are any.
+func (d *lokaliseDownloader) listKeys(ctx context.Context, tags []string) ([]projectKey, error) {
+	path := fmt.Sprintf("projects/%s/keys", url.PathEscape(d.client.ProjectID))
+
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(keysPageLimit))
+	if len(tags) > 0 {
+		query.Set("filter_tags", strings.Join(tags, ","))
+	}
+
+	var keys []projectKey
+	for page := 1; ; page++ {
+		query.Set("page", strconv.Itoa(page))
+
+		var resp keysPage
+		if err := getJSON(ctx, d.client, path, query, &resp); err != nil {
+			return nil, fmt.Errorf("cannot list project keys: %w", err)
+		}
+
+		keys = append(keys, resp.Keys...)
+		if len(resp.Keys) < keysPageLimit {
+			return keys, nil
+		}
+	}
+}
+
+// fingerprintKeys hashes the keys sorted by ID, so the page order does not matter.
+func fingerprintKeys(keys []projectKey) string {
+	sorted := slices.SortedFunc(slices.Values(keys), func(a, b projectKey) int {
+		return cmp.Compare(a.KeyID, b.KeyID)
+	})
+
+	h := sha256.New()
+	for _, k := range sorted {
+		fmt.Fprintf(h, "%d:%d:%d\n", k.KeyID, k.ModifiedAt, k.TranslationsModifiedAt)
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// paramStrings reads a string list param: []string when set by the action,
+// []any when decoded from additional_params.
+func paramStrings(v any) []string {
+	switch vv := v.(type) {
+	case []string:
+		return vv
+	case []any:
+		out := make([]string, 0, len(vv))
+		for _, item := range vv {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// checkProjectUnchanged compares the current project fingerprint with the lockfile.
+// It returns the fresh fingerprint so it can be recorded after the export.
+// Every failure here is a warning: the precheck is an optimization and must never block a pull.
+func checkProjectUnchanged(
+	ctx context.Context,
+	cfg DownloadConfig,
+	dl Downloader,
+	params download.DownloadParams,
+) (string, bool) {
+	fp, ok := dl.(ProjectFingerprinter)
+	if !ok {
+		slog.Warn("downloader cannot fingerprint the project, skip_unchanged has no effect")
+		return "", false
+	}
+
+	fingerprint, err := fp.ProjectFingerprint(ctx, params)
+	if err != nil {
+		slog.Warn("project precheck failed, downloading anyway", "error", err)
+		return "", false
+	}
+
+	previous, err := readLockfile(cfg.LockfilePath)
+	if err != nil {
+		slog.Warn("cannot read previous lockfile, downloading anyway", "error", err)
+		return fingerprint, false
+	}
+	if previous == nil {
+		return fingerprint, false
+	}
+
+	paramsHash, err := hashParams(params)
+	if err != nil {
+		return fingerprint, false
+	}
+
+	unchanged := previous.ProjectID == cfg.ProjectID &&
+		previous.ParamsHash == paramsHash &&
+		previous.ProjectFingerprint == fingerprint
+
+	return fingerprint, unchanged
+}
@@ -0,0 +1,351 @@
+package lokalisedownload
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bodrovis/lokalise-actions-common/v2/managedpaths"
+	"github.com/bodrovis/lokex/v2/client/download"
+)
+
+type fakeFingerprintDownloader struct {
+	*fakeDownloader
+	fingerprint string
+	err         error
+	gotParams   download.DownloadParams
+}
+
+func (f *fakeFingerprintDownloader) ProjectFingerprint(_ context.Context, params download.DownloadParams) (string, error) {
+	f.gotParams = params
+	return f.fingerprint, f.err
+}
+
+func TestFingerprintKeys_OrderIndependentAndSensitive(t *testing.T) {
+	a := []projectKey{{KeyID: 1, ModifiedAt: 10, TranslationsModifiedAt: 10}, {KeyID: 2, ModifiedAt: 10, TranslationsModifiedAt: 20}}
+
+	if fingerprintKeys(a) != fingerprintKeys([]projectKey{a[1], a[0]}) {
+		t.Fatal("fingerprint must not depend on the key order")
+	}
+
+	reworded := []projectKey{a[0], {KeyID: 2, ModifiedAt: 10, TranslationsModifiedAt: 21}}
+	if fingerprintKeys(a) == fingerprintKeys(reworded) {
+		t.Fatal("translation edits must change the fingerprint")
+	}
+
+	renamed := []projectKey{a[0], {KeyID: 2, ModifiedAt: 11, TranslationsModifiedAt: 20}}
+	if fingerprintKeys(a) == fingerprintKeys(renamed) {
+		t.Fatal("key edits must change the fingerprint")
+	}
+
+	if fingerprintKeys(a) == fingerprintKeys(a[:1]) {
+		t.Fatal("removed keys must change the fingerprint")
+	}
+}
+
+func TestParamStrings(t *testing.T) {
+	tests := []struct {
+		in   any
+		want []string
+	}{
+		{nil, nil},
+		{"release", nil},
+		{[]string{"x"}, []string{"x"}},
+		{[]any{"x", 1, "y"}, []string{"x", "y"}},
+	}
+
+	for _, tt := range tests {
+		if got := paramStrings(tt.in); !slices.Equal(got, tt.want) {
+			t.Fatalf("paramStrings(%#v) = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// keysServer serves the keys endpoint; keys returns the keys for a filter_tags value.
+func keysServer(t *testing.T, keys func(filterTags string) []projectKey) (*httptest.Server, *[]string) {
+	t.Helper()
+
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/projects/proj:main/keys" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		q := r.URL.Query()
+		queries = append(queries, r.URL.RawQuery)
+		if q.Get("limit") != strconv.Itoa(keysPageLimit) {
+			t.Errorf("unexpected limit in %s", r.URL.RawQuery)
+		}
+
+		all := keys(q.Get("filter_tags"))
+		page, _ := strconv.Atoi(q.Get("page"))
+		start := min((page-1)*keysPageLimit, len(all))
+		end := min(start+keysPageLimit, len(all))
+
+		_ = json.NewEncoder(w).Encode(keysPage{Keys: all[start:end]})
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &queries
+}
+
+func TestLokaliseDownloader_ProjectFingerprint_ChangesWithTranslationText(t *testing.T) {
+	// Rewording a translation changes neither the key nor any project count,
+	// only the translation modification time of its key.
+	translated := int64(1700000000)
+	srv, _ := keysServer(t, func(string) []projectKey {
+		return []projectKey{
+			{KeyID: 1, ModifiedAt: 1690000000, TranslationsModifiedAt: 1690000000},
+			{KeyID: 2, ModifiedAt: 1690000000, TranslationsModifiedAt: translated},
+		}
+	})
+
+	d := &lokaliseDownloader{client: newTestClient(t, srv.URL, "proj:main")}
+
+	before, err := d.ProjectFingerprint(context.Background(), download.DownloadParams{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(before, "sha256:") {
+		t.Fatalf("unexpected fingerprint %q", before)
+	}
+
+	same, _ := d.ProjectFingerprint(context.Background(), download.DownloadParams{})
+	if same != before {
+		t.Fatal("fingerprint must be stable while nothing changes")
+	}
+
+	translated++
+	after, err := d.ProjectFingerprint(context.Background(), download.DownloadParams{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if after == before {
+		t.Fatal("a reworded translation must change the fingerprint")
+	}
+}
+
+func TestLokaliseDownloader_ProjectFingerprint_PaginatesWithTagFilter(t *testing.T) {
+	var keys []projectKey
+	for i := range keysPageLimit + 1 {
+		keys = append(keys, projectKey{KeyID: int64(i + 1), ModifiedAt: 1})
+	}
+	srv, queries := keysServer(t, func(filterTags string) []projectKey {
+		if filterTags != "release,mobile" {
+			t.Errorf("filter_tags = %q", filterTags)
+		}
+		return keys
+	})
+
+	d := &lokaliseDownloader{client: newTestClient(t, srv.URL, "proj:main")}
+
+	got, err := d.ProjectFingerprint(context.Background(), download.DownloadParams{"include_tags": []any{"release", "mobile"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*queries) != 2 {
+		t.Fatalf("expected two pages, got %v", *queries)
+	}
+	if got != fingerprintKeys(keys) {
+		t.Fatal("fingerprint must cover every page")
+	}
+}
+
+func TestLokaliseDownloader_ProjectFingerprint_UntaggedKeysWhenTagsMatchNothing(t *testing.T) {
+	untagged := projectKey{KeyID: 1, ModifiedAt: 1, TranslationsModifiedAt: 2}
+	srv, queries := keysServer(t, func(filterTags string) []projectKey {
+		if filterTags != "" {
+			return nil
+		}
+		return []projectKey{untagged, {KeyID: 2, ModifiedAt: 1, TranslationsModifiedAt: 2, Tags: []string{"main"}}}
+	})
+
+	d := &lokaliseDownloader{client: newTestClient(t, srv.URL, "proj:main")}
+
+	got, err := d.ProjectFingerprint(context.Background(), download.DownloadParams{"include_tags": []string{"feature-x"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*queries) != 2 || strings.Contains((*queries)[1], "filter_tags") {
+		t.Fatalf("expected a filtered and an unfiltered listing, got %v", *queries)
+	}
+	if got != fingerprintKeys([]projectKey{untagged}) {
+		t.Fatal("fingerprint must cover the untagged keys the fallback exports")
+	}
+}
+
+func lockfileConfig() DownloadConfig {
+	return DownloadConfig{
+		ProjectID:       "proj_123",
+		Token:           "tok",
+		FileFormat:      "json",
+		SkipIncludeTags: true,
+		SkipUnchanged:   true,
+		LockfilePath:    "locales/.lokalise.lock",
+		TranslationScope: managedpaths.TranslationScope{
+			Paths:      []string{"locales"},
+			FileExts:   []string{"json"},
+			FlatNaming: true,
+			BaseLang:   "en",
+		},
+	}
+}
+
+func writePreviousLockfile(t *testing.T, cfg DownloadConfig, fingerprint string) {
+	t.Helper()
+
+	params, err := buildDownloadParams(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, _ := hashParams(params)
+
+	if err := writeLockfile(cfg.LockfilePath, &Lockfile{
+		Version:            lockfileVersion,
+		ProjectID:          cfg.ProjectID,
+		Format:             cfg.FileFormat,
+		ParamsHash:         hash,
+		ProjectFingerprint: fingerprint,
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDownloadFiles_SkipsWhenProjectUnchanged(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	cfg := lockfileConfig()
+	writePreviousLockfile(t, cfg, "sha256:same")
+
+	fd := &fakeFingerprintDownloader{fakeDownloader: &fakeDownloader{}, fingerprint: "sha256:same"}
+
+	err := downloadFiles(context.Background(), cfg, &fakeFactory{downloader: fd})
+	if !errors.Is(err, ErrProjectUnchanged) {
+		t.Fatalf("expected ErrProjectUnchanged, got %v", err)
+	}
+	if fd.called {
+		t.Fatal("export must be skipped")
+	}
+}
+
+func TestDownloadFiles_DownloadsAndRecordsFingerprintWhenChanged(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	cfg := lockfileConfig()
+	writePreviousLockfile(t, cfg, "sha256:old")
+
+	fd := &fakeFingerprintDownloader{fakeDownloader: &fakeDownloader{}, fingerprint: "sha256:new"}
+
+	if err := downloadFiles(context.Background(), cfg, &fakeFactory{downloader: fd}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !fd.called {
+		t.Fatal("expected export to run")
+	}
+
+	lock, err := readLockfile(cfg.LockfilePath)
+	if err != nil || lock == nil {
+		t.Fatalf("cannot read lockfile: %v", err)
+	}
+	if lock.ProjectFingerprint != "sha256:new" {
+		t.Fatalf("fingerprint not recorded: %#v", lock)
+	}
+}
+
+func TestCheckProjectUnchanged(t *testing.T) {
+	params := download.DownloadParams{"format": "json"}
+
+	tests := []struct {
+		name          string
+		previous      string // fingerprint stored in lockfile, "" = no lockfile
+		dl            Downloader
+		wantPrint     string
+		wantUnchanged bool
+	}{
+		{
+			name:      "downloader without fingerprint support",
+			dl:        &fakeDownloader{},
+			wantPrint: "",
+		},
+		{
+			name:      "fingerprint error",
+			dl:        &fakeFingerprintDownloader{fakeDownloader: &fakeDownloader{}, err: errors.New("boom")},
+			wantPrint: "",
+		},
+		{
+			name:      "first run",
+			dl:        &fakeFingerprintDownloader{fakeDownloader: &fakeDownloader{}, fingerprint: "fp"},
+			wantPrint: "fp",
+		},
+		{
+			name:          "matching lockfile",
+			previous:      "fp",
+			dl:            &fakeFingerprintDownloader{fakeDownloader: &fakeDownloader{}, fingerprint: "fp"},
+			wantPrint:     "fp",
+			wantUnchanged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+
+			cfg := lockfileConfig()
+			if tt.previous != "" {
+				hash, _ := hashParams(params)
+				if err := writeLockfile(cfg.LockfilePath, &Lockfile{
+					ProjectID:          cfg.ProjectID,
+					ParamsHash:         hash,
+					ProjectFingerprint: tt.previous,
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, unchanged := checkProjectUnchanged(context.Background(), cfg, tt.dl, params)
+			if got != tt.wantPrint || unchanged != tt.wantUnchanged {
+				t.Fatalf("got (%q, %v), want (%q, %v)", got, unchanged, tt.wantPrint, tt.wantUnchanged)
+			}
+		})
+	}
+}
+
+func TestCheckProjectUnchanged_ParamsChangeForcesDownload(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	cfg := lockfileConfig()
+	if err := writeLockfile(cfg.LockfilePath, &Lockfile{
+		ProjectID:          cfg.ProjectID,
+		ParamsHash:         "sha256:different",
+		ProjectFingerprint: "fp",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	dl := &fakeFingerprintDownloader{fakeDownloader: &fakeDownloader{}, fingerprint: "fp"}
+	if _, unchanged := checkProjectUnchanged(context.Background(), cfg, dl, download.DownloadParams{"format": "json"}); unchanged {
+		t.Fatal("changed params must force a download")
+	}
+}
+
+func TestReadLockfile(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	lock, err := readLockfile("missing.lock")
+	if lock != nil || err != nil {
+		t.Fatalf("missing lockfile should be (nil, nil), got (%v, %v)", lock, err)
+	}
+
+	if err := os.WriteFile("broken.lock", []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readLockfile("broken.lock"); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
@@ -1,14 +1,23 @@
-module lokalise_download
+module github.com/lokalise/lokalise-pull-action/src/lokalise_download
 
 go 1.26
 
 toolchain go1.26.4
 
-require github.com/bodrovis/lokalise-actions-common/v2 v2.14.0
-
-require github.com/bodrovis/lokex/v2 v2.3.1
+require github.com/bodrovis/lokalise-actions-common/v2 v2.15.0
 
 require (
-	go.yaml.in/yaml/v4 v4.0.0-rc.6 // indirect
-	golang.org/x/sync v0.21.0 // indirect
+	github.com/bodrovis/lokex/v2 v2.3.1
+	github.com/lokalise/lokalise-pull-action/src/actionlog v0.0.0
+	github.com/lokalise/lokalise-pull-action/src/cienv v0.0.0
+	github.com/lokalise/lokalise-pull-action/src/errkind v0.0.0
+	go.yaml.in/yaml/v4 v4.0.0-rc.6
 )
+
+require golang.org/x/sync v0.21.0 // indirect
+
+replace github.com/lokalise/lokalise-pull-action/src/actionlog => ../actionlog
+
+replace github.com/lokalise/lokalise-pull-action/src/cienv => ../cienv
+
+replace github.com/lokalise/lokalise-pull-action/src/errkind => ../errkind
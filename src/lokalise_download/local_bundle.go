@@ -0,0 +1,74 @@
+package lokalisedownload
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/bodrovis/lokex/v2/client/download"
+)
+
+// localBundleDownloader replays an archive that is already on disk instead of
+// exporting from Lokalise. Handy for reproducing bugs and for offline runs.
+// It extracts with the same safety checks and layout as a regular bundle.
+type localBundleDownloader struct {
+	source string // value of LOCAL_BUNDLE as configured, reported as the bundle URL
+	path   string // resolved filesystem path of the archive
+}
+
+func newLocalBundleDownloader(source string) (*localBundleDownloader, error) {
+	path, err := localBundlePath(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return &localBundleDownloader{source: source, path: path}, nil
+}
+
+// Download extracts the local archive into dest. Params are ignored:
+// the archive already reflects whatever export produced it.
+func (d *localBundleDownloader) Download(ctx context.Context, dest string, _ download.DownloadParams) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	slog.Info("Using local bundle", "path", d.path)
+	if err := extractArchive(d.path, dest); err != nil {
+		return "", err
+	}
+
+	return d.source, nil
+}
+
+// DownloadAsync behaves like Download: there is nothing to poll for a local file.
+func (d *localBundleDownloader) DownloadAsync(ctx context.Context, dest string, params download.DownloadParams) (string, error) {
+	return d.Download(ctx, dest, params)
+}
+
+// localBundlePath accepts a plain filesystem path or a file:// URL.
+// Only local file URLs are allowed ("file:///abs/path" or "file://localhost/abs/path").
+func localBundlePath(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("LOCAL_BUNDLE is empty")
+	}
+
+	if !strings.HasPrefix(strings.ToLower(raw), "file:") {
+		return raw, nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid LOCAL_BUNDLE URL %q: %w", raw, err)
+	}
+	if u.Host != "" && u.Host != "localhost" {
+		return "", fmt.Errorf("LOCAL_BUNDLE URL %q must point to a local file", raw)
+	}
+	if u.Path == "" {
+		return "", fmt.Errorf("LOCAL_BUNDLE URL %q has no path", raw)
+	}
+
+	return u.Path, nil
+}
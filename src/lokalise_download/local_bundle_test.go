@@ -0,0 +1,121 @@
+package lokalisedownload
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bodrovis/lokex/v2/client/download"
+)
+
+func TestLocalBundlePath(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "bundles/export.zip", want: "bundles/export.zip"},
+		{in: " /tmp/export.zip ", want: "/tmp/export.zip"},
+		{in: "file:///tmp/export.zip", want: "/tmp/export.zip"},
+		{in: "FILE://localhost/tmp/export.zip", want: "/tmp/export.zip"},
+		{in: "file://example.com/export.zip", wantErr: true},
+		{in: "file://", wantErr: true},
+		{in: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := localBundlePath(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Fatalf("%q: expected error, got %q", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Fatalf("%q: got (%q, %v), want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestLokaliseFactory_LocalBundle_RunsOffline(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	writeTestZip(t, "fixtures/export.zip",
+		zipEntry{name: "/locales/en.json", content: `{"hello":"Hello"}`},
+		zipEntry{name: "/locales/fr.json", content: `{"hello":"Bonjour"}`},
+	)
+	abs, err := filepath.Abs("fixtures/export.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DownloadConfig{
+		ProjectID:       "proj_123",
+		FileFormat:      "json",
+		SkipIncludeTags: true,
+		LocalBundle:     "file://" + filepath.ToSlash(abs),
+	}
+
+	if err := validateDownloadConfig(cfg); err != nil {
+		t.Fatalf("local bundle should not require a token: %v", err)
+	}
+
+	dl, err := (&LokaliseFactory{}).NewDownloader(cfg)
+	if err != nil {
+		t.Fatalf("NewDownloader: %v", err)
+	}
+	if _, ok := dl.(*localBundleDownloader); !ok {
+		t.Fatalf("expected local bundle downloader, got %T", dl)
+	}
+
+	if err := downloadFiles(context.Background(), cfg, &LokaliseFactory{}); err != nil {
+		t.Fatalf("downloadFiles: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join("locales", "fr.json"))
+	if err != nil || string(got) != `{"hello":"Bonjour"}` {
+		t.Fatalf("unexpected fr.json: %q, %v", got, err)
+	}
+}
+
+func TestLocalBundleDownloader_ReportsSourceAndRejectsUnsafeArchives(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "export.zip")
+	writeTestZip(t, archive, zipEntry{name: "../evil.json", content: "x"})
+
+	d, err := newLocalBundleDownloader(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = d.DownloadAsync(context.Background(), filepath.Join(dir, "out"), download.DownloadParams{})
+	if err == nil || !strings.Contains(err.Error(), "escapes") {
+		t.Fatalf("expected path check error, got %v", err)
+	}
+
+	writeTestZip(t, archive, zipEntry{name: "en.json", content: "{}"})
+	got, err := d.Download(context.Background(), filepath.Join(dir, "out"), download.DownloadParams{})
+	if err != nil || got != archive {
+		t.Fatalf("got (%q, %v), want source %q", got, err, archive)
+	}
+}
+
+func TestValidateDownloadConfig_LocalBundle(t *testing.T) {
+	cfg := DownloadConfig{
+		ProjectID:       "p",
+		FileFormat:      "json",
+		SkipIncludeTags: true,
+		LocalBundle:     filepath.Join(t.TempDir(), "missing.zip"),
+	}
+
+	if err := validateDownloadConfig(cfg); err == nil || !strings.Contains(err.Error(), "LOCAL_BUNDLE") {
+		t.Fatalf("expected missing bundle error, got %v", err)
+	}
+
+	cfg.LocalBundle = t.TempDir()
+	if err := validateDownloadConfig(cfg); err == nil || !strings.Contains(err.Error(), "not a regular file") {
+		t.Fatalf("expected regular file error, got %v", err)
+	}
+}
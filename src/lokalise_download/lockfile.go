@@ -0,0 +1,222 @@
+package lokalisedownload
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bodrovis/lokalise-actions-common/v2/managedpaths"
+	"github.com/bodrovis/lokalise-actions-common/v2/translationfiles"
+	"github.com/bodrovis/lokex/v2/client/download"
+)
+
+const lockfileVersion = 1
+
+// Lockfile records which Lokalise export produced the translation files on disk.
+// It is committed together with the translations for auditability.
+type Lockfile struct {
+	Version    int    `json:"version"`
+	ProjectID  string `json:"project_id"`
+	Format     string `json:"format"`
+	ParamsHash string `json:"params_hash"`
+	ExportedAt string `json:"exported_at"`
+	BundleURL  string `json:"bundle_url,omitempty"`
+	// ProjectFingerprint is recorded when SKIP_UNCHANGED is on and lets the next run skip the export.
+	ProjectFingerprint string            `json:"project_fingerprint,omitempty"`
+	Files              map[string]string `json:"files"`
+}
+
+// now is overridable in tests to get stable timestamps.
+var now = time.Now
+
+// writeExportLockfile describes the finished export and stores it at cfg.LockfilePath.
+func writeExportLockfile(cfg DownloadConfig, params download.DownloadParams, bundleURL, fingerprint string) error {
+	lock, err := buildLockfile(cfg, params, bundleURL, fingerprint)
+	if err != nil {
+		return fmt.Errorf("cannot build lockfile: %w", err)
+	}
+
+	if err := writeLockfile(cfg.LockfilePath, lock); err != nil {
+		return fmt.Errorf("cannot write lockfile: %w", err)
+	}
+
+	slog.Info("Export metadata written", "path", cfg.LockfilePath)
+	return nil
+}
+
+func buildLockfile(cfg DownloadConfig, params download.DownloadParams, bundleURL, fingerprint string) (*Lockfile, error) {
+	paramsHash, err := hashParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := collectFileChecksums(cfg.TranslationScope, cfg.LockfilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Lockfile{
+		Version:            lockfileVersion,
+		ProjectID:          cfg.ProjectID,
+		Format:             cfg.FileFormat,
+		ParamsHash:         paramsHash,
+		ExportedAt:         now().UTC().Format(time.RFC3339),
+		BundleURL:          redactBundleURL(bundleURL),
+		ProjectFingerprint: fingerprint,
+		Files:              files,
+	}, nil
+}
+
+// hashParams returns a stable digest of the export params.
+// encoding/json sorts map keys, so equal params always produce the same hash.
+func hashParams(params download.DownloadParams) (string, error) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("cannot encode download params: %w", err)
+	}
+
+	sum := sha256.Sum256(raw)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// redactBundleURL drops the query string and fragment. Bundle links are
+// pre-signed, and the signature must not end up in the repository.
+func redactBundleURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+
+	u.RawQuery = ""
+	u.Fragment = ""
+	u.User = nil
+	return u.String()
+}
+
+// collectFileChecksums hashes every managed translation file under the scope roots.
+// Keys are repo-relative paths with forward slashes. The lockfile itself is skipped.
+func collectFileChecksums(scope managedpaths.TranslationScope, lockfilePath string) (map[string]string, error) {
+	matchCfg := scope.ToTranslationFilesConfig()
+	skip := filepath.Clean(lockfilePath)
+	files := make(map[string]string)
+
+	for _, root := range scope.Paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && path == root {
+					// Nothing was exported into this root yet.
+					return nil
+				}
+				return err
+			}
+			if !d.Type().IsRegular() || filepath.Clean(path) == skip {
+				return nil
+			}
+			if !translationfiles.Matches(matchCfg, path) {
+				return nil
+			}
+
+			sum, err := fileChecksum(path)
+			if err != nil {
+				return err
+			}
+			files[filepath.ToSlash(filepath.Clean(path))] = sum
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot checksum files under %s: %w", root, err)
+		}
+	}
+
+	return files, nil
+}
+
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeLockfile stores the lockfile atomically, so an interrupted run
+// never leaves half-written JSON behind.
+func writeLockfile(path string, lock *Lockfile) error {
+	raw, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	raw = append(raw, '\n')
+
+	return writeFileAtomically(path, bytes.NewReader(raw))
+}
+
+// writeFileAtomically copies src into a temp file next to path and renames it into place.
+// Readers (including parallel jobs sharing a cache) see either the old or the new file.
+func writeFileAtomically(path string, src io.Reader) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer func() { _ = os.Remove(tmpName) }()
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpName, path)
+}
+
+// readLockfile loads a previously written lockfile.
+// A missing file is reported as (nil, nil) because the first run has nothing recorded.
+func readLockfile(path string) (*Lockfile, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var lock Lockfile
+	if err := json.Unmarshal(raw, &lock); err != nil {
+		return nil, fmt.Errorf("cannot parse lockfile %s: %w", path, err)
+	}
+
+	return &lock, nil
+}
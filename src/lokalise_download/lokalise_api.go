@@ -0,0 +1,205 @@
+package lokalisedownload
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+
+	"github.com/bodrovis/lokex/v2/client"
+)
+
+// apiErrCap limits how much of an error body we keep for messages.
+const apiErrCap = 8192
+
+// APIError is a non-2xx answer from the Lokalise API for requests issued
+// directly by this action (lokex handles its own calls).
+type APIError struct {
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("API error %d: %s", e.Status, e.Message)
+	}
+	return fmt.Sprintf("API error %d: %s", e.Status, http.StatusText(e.Status))
+}
+
+// getJSON performs a GET against a project-scoped endpoint and decodes the JSON answer into v.
+// lokex's DoJSONWithRetry escapes query strings, so list endpoints with filters go through here.
+// Retries reuse the client's backoff settings.
+func getJSON(ctx context.Context, c *client.Client, path string, query url.Values, v any) error {
+	endpoint, err := apiURL(c.BaseURL, path, query)
+	if err != nil {
+		return err
+	}
+
+	return c.WithExpBackoff(ctx, "GET "+path, func(int) error {
+		return doGetJSON(ctx, c, endpoint, v)
+	}, isRetryableAPICall)
+}
+
+func apiURL(baseURL, path string, query url.Values) (string, error) {
+	full, err := url.JoinPath(baseURL, path)
+	if err != nil {
+		return "", fmt.Errorf("cannot build API URL for %s: %w", path, err)
+	}
+	if len(query) > 0 {
+		full += "?" + query.Encode()
+	}
+	return full, nil
+}
+
+func doGetJSON(ctx context.Context, c *client.Client, endpoint string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Api-Token", c.Token)
+	req.Header.Set("User-Agent", c.UserAgent)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		slurp, _ := io.ReadAll(io.LimitReader(resp.Body, apiErrCap))
+		return &APIError{Status: resp.StatusCode, Message: apiErrorMessage(slurp)}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("cannot decode API response: %w", err)
+	}
+	return nil
+}
+
+// downloadArchive saves the bundle at bundleURL into destPath, retrying transient failures.
+// Bundle URLs are pre-signed, so no API token is sent along. The URL passes the
+// same checks lokex applies before its own downloads.
+func downloadArchive(ctx context.Context, c *client.Client, bundleURL, destPath string) error {
+	bundleURL, err := validateBundleURL(bundleURL)
+	if err != nil {
+		return err
+	}
+
+	return c.WithExpBackoff(ctx, "GET bundle", func(int) error {
+		return doDownloadArchive(ctx, c, bundleURL, destPath)
+	}, isRetryableAPICall)
+}
+
+func doDownloadArchive(ctx context.Context, c *client.Client, bundleURL, destPath string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, bundleURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		slurp, _ := io.ReadAll(io.LimitReader(resp.Body, apiErrCap))
+		return &APIError{Status: resp.StatusCode, Message: strings.TrimSpace(string(slurp))}
+	}
+
+	return writeFileAtomically(destPath, resp.Body)
+}
+
+// validateBundleURL accepts https URLs without userinfo or fragment whose host
+// is public: no localhost, no .local or .internal names, no loopback, link-local
+// or private address. It mirrors the lokex check, which is not exported.
+func validateBundleURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", fmt.Errorf("invalid bundle URL: %w", err)
+	}
+
+	switch {
+	case !strings.EqualFold(u.Scheme, "https"):
+		return "", fmt.Errorf("invalid bundle URL: unsupported scheme %q", u.Scheme)
+	case u.Hostname() == "":
+		return "", errors.New("invalid bundle URL: empty host")
+	case u.User != nil:
+		return "", errors.New("invalid bundle URL: userinfo is not allowed")
+	case u.Fragment != "":
+		return "", errors.New("invalid bundle URL: fragment is not allowed")
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") ||
+		strings.HasSuffix(host, ".local") || strings.HasSuffix(host, ".internal") {
+		return "", fmt.Errorf("invalid bundle URL: local host %q is not allowed", host)
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && !isPublicAddr(ip) {
+		return "", fmt.Errorf("invalid bundle URL: address %s is not allowed", ip)
+	}
+
+	return u.String(), nil
+}
+
+// isPublicAddr rejects loopback, unspecified, multicast, link-local and
+// private (including IPv6 unique local) addresses.
+func isPublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return !ip.IsLoopback() && !ip.IsUnspecified() && !ip.IsMulticast() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsPrivate()
+}
+
+// apiErrorMessage extracts the human-readable message from the usual
+// Lokalise error shapes: {"error":{"message":...}} or {"message":...}.
+func apiErrorMessage(body []byte) string {
+	var payload struct {
+		Message string `json:"message"`
+		Error   struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	if err := json.Unmarshal(body, &payload); err == nil {
+		if msg := strings.TrimSpace(payload.Error.Message); msg != "" {
+			return msg
+		}
+		if msg := strings.TrimSpace(payload.Message); msg != "" {
+			return msg
+		}
+	}
+
+	return strings.TrimSpace(string(body))
+}
+
+// isRetryableAPICall mirrors the lokex policy: retry on 429/5xx and transient
+// network timeouts, never on context cancellation.
+func isRetryableAPICall(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if apiErr, ok := errors.AsType[*APIError](err); ok {
+		return apiErr.Status == http.StatusTooManyRequests || apiErr.Status >= 500
+	}
+
+	type timeoutError interface {
+		error
+		Timeout() bool
+	}
+	if te, ok := errors.AsType[timeoutError](err); ok && te.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -1,34 +1,38 @@
-package main
+// Package lokalisedownload downloads translation files from Lokalise into the
+// repository: it exports the bundle (sync or async), unpacks it and keeps the
+// lockfile, cache and async state used to skip or resume work.
+package lokalisedownload
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
-)
+	"log/slog"
 
-// exitFunc is a function variable that defaults to os.Exit.
-// Overridable in tests to assert exit behavior without actually terminating the process.
-// Rationale: makes CLI testable without forking a process.
-var exitFunc = os.Exit
+	"github.com/lokalise/lokalise-pull-action/src/actionlog/summary"
+	"github.com/lokalise/lokalise-pull-action/src/errkind"
+)
 
 type (
 	validateFunc func(DownloadConfig) error
 	downloadFunc func(context.Context, DownloadConfig, ClientFactory) error
 )
 
-func main() {
-	if err := run(); err != nil {
-		returnWithError(err.Error())
-	}
-}
-
-func run() error {
-	return runWith(
+// Main runs the download step configured by the environment and returns the
+// process exit code. Outputs, including the error_kind of a failure, go to write.
+func Main(write func(string, string) bool) int {
+	err := runWith(
 		prepareConfig,
 		validateDownloadConfig,
 		downloadFiles,
 		&LokaliseFactory{},
+		write,
+		summary.WriteToStepSummary,
 	)
+	if err != nil {
+		return errkind.Report(err, classifyError(err), write)
+	}
+	return 0
 }
 
 func runWith(
@@ -36,26 +40,40 @@ func runWith(
 	validate validateFunc,
 	download downloadFunc,
 	factory ClientFactory,
+	write func(string, string) bool,
+	summarize func(string) bool,
 ) error {
 	cfg := prepare()
 
 	if err := validate(cfg); err != nil {
-		return fmt.Errorf("invalid download config: %w", err)
+		return errkind.With(errkind.Config, fmt.Errorf("invalid download config: %w", err))
 	}
 
 	// Hard deadline for the whole run to avoid hanging jobs in CI.
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.DownloadTimeout)
 	defer cancel()
 
-	if err := download(ctx, cfg, factory); err != nil {
+	started := now()
+	err := download(ctx, cfg, factory)
+	writeDownloadSummary(cfg, now().Sub(started), err, summarize)
+
+	if err != nil {
+		if errors.Is(err, ErrProjectUnchanged) {
+			slog.Info("Lokalise project has not changed since the last export, skipping download")
+			return writeSkippedOutputs(write)
+		}
 		return err
 	}
 
 	return nil
 }
 
-// Kept as a function to allow test substitution via exitFunc.
-func returnWithError(message string) {
-	fmt.Fprintf(os.Stderr, "Error: %s\n", message)
-	exitFunc(1)
+// writeSkippedOutputs tells the following steps that nothing was downloaded.
+func writeSkippedOutputs(write func(string, string) bool) error {
+	if !write("download_skipped", "true") ||
+		!write("has_changes", "false") {
+		return fmt.Errorf("failed to write to GitHub output")
+	}
+
+	return nil
 }
@@ -1,29 +1,16 @@
-package main
+package lokalisedownload
 
 import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
-)
-
-func TestMain(m *testing.M) {
-	// Override exitFunc for testing
-	exitFunc = func(code int) {
-		panic(fmt.Sprintf("Exit called with code %d", code))
-	}
-
-	// Run tests
-	code := m.Run()
-
-	// Restore exitFunc after testing
-	exitFunc = os.Exit
 
-	os.Exit(code)
-}
+	"github.com/lokalise/lokalise-pull-action/src/errkind"
+)
 
 func TestRunWith_Success(t *testing.T) {
 	t.Parallel()
@@ -46,7 +33,7 @@ func TestRunWith_Success(t *testing.T) {
 	validate := func(gotCfg DownloadConfig) error {
 		validateCalled = true
 
-		if gotCfg != cfg {
+		if !reflect.DeepEqual(gotCfg, cfg) {
 			t.Fatalf("validate got unexpected config: %#v", gotCfg)
 		}
 
@@ -56,7 +43,7 @@ func TestRunWith_Success(t *testing.T) {
 	download := func(ctx context.Context, gotCfg DownloadConfig, gotFactory ClientFactory) error {
 		downloadCalled = true
 
-		if gotCfg != cfg {
+		if !reflect.DeepEqual(gotCfg, cfg) {
 			t.Fatalf("download got unexpected config: %#v", gotCfg)
 		}
 		if gotFactory != factory {
@@ -74,7 +61,7 @@ func TestRunWith_Success(t *testing.T) {
 		return nil
 	}
 
-	err := runWith(prepare, validate, download, factory)
+	err := runWith(prepare, validate, download, factory, failingWrite(t), noSummary)
 	if err != nil {
 		t.Fatalf("runWith returned unexpected error: %v", err)
 	}
@@ -106,7 +93,7 @@ func TestRunWith_ReturnsError_WhenValidationFails(t *testing.T) {
 	}
 
 	validate := func(gotCfg DownloadConfig) error {
-		if gotCfg != cfg {
+		if !reflect.DeepEqual(gotCfg, cfg) {
 			t.Fatalf("validate got unexpected config: %#v", gotCfg)
 		}
 
@@ -118,7 +105,7 @@ func TestRunWith_ReturnsError_WhenValidationFails(t *testing.T) {
 		return nil
 	}
 
-	err := runWith(prepare, validate, download, &fakeFactory{})
+	err := runWith(prepare, validate, download, &fakeFactory{}, failingWrite(t), noSummary)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -132,6 +119,9 @@ func TestRunWith_ReturnsError_WhenValidationFails(t *testing.T) {
 	if !strings.Contains(err.Error(), "invalid download config: missing token") {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	if kind := classifyError(err); kind != errkind.Config {
+		t.Fatalf("validation errors should be classified as %q, got %q", errkind.Config, kind)
+	}
 }
 
 func TestRunWith_ReturnsError_WhenDownloadFails(t *testing.T) {
@@ -146,14 +136,14 @@ func TestRunWith_ReturnsError_WhenDownloadFails(t *testing.T) {
 	}
 
 	validate := func(gotCfg DownloadConfig) error {
-		if gotCfg != cfg {
+		if !reflect.DeepEqual(gotCfg, cfg) {
 			t.Fatalf("validate got unexpected config: %#v", gotCfg)
 		}
 		return nil
 	}
 
 	download := func(ctx context.Context, gotCfg DownloadConfig, gotFactory ClientFactory) error {
-		if gotCfg != cfg {
+		if !reflect.DeepEqual(gotCfg, cfg) {
 			t.Fatalf("download got unexpected config: %#v", gotCfg)
 		}
 
@@ -168,7 +158,7 @@ func TestRunWith_ReturnsError_WhenDownloadFails(t *testing.T) {
 		return errors.New("download failed")
 	}
 
-	err := runWith(prepare, validate, download, &fakeFactory{})
+	err := runWith(prepare, validate, download, &fakeFactory{}, failingWrite(t), noSummary)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -227,7 +217,7 @@ func TestRunWith_PassesPreparedConfigToValidateAndDownload(t *testing.T) {
 		return nil
 	}
 
-	err := runWith(prepare, validate, download, &fakeFactory{})
+	err := runWith(prepare, validate, download, &fakeFactory{}, failingWrite(t), noSummary)
 	if err != nil {
 		t.Fatalf("runWith returned unexpected error: %v", err)
 	}
@@ -239,3 +229,59 @@ func TestRunWith_PassesPreparedConfigToValidateAndDownload(t *testing.T) {
 		t.Fatal("expected download to see config")
 	}
 }
+
+func noSummary(string) bool { return true }
+
+func failingWrite(t *testing.T) func(string, string) bool {
+	t.Helper()
+
+	return func(name, value string) bool {
+		t.Fatalf("unexpected output write: %s=%s", name, value)
+		return false
+	}
+}
+
+func TestRunWith_ProjectUnchanged_WritesSkippedOutputs(t *testing.T) {
+	t.Parallel()
+
+	prepare := func() DownloadConfig {
+		return DownloadConfig{DownloadTimeout: 50 * time.Millisecond}
+	}
+	validate := func(DownloadConfig) error { return nil }
+	download := func(context.Context, DownloadConfig, ClientFactory) error {
+		return fmt.Errorf("precheck: %w", ErrProjectUnchanged)
+	}
+
+	outputs := map[string]string{}
+	write := func(name, value string) bool {
+		outputs[name] = value
+		return true
+	}
+
+	if err := runWith(prepare, validate, download, &fakeFactory{}, write, noSummary); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{"download_skipped": "true", "has_changes": "false"}
+	if !reflect.DeepEqual(outputs, want) {
+		t.Fatalf("outputs mismatch: got %v want %v", outputs, want)
+	}
+}
+
+func TestRunWith_ProjectUnchanged_WriteFails(t *testing.T) {
+	t.Parallel()
+
+	prepare := func() DownloadConfig {
+		return DownloadConfig{DownloadTimeout: 50 * time.Millisecond}
+	}
+	validate := func(DownloadConfig) error { return nil }
+	download := func(context.Context, DownloadConfig, ClientFactory) error {
+		return ErrProjectUnchanged
+	}
+	write := func(string, string) bool { return false }
+
+	err := runWith(prepare, validate, download, &fakeFactory{}, write, noSummary)
+	if err == nil || !strings.Contains(err.Error(), "failed to write to GitHub output") {
+		t.Fatalf("expected output error, got %v", err)
+	}
+}
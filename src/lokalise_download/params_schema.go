@@ -0,0 +1,270 @@
+package lokalisedownload
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/bodrovis/lokalise-actions-common/v2/parsers"
+)
+
+// paramKind is the JSON type the download API expects for a param.
+type paramKind int
+
+const (
+	kindString paramKind = iota
+	kindBool
+	kindInt
+	kindStringList
+	kindIntList
+	kindObjectList
+)
+
+func (k paramKind) String() string {
+	switch k {
+	case kindBool:
+		return "a boolean"
+	case kindInt:
+		return "an integer"
+	case kindStringList:
+		return "a list of strings"
+	case kindIntList:
+		return "a list of integers"
+	case kindObjectList:
+		return "a list of objects"
+	default:
+		return "a string"
+	}
+}
+
+// paramSpec describes a single download param. enum applies to strings and string lists.
+type paramSpec struct {
+	kind paramKind
+	enum []string
+}
+
+// downloadParamsSchema lists the params of the Lokalise "Download files" endpoint.
+// https://developers.lokalise.com/reference/download-files
+var downloadParamsSchema = map[string]paramSpec{
+	"format":                        {kind: kindString},
+	"original_filenames":            {kind: kindBool},
+	"bundle_structure":              {kind: kindString},
+	"directory_prefix":              {kind: kindString},
+	"all_platforms":                 {kind: kindBool},
+	"filter_langs":                  {kind: kindStringList},
+	"filter_data":                   {kind: kindStringList, enum: []string{"translated", "untranslated", "reviewed", "reviewed_only", "last_reviewed_only", "verified", "nonhidden"}},
+	"filter_filenames":              {kind: kindStringList},
+	"add_newline_eof":               {kind: kindBool},
+	"custom_translation_status_ids": {kind: kindIntList},
+	"include_tags":                  {kind: kindStringList},
+	"exclude_tags":                  {kind: kindStringList},
+	"export_sort":                   {kind: kindString, enum: []string{"first_added", "last_added", "last_updated", "a_z", "z_a"}},
+	"export_empty_as":               {kind: kindString, enum: []string{"empty", "base", "skip"}},
+	"export_null_as":                {kind: kindString, enum: []string{"null", "empty"}},
+	"include_comments":              {kind: kindBool},
+	"include_description":           {kind: kindBool},
+	"include_pids":                  {kind: kindIntList},
+	"triggers":                      {kind: kindStringList, enum: []string{"amazons3", "gcs", "github", "gitlab", "bitbucket", "azure"}},
+	"filter_repositories":           {kind: kindStringList},
+	"replace_breaks":                {kind: kindBool},
+	"disable_references":            {kind: kindBool},
+	"plural_format":                 {kind: kindString, enum: []string{"array", "generic", "json_string", "icu", "symfony", "i18next", "i18next_v4"}},
+	"placeholder_format":            {kind: kindString, enum: []string{"printf", "ios", "icu", "net", "symfony", "i18n", "raw"}},
+	"webhook_url":                   {kind: kindString},
+	"language_mapping":              {kind: kindObjectList},
+	"icu_numeric":                   {kind: kindBool},
+	"escape_percent":                {kind: kindBool},
+	"indentation":                   {kind: kindString, enum: []string{"default", "1sp", "2sp", "3sp", "4sp", "5sp", "6sp", "7sp", "8sp", "tab"}},
+	"yaml_include_root":             {kind: kindBool},
+	"json_unescaped_slashes":        {kind: kindBool},
+	"java_properties_encoding":      {kind: kindString, enum: []string{"utf-8", "latin-1"}},
+	"java_properties_separator":     {kind: kindString, enum: []string{"=", ":"}},
+	"bundle_description":            {kind: kindString},
+	"filter_task_id":                {kind: kindInt},
+	"compact":                       {kind: kindBool},
+}
+
+// overrideReportedParams are set by the action itself; replacing them through
+// additional_params is allowed but easy to do by accident.
+var overrideReportedParams = []string{"format", "original_filenames", "include_tags"}
+
+// paramsReport is the outcome of checking additional_params.
+type paramsReport struct {
+	Errors   []string // wrong types; the API would reject these
+	Problems []string // unknown keys and values outside known enums; fatal in strict mode
+	Notices  []string // overrides of params the action sets
+}
+
+// checkAdditionalParams validates the user-provided params against the schema
+// and compares them with what the action would send on its own.
+func checkAdditionalParams(cfg DownloadConfig) (paramsReport, error) {
+	var report paramsReport
+	if cfg.AdditionalParams == "" {
+		return report, nil
+	}
+
+	user, err := parsers.ParseObject(cfg.AdditionalParams)
+	if err != nil {
+		return report, fmt.Errorf("invalid additional_params (must be JSON object or YAML mapping): %w", err)
+	}
+
+	baseCfg := cfg
+	baseCfg.AdditionalParams = ""
+	base, err := buildDownloadParams(baseCfg)
+	if err != nil {
+		return report, err
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(user)) {
+		value := user[key]
+
+		spec, known := downloadParamsSchema[key]
+		if !known {
+			report.Problems = append(report.Problems, unknownParamMessage(key))
+			continue
+		}
+
+		if msg := checkParamType(key, spec, value); msg != "" {
+			report.Errors = append(report.Errors, msg)
+			continue
+		}
+		if msg := checkParamEnum(key, spec, value); msg != "" {
+			report.Problems = append(report.Problems, msg)
+		}
+
+		if prev, set := base[key]; set && slices.Contains(overrideReportedParams, key) && !sameParamValue(prev, value) {
+			report.Notices = append(report.Notices, fmt.Sprintf("additional_params overrides %s: %v -> %v", key, prev, value))
+		}
+	}
+
+	return report, nil
+}
+
+func checkParamType(key string, spec paramSpec, value any) string {
+	ok := false
+
+	switch spec.kind {
+	case kindString:
+		_, ok = value.(string)
+	case kindBool:
+		_, ok = value.(bool)
+	case kindInt:
+		ok = isInteger(value)
+	case kindStringList:
+		ok = isListOf(value, func(v any) bool { _, s := v.(string); return s })
+	case kindIntList:
+		ok = isListOf(value, isInteger)
+	case kindObjectList:
+		ok = isListOf(value, func(v any) bool { _, m := v.(map[string]any); return m })
+	}
+
+	if ok {
+		return ""
+	}
+	return fmt.Sprintf("%s must be %s, got %s", key, spec.kind, describeValue(value))
+}
+
+func checkParamEnum(key string, spec paramSpec, value any) string {
+	if len(spec.enum) == 0 {
+		return ""
+	}
+
+	var values []string
+	switch v := value.(type) {
+	case string:
+		values = []string{v}
+	case []any:
+		for _, item := range v {
+			values = append(values, item.(string))
+		}
+	}
+
+	for _, v := range values {
+		if !slices.Contains(spec.enum, v) {
+			return fmt.Sprintf("%s has unsupported value %q (expected one of: %s)", key, v, strings.Join(spec.enum, ", "))
+		}
+	}
+	return ""
+}
+
+// isInteger accepts whole numbers as decoded by encoding/json (float64) or YAML (int).
+func isInteger(v any) bool {
+	switch n := v.(type) {
+	case int, int64, uint64:
+		return true
+	case float64:
+		return n == math.Trunc(n)
+	default:
+		return false
+	}
+}
+
+func isListOf(v any, item func(any) bool) bool {
+	list, ok := v.([]any)
+	if !ok {
+		return false
+	}
+	for _, el := range list {
+		if !item(el) {
+			return false
+		}
+	}
+	return true
+}
+
+func describeValue(v any) string {
+	if v == nil {
+		return "null"
+	}
+	return fmt.Sprintf("%T (%v)", v, v)
+}
+
+// sameParamValue compares values that may differ only in Go types
+// ([]string built by the action vs []any decoded from YAML/JSON).
+func sameParamValue(a, b any) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// unknownParamMessage suggests the closest known key, which catches most typos.
+func unknownParamMessage(key string) string {
+	best, bestDist := "", math.MaxInt
+	for known := range downloadParamsSchema {
+		if d := editDistance(key, known); d < bestDist || (d == bestDist && known < best) {
+			best, bestDist = known, d
+		}
+	}
+
+	if bestDist <= max(2, len(key)/4) {
+		return fmt.Sprintf("unknown download param %q (did you mean %q?)", key, best)
+	}
+	return fmt.Sprintf("unknown download param %q", key)
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,159 @@
+package lokalisedownload
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckAdditionalParams_ValidParams(t *testing.T) {
+	cfg := DownloadConfig{
+		FileFormat:      "json",
+		SkipIncludeTags: true,
+		AdditionalParams: `
+indentation: 2sp
+export_sort: a_z
+filter_data: [translated, reviewed]
+custom_translation_status_ids: [1, 2]
+filter_task_id: 42
+language_mapping:
+  - original_language_iso: en_US
+    custom_language_iso: en-US
+`,
+	}
+
+	report, err := checkAdditionalParams(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Errors)+len(report.Problems)+len(report.Notices) != 0 {
+		t.Fatalf("expected clean report, got %#v", report)
+	}
+
+	// JSON numbers decode as float64 and must still count as integers.
+	cfg.AdditionalParams = `{"filter_task_id": 42, "include_pids": [1, 2]}`
+	if report, err := checkAdditionalParams(cfg); err != nil || len(report.Errors) != 0 {
+		t.Fatalf("unexpected result for JSON numbers: %#v, %v", report, err)
+	}
+}
+
+func TestCheckAdditionalParams_ReportsProblems(t *testing.T) {
+	cfg := DownloadConfig{
+		FileFormat: "json",
+		RefName:    "main",
+		AdditionalParams: `{
+  "orignal_filenames": false,
+  "totally_custom": 1,
+  "replace_breaks": "no",
+  "filter_task_id": 1.5,
+  "export_empty_as": "nothing",
+  "filter_data": ["translated", "fuzzy"],
+  "format": "yaml",
+  "include_tags": ["main"]
+}`,
+	}
+
+	report, err := checkAdditionalParams(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantErrors := []string{
+		"filter_task_id must be an integer, got float64 (1.5)",
+		`replace_breaks must be a boolean, got string (no)`,
+	}
+	if !reflect.DeepEqual(report.Errors, wantErrors) {
+		t.Fatalf("errors:\n got %#v\nwant %#v", report.Errors, wantErrors)
+	}
+
+	wantProblems := []string{
+		`export_empty_as has unsupported value "nothing" (expected one of: empty, base, skip)`,
+		`filter_data has unsupported value "fuzzy" (expected one of: translated, untranslated, reviewed, reviewed_only, last_reviewed_only, verified, nonhidden)`,
+		`unknown download param "orignal_filenames" (did you mean "original_filenames"?)`,
+		`unknown download param "totally_custom"`,
+	}
+	if !reflect.DeepEqual(report.Problems, wantProblems) {
+		t.Fatalf("problems:\n got %#v\nwant %#v", report.Problems, wantProblems)
+	}
+
+	// include_tags matches what the action sends, so only format is reported.
+	if len(report.Notices) != 1 || report.Notices[0] != "additional_params overrides format: json -> yaml" {
+		t.Fatalf("unexpected notices: %#v", report.Notices)
+	}
+}
+
+func TestCheckAdditionalParams_OverrideNotices(t *testing.T) {
+	cfg := DownloadConfig{
+		FileFormat:       "json",
+		RefName:          "main",
+		AdditionalParams: `{"original_filenames": false, "include_tags": ["shared"]}`,
+	}
+
+	report, err := checkAdditionalParams(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		"additional_params overrides include_tags: [main] -> [shared]",
+		"additional_params overrides original_filenames: true -> false",
+	}
+	if !reflect.DeepEqual(report.Notices, want) {
+		t.Fatalf("notices:\n got %#v\nwant %#v", report.Notices, want)
+	}
+
+	// Without include_tags from the action there is nothing to override.
+	cfg.SkipIncludeTags = true
+	report, _ = checkAdditionalParams(cfg)
+	if len(report.Notices) != 1 {
+		t.Fatalf("expected only original_filenames notice, got %#v", report.Notices)
+	}
+}
+
+func TestValidateDownloadConfig_AdditionalParams(t *testing.T) {
+	cfg := DownloadConfig{
+		ProjectID:        "p",
+		Token:            "t",
+		FileFormat:       "json",
+		SkipIncludeTags:  true,
+		AdditionalParams: `{"indentaton": "2sp"}`,
+	}
+
+	if err := validateDownloadConfig(cfg); err != nil {
+		t.Fatalf("unknown keys only warn by default: %v", err)
+	}
+
+	cfg.StrictParams = true
+	err := validateDownloadConfig(cfg)
+	if err == nil || !strings.Contains(err.Error(), `did you mean "indentation"`) {
+		t.Fatalf("expected strict mode failure with a suggestion, got %v", err)
+	}
+
+	cfg.StrictParams = false
+	cfg.AdditionalParams = `{"compact": "yes"}`
+	if err := validateDownloadConfig(cfg); err == nil || !strings.Contains(err.Error(), "compact must be a boolean") {
+		t.Fatalf("expected type error, got %v", err)
+	}
+
+	cfg.AdditionalParams = `[1, 2]`
+	if err := validateDownloadConfig(cfg); err == nil || !strings.Contains(err.Error(), "must be JSON object or YAML mapping") {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "abc", 3},
+		{"same", "same", 0},
+		{"orignal", "original", 1},
+		{"kitten", "sitting", 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Fatalf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,384 @@
+package lokalisedownload
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bodrovis/lokalise-actions-common/v2/parsers"
+	"github.com/bodrovis/lokex/v2/client/download"
+	"go.yaml.in/yaml/v4"
+)
+
+// Conflict policies for files produced by more than one project.
+const (
+	conflictFail      = "fail"       // abort the run
+	conflictFirstWins = "first-wins" // keep the file from the project listed first
+	conflictMergeJSON = "merge-json" // merge JSON objects key by key, later projects win
+)
+
+var conflictPolicies = []string{conflictFail, conflictFirstWins, conflictMergeJSON}
+
+// ProjectSpec is one entry of LOKALISE_PROJECTS.
+// Path and Format override DOWNLOAD_DEST and FILE_FORMAT for this project only.
+type ProjectSpec struct {
+	ProjectID string `yaml:"project_id" json:"project_id"`
+	Path      string `yaml:"path,omitempty" json:"path,omitempty"`
+	Format    string `yaml:"format,omitempty" json:"format,omitempty"`
+}
+
+// parseProjects reads the LOKALISE_PROJECTS value. Like additional_params it
+// accepts YAML or JSON (a JSON list is valid YAML).
+func parseProjects(raw string) ([]ProjectSpec, error) {
+	var projects []ProjectSpec
+	if err := yaml.Unmarshal([]byte(raw), &projects); err != nil {
+		return nil, fmt.Errorf("LOKALISE_PROJECTS must be a YAML or JSON list: %w", err)
+	}
+	if len(projects) == 0 {
+		return nil, fmt.Errorf("LOKALISE_PROJECTS must list at least one project")
+	}
+
+	seen := make(map[string]struct{}, len(projects))
+	for i := range projects {
+		p := &projects[i]
+		p.ProjectID = strings.TrimSpace(p.ProjectID)
+		p.Path = strings.TrimSpace(p.Path)
+		p.Format = strings.TrimSpace(p.Format)
+
+		if p.ProjectID == "" {
+			return nil, fmt.Errorf("LOKALISE_PROJECTS entry %d has no project_id", i+1)
+		}
+		if _, dup := seen[p.ProjectID]; dup {
+			return nil, fmt.Errorf("LOKALISE_PROJECTS lists project %s more than once", p.ProjectID)
+		}
+		seen[p.ProjectID] = struct{}{}
+
+		if p.Path != "" {
+			if _, err := parsers.EnsureRepoRelativePath(p.Path); err != nil {
+				return nil, fmt.Errorf("invalid path for project %s: %w", p.ProjectID, err)
+			}
+		}
+	}
+
+	return projects, nil
+}
+
+// conflictPolicyOf returns the configured policy, "fail" when empty.
+func conflictPolicyOf(cfg DownloadConfig) string {
+	if cfg.ConflictPolicy == "" {
+		return conflictFail
+	}
+	return cfg.ConflictPolicy
+}
+
+// projectConfig derives the single-project config used for one list entry.
+func projectConfig(cfg DownloadConfig, p ProjectSpec) DownloadConfig {
+	pcfg := cfg
+	pcfg.Projects = ""
+	pcfg.ProjectID = p.ProjectID
+	if p.Path != "" {
+		pcfg.DownloadDest = p.Path
+	}
+	if p.Format != "" {
+		pcfg.FileFormat = p.Format
+	}
+	return pcfg
+}
+
+// downloadProjects exports every listed project under the shared deadline in ctx.
+// Each bundle is extracted into its own staging directory first and then merged
+// into the destination, so overlapping files are detected regardless of order.
+func downloadProjects(ctx context.Context, cfg DownloadConfig, factory ClientFactory) error {
+	projects, err := parseProjects(cfg.Projects)
+	if err != nil {
+		return err
+	}
+
+	m := &projectMerger{policy: conflictPolicyOf(cfg), owners: make(map[string]string)}
+	ids := make([]string, 0, len(projects))
+	exports := make([]any, 0, len(projects))
+
+	for _, p := range projects {
+		pcfg := projectConfig(cfg, p)
+		slog.Info("Downloading project", "project_id", p.ProjectID)
+
+		params, err := downloadProjectInto(ctx, pcfg, factory, m)
+		if err != nil {
+			return fmt.Errorf("project %s: %w", p.ProjectID, err)
+		}
+
+		ids = append(ids, p.ProjectID)
+		exports = append(exports, map[string]any{"project_id": p.ProjectID, "params": params})
+	}
+
+	if cfg.LockfilePath != "" {
+		lockCfg := cfg
+		lockCfg.ProjectID = strings.Join(ids, ",")
+		return writeExportLockfile(lockCfg, download.DownloadParams{"projects": exports}, "", "")
+	}
+
+	return nil
+}
+
+// downloadProjectInto exports one project into a staging directory and merges the result.
+func downloadProjectInto(ctx context.Context, pcfg DownloadConfig, factory ClientFactory, m *projectMerger) (download.DownloadParams, error) {
+	pcfg, err := withProjectBranch(ctx, pcfg, factory)
+	if err != nil {
+		return nil, err
+	}
+
+	dl, err := factory.NewDownloader(pcfg)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create Lokalise API client: %w", err)
+	}
+
+	params, err := buildDownloadParams(pcfg)
+	if err != nil {
+		return nil, err
+	}
+	pcfg = resolveAsyncMode(ctx, pcfg, dl)
+
+	staging, err := os.MkdirTemp("", "lokalise-project-*")
+	if err != nil {
+		return nil, fmt.Errorf("cannot create staging directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(staging) }()
+
+	destDir := downloadDestOf(pcfg)
+	pcfg.DownloadDest = staging
+
+	if _, err := exportWithTagFallback(ctx, pcfg, dl, params); err != nil {
+		return nil, err
+	}
+
+	if err := m.merge(pcfg.ProjectID, staging, destDir); err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
+
+// projectMerger moves staged files into place and applies the conflict policy.
+// owners maps every destination path written in this run to its project.
+type projectMerger struct {
+	policy string
+	owners map[string]string
+}
+
+func (m *projectMerger) merge(projectID, staging, destDir string) error {
+	return filepath.WalkDir(staging, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(staging, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(destDir, rel)
+		key := filepath.ToSlash(filepath.Clean(target))
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		owner, taken := m.owners[key]
+		if taken {
+			content, err = m.resolve(target, owner, projectID, content)
+			if err != nil || content == nil {
+				return err
+			}
+		} else {
+			m.owners[key] = projectID
+		}
+
+		return writeFileAtomically(target, bytes.NewReader(content))
+	})
+}
+
+// resolve decides what to write when projectID produced a file that owner already wrote.
+// A nil result means the existing file is kept.
+func (m *projectMerger) resolve(target, owner, projectID string, content []byte) ([]byte, error) {
+	key := filepath.ToSlash(filepath.Clean(target))
+
+	switch m.policy {
+	case conflictFirstWins:
+		slog.Info("Keeping file from the first project", "file", key, "kept", owner, "skipped", projectID)
+		return nil, nil
+	case conflictMergeJSON:
+		if !strings.EqualFold(filepath.Ext(key), ".json") {
+			return nil, fmt.Errorf("file %s is produced by projects %s and %s and is not JSON, cannot merge", key, owner, projectID)
+		}
+		existing, err := os.ReadFile(target)
+		if err != nil {
+			return nil, err
+		}
+		merged, err := mergeJSONFiles(existing, content)
+		if err != nil {
+			return nil, fmt.Errorf("cannot merge %s from projects %s and %s: %w", key, owner, projectID, err)
+		}
+		slog.Info("Merged file from two projects", "file", key, "projects", owner+","+projectID)
+		return merged, nil
+	default:
+		return nil, fmt.Errorf(
+			"file %s is produced by projects %s and %s; set PROJECT_CONFLICT_POLICY to first-wins or merge-json",
+			key, owner, projectID,
+		)
+	}
+}
+
+// mergeJSONFiles deep-merges two JSON objects; keys from next win.
+// Keys keep their order: those of base first, then the new ones of next.
+// Values are copied as written (number formats, string escapes), and the
+// indentation of base is kept so diffs stay small.
+func mergeJSONFiles(base, next []byte) ([]byte, error) {
+	dst, err := parseJSONObject(base)
+	if err != nil {
+		return nil, fmt.Errorf("existing file is not a JSON object: %w", err)
+	}
+	src, err := parseJSONObject(next)
+	if err != nil {
+		return nil, fmt.Errorf("new file is not a JSON object: %w", err)
+	}
+
+	if err := dst.merge(src); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, dst.compact(), "", jsonIndent(base)); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+
+	return buf.Bytes(), nil
+}
+
+// jsonObject is a JSON object that keeps the order of its keys.
+// Values are kept as they were written.
+type jsonObject struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+// parseJSONObject reads an object member by member; "null" is an empty object.
+func parseJSONObject(raw []byte) (*jsonObject, error) {
+	obj := &jsonObject{values: make(map[string]json.RawMessage)}
+	if string(bytes.TrimSpace(raw)) == "null" {
+		return obj, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('{') {
+		return nil, fmt.Errorf("unexpected %v", tok)
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := tok.(string) // object keys are always strings
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		obj.set(key, value)
+	}
+
+	if _, err := dec.Token(); err != nil { // the closing brace
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("unexpected data after the object")
+	}
+
+	return obj, nil
+}
+
+func (o *jsonObject) set(key string, value json.RawMessage) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// merge copies src into o; objects present on both sides are merged recursively.
+func (o *jsonObject) merge(src *jsonObject) error {
+	for _, key := range src.keys {
+		value := src.values[key]
+
+		if existing, ok := o.values[key]; ok && isJSONObject(existing) && isJSONObject(value) {
+			dst, err := parseJSONObject(existing)
+			if err != nil {
+				return err
+			}
+			sub, err := parseJSONObject(value)
+			if err != nil {
+				return err
+			}
+			if err := dst.merge(sub); err != nil {
+				return err
+			}
+			value = dst.compact()
+		}
+
+		o.set(key, value)
+	}
+	return nil
+}
+
+// compact writes the object with its keys in order; values are written as is.
+func (o *jsonObject) compact() json.RawMessage {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		_ = enc.Encode(key) // a string always encodes
+		buf.Truncate(buf.Len() - 1)
+		buf.WriteByte(':')
+		buf.Write(o.values[key])
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes()
+}
+
+func isJSONObject(raw json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(raw)
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
+
+// jsonIndent returns the indentation of the first indented line, two spaces by default.
+func jsonIndent(raw []byte) string {
+	for line := range strings.SplitSeq(string(raw), "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && len(trimmed) < len(line) {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return "  "
+}
+
+// isConflictPolicy reports whether p is one of the supported policies.
+func isConflictPolicy(p string) bool {
+	return slices.Contains(conflictPolicies, p)
+}
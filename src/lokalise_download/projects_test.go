@@ -0,0 +1,282 @@
+package lokalisedownload
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bodrovis/lokex/v2/client/download"
+)
+
+// projectBundleFactory hands out a downloader per project that extracts a fixed set of files.
+type projectBundleFactory struct {
+	t       *testing.T
+	bundles map[string][]zipEntry
+	errs    map[string]error
+	configs []DownloadConfig
+}
+
+func (f *projectBundleFactory) NewDownloader(cfg DownloadConfig) (Downloader, error) {
+	f.configs = append(f.configs, cfg)
+	return &projectBundleDownloader{t: f.t, entries: f.bundles[cfg.ProjectID], err: f.errs[cfg.ProjectID]}, nil
+}
+
+type projectBundleDownloader struct {
+	t       *testing.T
+	entries []zipEntry
+	err     error
+}
+
+func (d *projectBundleDownloader) Download(_ context.Context, dest string, _ download.DownloadParams) (string, error) {
+	if d.err != nil {
+		return "", d.err
+	}
+	archive := filepath.Join(d.t.TempDir(), "bundle.zip")
+	writeTestZip(d.t, archive, d.entries...)
+	return "https://bucket.example.com/b.zip", extractArchive(archive, dest)
+}
+
+func projectsConfig(projects, policy string) DownloadConfig {
+	return DownloadConfig{
+		Token:           "tok",
+		FileFormat:      "json",
+		SkipIncludeTags: true,
+		Projects:        projects,
+		ConflictPolicy:  policy,
+	}
+}
+
+const twoProjects = `
+- project_id: common
+- project_id: app
+  format: json_structured
+`
+
+func TestParseProjects(t *testing.T) {
+	got, err := parseProjects(`[{"project_id":" p1 "},{"project_id":"p2","path":"packages/web","format":"yaml"}]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []ProjectSpec{{ProjectID: "p1"}, {ProjectID: "p2", Path: "packages/web", Format: "yaml"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+
+	if _, err := parseProjects(twoProjects); err != nil {
+		t.Fatalf("YAML list: unexpected error: %v", err)
+	}
+
+	bad := map[string]string{
+		"not a list":   `project_id: p1`,
+		"empty":        `[]`,
+		"missing id":   `[{"path":"x"}]`,
+		"duplicate id": "- project_id: p1\n- project_id: p1",
+		"bad path":     `[{"project_id":"p1","path":"../x"}]`,
+	}
+	for name, raw := range bad {
+		if _, err := parseProjects(raw); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestDownloadProjects_MergesDistinctFiles(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	f := &projectBundleFactory{t: t, bundles: map[string][]zipEntry{
+		"common": {{name: "locales/common/en.json", content: `{"ok":"OK"}`}},
+		"app":    {{name: "locales/app/en.json", content: `{"title":"App"}`}},
+	}}
+
+	if err := downloadFiles(context.Background(), projectsConfig(twoProjects, ""), f); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, path := range []string{"locales/common/en.json", "locales/app/en.json"} {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("%s not written: %v", path, err)
+		}
+	}
+
+	if len(f.configs) != 2 || f.configs[0].ProjectID != "common" || f.configs[1].FileFormat != "json_structured" {
+		t.Fatalf("unexpected per-project configs: %#v", f.configs)
+	}
+}
+
+func TestDownloadProjects_ConflictPolicies(t *testing.T) {
+	bundles := map[string][]zipEntry{
+		"common": {{name: "locales/en.json", content: "{\n    \"ok\": \"OK\",\n    \"nested\": {\"a\": \"1\"}\n}\n"}},
+		"app":    {{name: "locales/en.json", content: `{"ok":"Okay","nested":{"b":"2"},"title":"<App>"}`}},
+	}
+
+	tests := []struct {
+		policy  string
+		want    string
+		wantErr string
+	}{
+		{policy: "", wantErr: "produced by projects common and app"},
+		{policy: conflictFirstWins, want: "{\n    \"ok\": \"OK\",\n    \"nested\": {\"a\": \"1\"}\n}\n"},
+		{
+			policy: conflictMergeJSON,
+			want:   "{\n    \"ok\": \"Okay\",\n    \"nested\": {\n        \"a\": \"1\",\n        \"b\": \"2\"\n    },\n    \"title\": \"<App>\"\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("policy="+tt.policy, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+
+			f := &projectBundleFactory{t: t, bundles: bundles}
+			err := downloadFiles(context.Background(), projectsConfig(twoProjects, tt.policy), f)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, _ := os.ReadFile("locales/en.json")
+			if string(got) != tt.want {
+				t.Fatalf("unexpected content:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloadProjects_MergeJSONRejectsOtherFormats(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	f := &projectBundleFactory{t: t, bundles: map[string][]zipEntry{
+		"common": {{name: "locales/en.yml", content: "ok: OK\n"}},
+		"app":    {{name: "locales/en.yml", content: "ok: Okay\n"}},
+	}}
+
+	err := downloadFiles(context.Background(), projectsConfig(twoProjects, conflictMergeJSON), f)
+	if err == nil || !strings.Contains(err.Error(), "not JSON") {
+		t.Fatalf("expected non-JSON merge error, got %v", err)
+	}
+}
+
+func TestDownloadProjects_PathOverrideAndErrors(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	projects := "- project_id: common\n- project_id: web\n  path: packages/web\n"
+	f := &projectBundleFactory{t: t, bundles: map[string][]zipEntry{
+		"common": {{name: "locales/en.json", content: `{}`}},
+		"web":    {{name: "locales/en.json", content: `{}`}},
+	}}
+
+	if err := downloadFiles(context.Background(), projectsConfig(projects, ""), f); err != nil {
+		t.Fatalf("same names in different destinations must not conflict: %v", err)
+	}
+	if _, err := os.Stat("packages/web/locales/en.json"); err != nil {
+		t.Fatalf("path override ignored: %v", err)
+	}
+
+	f.errs = map[string]error{"web": errors.New("boom")}
+	err := downloadFiles(context.Background(), projectsConfig(projects, ""), f)
+	if err == nil || !strings.Contains(err.Error(), "project web: download failed: boom") {
+		t.Fatalf("expected project error, got %v", err)
+	}
+}
+
+func TestDownloadProjects_WritesCombinedLockfile(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	cfg := projectsConfig(twoProjects, conflictFirstWins)
+	cfg.LockfilePath = "locales/.lokalise.lock"
+	cfg.TranslationScope = lockfileConfig().TranslationScope
+
+	f := &projectBundleFactory{t: t, bundles: map[string][]zipEntry{
+		"common": {{name: "locales/fr.json", content: `{}`}},
+	}}
+
+	if err := downloadFiles(context.Background(), cfg, f); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lock, err := readLockfile(cfg.LockfilePath)
+	if err != nil || lock == nil {
+		t.Fatalf("cannot read lockfile: %v", err)
+	}
+	if lock.ProjectID != "common,app" || len(lock.Files) != 1 {
+		t.Fatalf("unexpected lockfile: %#v", lock)
+	}
+}
+
+func TestValidateDownloadConfig_Projects(t *testing.T) {
+	t.Parallel()
+
+	ok := projectsConfig(twoProjects, conflictMergeJSON)
+	if err := validateDownloadConfig(ok); err != nil {
+		t.Fatalf("project list should replace LOKALISE_PROJECT_ID: %v", err)
+	}
+
+	tests := map[string]func(*DownloadConfig){
+		"bad policy":     func(c *DownloadConfig) { c.ConflictPolicy = "last-wins" },
+		"bad list":       func(c *DownloadConfig) { c.Projects = "nope" },
+		"skip unchanged": func(c *DownloadConfig) { c.SkipUnchanged = true; c.LockfilePath = "x" },
+		"local bundle":   func(c *DownloadConfig) { c.LocalBundle = "bundle.zip" },
+	}
+	for name, mutate := range tests {
+		cfg := projectsConfig(twoProjects, "")
+		mutate(&cfg)
+		if err := validateDownloadConfig(cfg); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestMergeJSONFiles(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		base string
+		next string
+		want string
+	}{
+		{
+			name: "keeps key order and tabs",
+			base: "{\n\t\"zeta\": \"Z\",\n\t\"alpha\": {\n\t\t\"y\": \"1\",\n\t\t\"x\": \"2\"\n\t},\n\t\"mid\": \"M\"\n}\n",
+			next: `{"beta":"B","alpha":{"w":"0","y":"one"},"zeta":"Zed"}`,
+			want: "{\n\t\"zeta\": \"Zed\",\n\t\"alpha\": {\n\t\t\"y\": \"one\",\n\t\t\"x\": \"2\",\n\t\t\"w\": \"0\"\n\t},\n\t\"mid\": \"M\",\n\t\"beta\": \"B\"\n}\n",
+		},
+		{
+			name: "keeps values as written",
+			base: "{\n  \"n\": 1.50,\n  \"s\": \"caf\\u00e9 <b>\"\n}",
+			next: `{"list":[1, 2]}`,
+			want: "{\n  \"n\": 1.50,\n  \"s\": \"caf\\u00e9 <b>\",\n  \"list\": [\n    1,\n    2\n  ]\n}\n",
+		},
+		{
+			name: "null base",
+			base: `null`,
+			next: `{"a":{"b":"c"}}`,
+			want: "{\n  \"a\": {\n    \"b\": \"c\"\n  }\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := mergeJSONFiles([]byte(tt.base), []byte(tt.next))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: unexpected result:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{`[1]`, `"text"`, `{"a":1} {}`, `{"a":`} {
+		if _, err := mergeJSONFiles([]byte(bad), []byte(`{}`)); err == nil {
+			t.Errorf("expected an error for base %q", bad)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package lokalisedownload
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bodrovis/lokex/v2/client/download"
+
+	"github.com/lokalise/lokalise-pull-action/src/actionlog/summary"
+)
+
+// redacted replaces secrets in the params shown in the job summary.
+const redacted = "[redacted]"
+
+// writeDownloadSummary appends the projects, export mode, duration, outcome and
+// download params to the job summary. The summary is informational: failures are logged only.
+func writeDownloadSummary(cfg DownloadConfig, elapsed time.Duration, err error, summarize func(string) bool) {
+	var md summary.Markdown
+	md.Heading("Lokalise download")
+	md.Table([]string{"Item", "Value"}, [][]string{
+		{"Project", summary.Code(projectsOf(cfg))},
+		{"Export mode", exportModeOf(cfg)},
+		{"Duration", elapsed.Round(100 * time.Millisecond).String()},
+		{"Result", downloadResultOf(err)},
+	})
+
+	if params, perr := buildDownloadParams(cfg); perr == nil {
+		if raw, jerr := json.MarshalIndent(redactParams(params, cfg.Token), "", "  "); jerr == nil {
+			md.Details("Download params", "json", redactToken(string(raw), cfg.Token))
+		}
+	}
+
+	if !summarize(md.String()) {
+		slog.Debug("job summary not written")
+	}
+}
+
+func projectsOf(cfg DownloadConfig) string {
+	if cfg.Projects == "" {
+		return cfg.ProjectID
+	}
+
+	specs, err := parseProjects(cfg.Projects)
+	if err != nil {
+		return cfg.ProjectID
+	}
+	ids := make([]string, 0, len(specs))
+	for _, p := range specs {
+		ids = append(ids, p.ProjectID)
+	}
+	return strings.Join(ids, ", ")
+}
+
+func exportModeOf(cfg DownloadConfig) string {
+	switch {
+	case cfg.LocalBundle != "":
+		return "local bundle"
+	case cfg.AsyncAuto:
+		return "auto"
+	case cfg.AsyncMode:
+		return "async"
+	default:
+		return "sync"
+	}
+}
+
+func downloadResultOf(err error) string {
+	switch {
+	case err == nil:
+		return "downloaded"
+	case errors.Is(err, ErrProjectUnchanged):
+		return "skipped, project unchanged"
+	default:
+		return "failed (" + string(classifyError(err)) + "): " + err.Error()
+	}
+}
+
+// redactParams hides values of secret-looking keys; additional_params is user input
+// and may carry credentials for custom webhooks or integrations.
+func redactParams(params download.DownloadParams, token string) download.DownloadParams {
+	out := make(download.DownloadParams, len(params))
+	for k, v := range params {
+		key := strings.ToLower(k)
+		if strings.Contains(key, "token") || strings.Contains(key, "secret") || strings.Contains(key, "password") {
+			out[k] = redacted
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// redactToken removes the API token wherever it appears.
+func redactToken(s, token string) string {
+	if token == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, token, redacted)
+}
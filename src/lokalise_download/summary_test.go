@@ -0,0 +1,71 @@
+package lokalisedownload
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bodrovis/lokex/v2/client/download"
+)
+
+func TestWriteDownloadSummary(t *testing.T) {
+	t.Parallel()
+
+	cfg := DownloadConfig{
+		ProjectID:        "123.abc",
+		Token:            "tok_secret",
+		FileFormat:       "json",
+		RefName:          "main",
+		AsyncMode:        true,
+		AdditionalParams: `{"webhook_secret": "s3cr3t", "note": "tok_secret"}`,
+	}
+
+	var got string
+	writeDownloadSummary(cfg, 2340*time.Millisecond, nil, func(md string) bool { got = md; return true })
+
+	for _, want := range []string{
+		"### Lokalise download",
+		"| Project | `123.abc` |",
+		"| Export mode | async |",
+		"| Duration | 2.3s |",
+		"| Result | downloaded |",
+		`"format": "json"`,
+		`"webhook_secret": "[redacted]"`,
+		`"note": "[redacted]"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("summary is missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "tok_secret") || strings.Contains(got, "s3cr3t") {
+		t.Fatalf("summary leaks secrets:\n%s", got)
+	}
+}
+
+func TestDownloadResultOf(t *testing.T) {
+	t.Parallel()
+
+	if got := downloadResultOf(ErrProjectUnchanged); got != "skipped, project unchanged" {
+		t.Fatalf("unexpected result: %q", got)
+	}
+	if got := downloadResultOf(&APIError{Status: 401, Message: "Invalid token"}); got != "failed (auth): API error 401: Invalid token" {
+		t.Fatalf("unexpected result: %q", got)
+	}
+	if got := downloadResultOf(errors.New("boom")); got != "failed (unknown): boom" {
+		t.Fatalf("unexpected result: %q", got)
+	}
+}
+
+func TestProjectsOf(t *testing.T) {
+	t.Parallel()
+
+	cfg := DownloadConfig{ProjectID: "main", Projects: `[{"project_id": "p1"}, {"project_id": "p2", "path": "web"}]`}
+	if got := projectsOf(cfg); got != "p1, p2" {
+		t.Fatalf("unexpected projects: %q", got)
+	}
+
+	if got := redactParams(download.DownloadParams{"api_token": "x", "format": "json"}, ""); got["api_token"] != redacted || got["format"] != "json" {
+		t.Fatalf("unexpected params: %v", got)
+	}
+}
@@ -0,0 +1,200 @@
+package lokalisedownload
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/bodrovis/lokex/v2/client/download"
+)
+
+// defaultIncludeTag keeps the historical behavior: filter by the current ref.
+const defaultIncludeTag = "{{ref}}"
+
+// tagPlaceholder matches "{{ref}}" and "{{ ref }}".
+var tagPlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z_]+)\s*\}\}`)
+
+// splitTags turns a newline- or comma-separated list into tags.
+// Lokalise tags cannot contain commas, so both separators are safe.
+func splitTags(lines []string) []string {
+	var tags []string
+	for _, line := range lines {
+		for tag := range strings.SplitSeq(line, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// includeTagTemplatesOf returns INCLUDE_TAGS, or "{{ref}}" when none are configured.
+func includeTagTemplatesOf(cfg DownloadConfig) []string {
+	if len(cfg.IncludeTags) == 0 {
+		return []string{defaultIncludeTag}
+	}
+	return cfg.IncludeTags
+}
+
+// usesRef reports whether any template refers to the git ref.
+func usesRef(templates []string) bool {
+	for _, tpl := range templates {
+		for _, m := range tagPlaceholder.FindAllStringSubmatch(tpl, -1) {
+			if m[1] == "ref" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// renderTags expands the templates and drops duplicates.
+func renderTags(templates []string, ref string) ([]string, error) {
+	seen := make(map[string]struct{}, len(templates))
+	tags := make([]string, 0, len(templates))
+
+	for _, tpl := range templates {
+		tag, err := renderTag(tpl, ref)
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := seen[tag]; dup {
+			continue
+		}
+		seen[tag] = struct{}{}
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+// renderTag expands "{{ref}}" in a single template. Unknown placeholders and
+// templates that render to an empty tag are errors.
+func renderTag(tpl, ref string) (string, error) {
+	var unknown string
+	out := tagPlaceholder.ReplaceAllStringFunc(tpl, func(m string) string {
+		name := tagPlaceholder.FindStringSubmatch(m)[1]
+		if name != "ref" {
+			unknown = name
+			return m
+		}
+		return ref
+	})
+
+	if unknown != "" {
+		return "", fmt.Errorf("unknown placeholder {{%s}} in tag %q (supported: {{ref}})", unknown, tpl)
+	}
+
+	out = strings.TrimSpace(out)
+	if out == "" {
+		return "", fmt.Errorf("tag %q renders to an empty value", tpl)
+	}
+
+	return out, nil
+}
+
+// applyTagFilters sets include_tags and exclude_tags. AdditionalParams are merged
+// afterwards and can still override both.
+func applyTagFilters(params download.DownloadParams, cfg DownloadConfig) error {
+	if !cfg.SkipIncludeTags {
+		templates := includeTagTemplatesOf(cfg)
+		// Without a ref, the default "{{ref}}" filter is simply not applied;
+		// validateDownloadConfig reports this case before we get here.
+		if cfg.RefName != "" || !usesRef(templates) {
+			tags, err := renderTags(templates, cfg.RefName)
+			if err != nil {
+				return fmt.Errorf("invalid INCLUDE_TAGS: %w", err)
+			}
+			params["include_tags"] = tags
+		}
+	}
+
+	if len(cfg.ExcludeTags) > 0 {
+		tags, err := renderTags(cfg.ExcludeTags, cfg.RefName)
+		if err != nil {
+			return fmt.Errorf("invalid EXCLUDE_TAGS: %w", err)
+		}
+		params["exclude_tags"] = tags
+	}
+
+	return nil
+}
+
+// exportWithTagFallback runs the export and, when TagFallback is on and the
+// include_tags filter matches no keys, exports the untagged keys instead. The
+// export API has no filter for untagged keys, so they are listed first and
+// exported by ID (include_ids) in place of include_tags. params is updated in
+// place so the lockfile records what was actually exported.
+func exportWithTagFallback(ctx context.Context, cfg DownloadConfig, dl Downloader, params download.DownloadParams) (string, error) {
+	bundleURL, err := exportBundle(ctx, cfg, dl, params)
+	if err == nil || !cfg.TagFallback || !isNoKeysError(err) {
+		return bundleURL, err
+	}
+	if _, ok := params["include_tags"]; !ok {
+		return "", err
+	}
+
+	lister, ok := dl.(UntaggedKeyLister)
+	if !ok {
+		slog.Warn("downloader cannot list untagged keys, INCLUDE_TAGS_FALLBACK has no effect")
+		return "", err
+	}
+	ids, listErr := lister.UntaggedKeyIDs(ctx)
+	if listErr != nil {
+		return "", fmt.Errorf("cannot list untagged keys for INCLUDE_TAGS_FALLBACK: %w", listErr)
+	}
+	if len(ids) == 0 {
+		slog.Warn("No keys match include_tags and the project has no untagged keys", "include_tags", params["include_tags"])
+		return "", err
+	}
+
+	slog.Warn("No keys match include_tags, downloading the untagged keys instead (INCLUDE_TAGS_FALLBACK)",
+		"include_tags", params["include_tags"], "untagged_keys", len(ids))
+	delete(params, "include_tags")
+	params["include_ids"] = ids
+
+	return exportBundle(ctx, cfg, dl, params)
+}
+
+// UntaggedKeyIDs lists the keys of the project that carry no tag.
+func (d *lokaliseDownloader) UntaggedKeyIDs(ctx context.Context) ([]int64, error) {
+	keys, err := d.listKeys(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+	for _, k := range untaggedKeys(keys) {
+		ids = append(ids, k.KeyID)
+	}
+	return ids, nil
+}
+
+// untaggedKeys returns the keys without any tag.
+func untaggedKeys(keys []projectKey) []projectKey {
+	var res []projectKey
+	for _, k := range keys {
+		if len(k.Tags) == 0 {
+			res = append(res, k)
+		}
+	}
+	return res
+}
+
+// noKeysCode is the status and error code of the Lokalise answer for an export
+// that matches no keys ("No keys for export with current export settings").
+const noKeysCode = http.StatusNotAcceptable
+
+// isNoKeysError recognizes the answer for an export that matches no keys.
+// The error code from the answer body is preferred; errors without one are
+// matched on the HTTP status.
+func isNoKeysError(err error) bool {
+	if code, ok := apiCodeOf(err); ok && code != 0 {
+		return code == noKeysCode
+	}
+	status, ok := apiStatusOf(err)
+	return ok && status == noKeysCode
+}
@@ -0,0 +1,269 @@
+package lokalisedownload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bodrovis/lokex/v2/client/download"
+)
+
+// noKeysDownloader fails while include_tags are set, like Lokalise does for an empty export.
+type noKeysDownloader struct {
+	calls      []download.DownloadParams
+	alwaysFail bool
+	untagged   []int64
+	listErr    error
+}
+
+func (d *noKeysDownloader) UntaggedKeyIDs(context.Context) ([]int64, error) {
+	return d.untagged, d.listErr
+}
+
+func (d *noKeysDownloader) Download(_ context.Context, _ string, params download.DownloadParams) (string, error) {
+	d.calls = append(d.calls, maps.Clone(params))
+	if _, tagged := params["include_tags"]; tagged || d.alwaysFail {
+		return "", &APIError{Status: http.StatusNotAcceptable, Message: "No keys for export with current export settings"}
+	}
+	return "https://bucket.example.com/b.zip", nil
+}
+
+func TestSplitTags(t *testing.T) {
+	got := splitTags([]string{"a, b", "", " c ", "d,,"})
+	if !reflect.DeepEqual(got, []string{"a", "b", "c", "d"}) {
+		t.Fatalf("unexpected tags: %#v", got)
+	}
+	if splitTags(nil) != nil {
+		t.Fatal("expected nil for no tags")
+	}
+}
+
+func TestRenderTags(t *testing.T) {
+	got, err := renderTags([]string{"{{ref}}", "release-{{ ref }}", "mobile", "{{ref}}"}, "main")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"main", "release-main", "mobile"}) {
+		t.Fatalf("unexpected tags: %#v", got)
+	}
+
+	if _, err := renderTags([]string{"{{sha}}"}, "main"); err == nil || !strings.Contains(err.Error(), "unknown placeholder") {
+		t.Fatalf("expected unknown placeholder error, got %v", err)
+	}
+	if _, err := renderTags([]string{"{{ref}}"}, ""); err == nil || !strings.Contains(err.Error(), "empty") {
+		t.Fatalf("expected empty tag error, got %v", err)
+	}
+}
+
+func TestBuildDownloadParams_TagFilters(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         DownloadConfig
+		wantInclude any
+		wantExclude any
+	}{
+		{
+			name:        "default ref tag",
+			cfg:         DownloadConfig{RefName: "main"},
+			wantInclude: []string{"main"},
+		},
+		{
+			name:        "templates and excludes",
+			cfg:         DownloadConfig{RefName: "2.1", IncludeTags: []string{"release-{{ref}}", "shared"}, ExcludeTags: []string{"deprecated"}},
+			wantInclude: []string{"release-2.1", "shared"},
+			wantExclude: []string{"deprecated"},
+		},
+		{
+			name:        "static tags without ref",
+			cfg:         DownloadConfig{IncludeTags: []string{"mobile"}},
+			wantInclude: []string{"mobile"},
+		},
+		{
+			name:        "excludes with skipped includes",
+			cfg:         DownloadConfig{SkipIncludeTags: true, RefName: "main", ExcludeTags: []string{"wip-{{ref}}"}},
+			wantExclude: []string{"wip-main"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.FileFormat = "json"
+			params, err := buildDownloadParams(tt.cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := params["include_tags"]; !reflect.DeepEqual(got, tt.wantInclude) {
+				t.Fatalf("include_tags = %#v, want %#v", got, tt.wantInclude)
+			}
+			if got := params["exclude_tags"]; !reflect.DeepEqual(got, tt.wantExclude) {
+				t.Fatalf("exclude_tags = %#v, want %#v", got, tt.wantExclude)
+			}
+		})
+	}
+}
+
+func TestValidateDownloadConfig_TagFilters(t *testing.T) {
+	base := DownloadConfig{ProjectID: "p", Token: "t", FileFormat: "json"}
+
+	tests := []struct {
+		name    string
+		mutate  func(*DownloadConfig)
+		wantErr string
+	}{
+		{name: "static include without ref", mutate: func(c *DownloadConfig) { c.IncludeTags = []string{"mobile"} }},
+		{name: "template without ref", mutate: func(c *DownloadConfig) { c.IncludeTags = []string{"release-{{ref}}"} }, wantErr: "GITHUB_REF_NAME or GITHUB_HEAD_REF is required"},
+		{name: "exclude template without ref", mutate: func(c *DownloadConfig) {
+			c.SkipIncludeTags = true
+			c.ExcludeTags = []string{"{{ref}}"}
+		}, wantErr: "EXCLUDE_TAGS use {{ref}}"},
+		{name: "unknown placeholder", mutate: func(c *DownloadConfig) {
+			c.RefName = "main"
+			c.IncludeTags = []string{"{{branch}}"}
+		}, wantErr: "invalid INCLUDE_TAGS"},
+		{name: "skipped includes are not checked", mutate: func(c *DownloadConfig) {
+			c.SkipIncludeTags = true
+			c.IncludeTags = []string{"{{branch}}"}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			tt.mutate(&cfg)
+			err := validateDownloadConfig(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDownloadFiles_TagFallback(t *testing.T) {
+	cfg := DownloadConfig{
+		ProjectID:   "p",
+		Token:       "t",
+		FileFormat:  "json",
+		RefName:     "feature/x",
+		ExcludeTags: []string{"wip"},
+		TagFallback: true,
+	}
+
+	dl := &noKeysDownloader{untagged: []int64{3, 5}}
+	if err := downloadFiles(context.Background(), cfg, &fakeFactory{downloader: dl}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(dl.calls) != 2 {
+		t.Fatalf("expected a retry, got %d calls", len(dl.calls))
+	}
+	if _, ok := dl.calls[1]["include_tags"]; ok {
+		t.Fatal("retry must drop include_tags")
+	}
+	if !reflect.DeepEqual(dl.calls[1]["include_ids"], []int64{3, 5}) {
+		t.Fatalf("retry must export the untagged keys only: %#v", dl.calls[1])
+	}
+
+	// Without the fallback the error is reported as is.
+	cfg.TagFallback = false
+	dl = &noKeysDownloader{untagged: []int64{3}}
+	err := downloadFiles(context.Background(), cfg, &fakeFactory{downloader: dl})
+	if err == nil || len(dl.calls) != 1 {
+		t.Fatalf("expected a single failing call, got %v after %d calls", err, len(dl.calls))
+	}
+
+	// The retry is attempted once only.
+	cfg.TagFallback = true
+	dl = &noKeysDownloader{alwaysFail: true, untagged: []int64{3}}
+	err = downloadFiles(context.Background(), cfg, &fakeFactory{downloader: dl})
+	if err == nil || len(dl.calls) != 2 {
+		t.Fatalf("expected failure after one retry, got %v after %d calls", err, len(dl.calls))
+	}
+}
+
+func TestDownloadFiles_TagFallbackWithoutUntaggedKeys(t *testing.T) {
+	cfg := DownloadConfig{ProjectID: "p", Token: "t", FileFormat: "json", RefName: "main", TagFallback: true}
+
+	// Without untagged keys there is nothing to fall back to: the whole
+	// project must never be exported instead.
+	dl := &noKeysDownloader{}
+	err := downloadFiles(context.Background(), cfg, &fakeFactory{downloader: dl})
+	if !isNoKeysError(err) || len(dl.calls) != 1 {
+		t.Fatalf("expected the no-keys error after a single call, got %v after %d calls", err, len(dl.calls))
+	}
+
+	dl = &noKeysDownloader{listErr: errors.New("boom")}
+	err = downloadFiles(context.Background(), cfg, &fakeFactory{downloader: dl})
+	if err == nil || !strings.Contains(err.Error(), "untagged keys") || len(dl.calls) != 1 {
+		t.Fatalf("expected the listing error after a single call, got %v after %d calls", err, len(dl.calls))
+	}
+}
+
+func TestLokaliseDownloader_UntaggedKeyIDs(t *testing.T) {
+	srv, queries := keysServer(t, func(string) []projectKey {
+		return []projectKey{
+			{KeyID: 1},
+			{KeyID: 2, Tags: []string{"main"}},
+			{KeyID: 3, Tags: []string{}},
+		}
+	})
+
+	d := &lokaliseDownloader{client: newTestClient(t, srv.URL, "proj:main")}
+
+	ids, err := d.UntaggedKeyIDs(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []int64{1, 3}) {
+		t.Fatalf("ids = %v, want [1 3]", ids)
+	}
+	if strings.Contains((*queries)[0], "filter_tags") {
+		t.Fatalf("untagged keys must be listed without a tag filter: %v", *queries)
+	}
+}
+
+func TestIsNoKeysError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/projects/proj/files/download":
+			w.WriteHeader(http.StatusNotAcceptable)
+			_, _ = w.Write([]byte(`{"error":{"message":"No keys for export with current export settings","code":406}}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"No keys for export with current export settings","code":400}}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv.URL, "proj")
+	var out map[string]any
+	noKeys := c.DoJSONWithRetry(context.Background(), http.MethodPost, "projects/proj/files/download", nil, &out)
+	other := c.DoJSONWithRetry(context.Background(), http.MethodPost, "projects/proj/files/other", nil, &out)
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"lokex 406", fmt.Errorf("download: %w", noKeys), true},
+		{"lokex code 400 with the same message", other, false},
+		{"status only", &APIError{Status: http.StatusNotAcceptable}, true},
+		{"message only", errors.New("No keys for export with current export settings"), false},
+	}
+
+	for _, tt := range tests {
+		if got := isNoKeysError(tt.err); got != tt.want {
+			t.Errorf("%s: isNoKeysError(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
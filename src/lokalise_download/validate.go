@@ -1,17 +1,44 @@
-package main
+package lokalisedownload
 
 import (
 	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bodrovis/lokalise-actions-common/v2/parsers"
 )
 
 // validateDownloadConfig enforces required inputs and guards common pitfalls.
 // Intentionally fails fast with actionable messages for CI logs.
 func validateDownloadConfig(config DownloadConfig) error {
-	if config.ProjectID == "" {
+	if err := validateInputProblems(config); err != nil {
+		return err
+	}
+
+	// With a project list, LOKALISE_PROJECT_ID is not used.
+	if config.Projects != "" {
+		if err := validateProjects(config); err != nil {
+			return err
+		}
+	} else if config.ProjectID == "" {
 		return fmt.Errorf("LOKALISE_PROJECT_ID is required and cannot be empty")
 	}
 
-	if config.Token == "" {
+	if config.ConflictPolicy != "" && !isConflictPolicy(config.ConflictPolicy) {
+		return fmt.Errorf(
+			"invalid PROJECT_CONFLICT_POLICY %q (expected one of: %s)",
+			config.ConflictPolicy, strings.Join(conflictPolicies, ", "),
+		)
+	}
+
+	// A local bundle never reaches the API, so the token is optional then.
+	if config.LocalBundle != "" {
+		if err := validateLocalBundle(config.LocalBundle); err != nil {
+			return err
+		}
+	} else if config.Token == "" {
 		return fmt.Errorf("LOKALISE_API_KEY is required and cannot be empty")
 	}
 
@@ -19,13 +46,350 @@ func validateDownloadConfig(config DownloadConfig) error {
 		return fmt.Errorf("FILE_FORMAT environment variable is required")
 	}
 
-	// include_tags requires a non-empty ref. Users can opt-out via SKIP_INCLUDE_TAGS=true.
-	if !config.SkipIncludeTags && config.GitHubRefName == "" {
+	if err := validateTagFilters(config); err != nil {
+		return err
+	}
+
+	if err := validateAdditionalParams(config); err != nil {
+		return err
+	}
+
+	if config.ProjectBranches {
+		if err := validateProjectBranches(config); err != nil {
+			return err
+		}
+	}
+
+	if err := validateDownloadLayout(config); err != nil {
+		return err
+	}
+
+	if config.LockfilePath != "" {
+		if err := validateLockfilePath(config); err != nil {
+			return err
+		}
+	}
+
+	// The previous project state is read from the lockfile.
+	if config.SkipUnchanged && config.LockfilePath == "" {
+		return fmt.Errorf("SKIP_UNCHANGED requires LOCKFILE_PATH to be set")
+	}
+
+	if err := validateAsyncResume(config); err != nil {
+		return err
+	}
+
+	// Cached archives and their JSON sidecars must not be mistaken for translations.
+	if config.CacheDir != "" && !filepath.IsAbs(config.CacheDir) {
+		for _, root := range config.TranslationScope.Paths {
+			if isWithinRoot(root, config.CacheDir) {
+				return fmt.Errorf("DOWNLOAD_CACHE_DIR %q must be located outside of the translation paths", config.CacheDir)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateAsyncResume checks ASYNC_STATE_FILE and ASYNC_PROCESS_ID.
+func validateAsyncResume(config DownloadConfig) error {
+	if config.AsyncStateFile == "" && config.AsyncProcessID == "" {
+		return nil
+	}
+	if !config.AsyncMode && !config.AsyncAuto {
+		return fmt.Errorf("ASYNC_STATE_FILE and ASYNC_PROCESS_ID require ASYNC_MODE")
+	}
+	if config.AsyncProcessID != "" && config.AsyncAuto {
+		return fmt.Errorf("ASYNC_PROCESS_ID requires ASYNC_MODE=true, auto mode may not export asynchronously")
+	}
+	if config.AsyncProcessID != "" && config.Projects != "" {
+		return fmt.Errorf("ASYNC_PROCESS_ID cannot be combined with LOKALISE_PROJECTS, use ASYNC_STATE_FILE instead")
+	}
+
+	// Like the cache, the state file must not be picked up as a translation change.
+	if config.AsyncStateFile != "" && !filepath.IsAbs(config.AsyncStateFile) {
+		for _, root := range config.TranslationScope.Paths {
+			if isWithinRoot(root, config.AsyncStateFile) {
+				return fmt.Errorf("ASYNC_STATE_FILE %q must be located outside of the translation paths", config.AsyncStateFile)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateProjects checks LOKALISE_PROJECTS and the layout of every project.
+func validateProjects(config DownloadConfig) error {
+	projects, err := parseProjects(config.Projects)
+	if err != nil {
+		return err
+	}
+
+	if config.LocalBundle != "" {
+		return fmt.Errorf("LOCAL_BUNDLE cannot be combined with LOKALISE_PROJECTS")
+	}
+	if config.SkipUnchanged {
+		return fmt.Errorf("SKIP_UNCHANGED is not supported with LOKALISE_PROJECTS")
+	}
+
+	for _, p := range projects {
+		if err := validateDownloadLayout(projectConfig(config, p)); err != nil {
+			return fmt.Errorf("project %s: %w", p.ProjectID, err)
+		}
+	}
+
+	return nil
+}
+
+// validateTagFilters makes sure every include/exclude tag renders to a non-empty value.
+// Ref-derived tags (the default "{{ref}}" included) need a non-empty ref.
+// Users can opt-out of include_tags via SKIP_INCLUDE_TAGS=true.
+func validateTagFilters(config DownloadConfig) error {
+	var templates []string
+	if !config.SkipIncludeTags {
+		templates = includeTagTemplatesOf(config)
+	}
+
+	if config.RefName == "" && usesRef(templates) {
 		return fmt.Errorf(
 			"GITHUB_REF_NAME or GITHUB_HEAD_REF is required when include_tags are enabled. " +
 				"Set SKIP_INCLUDE_TAGS=true to disable tag filtering",
 		)
 	}
+	if config.RefName == "" && usesRef(config.ExcludeTags) {
+		return fmt.Errorf("GITHUB_REF_NAME or GITHUB_HEAD_REF is required when EXCLUDE_TAGS use {{ref}}")
+	}
+
+	if _, err := renderTags(templates, config.RefName); err != nil {
+		return fmt.Errorf("invalid INCLUDE_TAGS: %w", err)
+	}
+	if _, err := renderTags(config.ExcludeTags, config.RefName); err != nil {
+		return fmt.Errorf("invalid EXCLUDE_TAGS: %w", err)
+	}
+
+	return nil
+}
+
+// validateInputProblems reports every malformed boolean or numeric input at once.
+// They already fell back to defaults; STRICT_CONFIG turns them into an error.
+func validateInputProblems(config DownloadConfig) error {
+	if len(config.InputProblems) == 0 {
+		return nil
+	}
+
+	if config.StrictConfig {
+		return fmt.Errorf("invalid inputs (STRICT_CONFIG is on): %s", strings.Join(config.InputProblems, "; "))
+	}
+	for _, problem := range config.InputProblems {
+		slog.Warn(problem)
+	}
+
+	return nil
+}
+
+// validateAdditionalParams catches typos and wrong types in additional_params
+// before the export starts. Unknown keys and enum values only warn unless
+// STRICT_PARAMS is on, since the API may know values this schema does not.
+func validateAdditionalParams(config DownloadConfig) error {
+	report, err := checkAdditionalParams(config)
+	if err != nil {
+		return err
+	}
+
+	for _, notice := range report.Notices {
+		slog.Info("Notice: " + notice)
+	}
+
+	if len(report.Errors) > 0 {
+		return fmt.Errorf("invalid additional_params: %s", strings.Join(report.Errors, "; "))
+	}
+
+	if config.StrictParams && len(report.Problems) > 0 {
+		return fmt.Errorf("invalid additional_params (STRICT_PARAMS is on): %s", strings.Join(report.Problems, "; "))
+	}
+	for _, problem := range report.Problems {
+		slog.Warn(problem)
+	}
+
+	return nil
+}
+
+// validateProjectBranches needs a git ref to map and plain project IDs to append the branch to.
+func validateProjectBranches(config DownloadConfig) error {
+	if config.RefName == "" {
+		return fmt.Errorf("GITHUB_REF_NAME or GITHUB_HEAD_REF is required when USE_PROJECT_BRANCHES is enabled")
+	}
+
+	if _, err := parseBranchMapping(config.BranchMapping); err != nil {
+		return err
+	}
+
+	ids := []string{config.ProjectID}
+	if config.Projects != "" {
+		projects, err := parseProjects(config.Projects)
+		if err != nil {
+			return err
+		}
+		ids = ids[:0]
+		for _, p := range projects {
+			ids = append(ids, p.ProjectID)
+		}
+	}
+
+	for _, id := range ids {
+		if strings.Contains(id, ":") {
+			return fmt.Errorf("project ID %q already names a branch; remove it or disable USE_PROJECT_BRANCHES", id)
+		}
+	}
+
+	return nil
+}
+
+// validateDownloadLayout checks DOWNLOAD_DEST and DIRECTORY_PREFIX, and that
+// the exported files land where TRANSLATIONS_PATH expects them.
+func validateDownloadLayout(config DownloadConfig) error {
+	if config.DownloadDest != "" {
+		if _, err := parsers.EnsureRepoRelativePath(config.DownloadDest); err != nil {
+			return fmt.Errorf("invalid DOWNLOAD_DEST: %w", err)
+		}
+	}
+
+	if config.DirectoryPrefix != "" {
+		if err := validateDirectoryPrefix(config.DirectoryPrefix); err != nil {
+			return fmt.Errorf("invalid DIRECTORY_PREFIX: %w", err)
+		}
+	}
+
+	exportRoot := filepath.Clean(downloadDestOf(config))
+	if !config.SkipOriginalFilenames {
+		exportRoot = filepath.Join(exportRoot, staticPrefixDir(directoryPrefixOf(config)))
+	}
+
+	// Either side may be the broader one: "locales" inside an "app" export root
+	// is as fine as TRANSLATIONS_PATH "." covering everything.
+	for _, root := range config.TranslationScope.Paths {
+		if !isWithinRoot(exportRoot, root) && !isWithinRoot(root, exportRoot) {
+			return fmt.Errorf(
+				"translation path %q is outside of the download destination %q; adjust DOWNLOAD_DEST, DIRECTORY_PREFIX or TRANSLATIONS_PATH",
+				root, filepath.ToSlash(exportRoot),
+			)
+		}
+	}
+
+	return nil
+}
+
+// validateDirectoryPrefix accepts Lokalise prefixes such as "/", "/app/" or
+// "/%LANG_ISO%/" but rejects anything that could point outside the destination.
+func validateDirectoryPrefix(prefix string) error {
+	if strings.ContainsAny(prefix, "\\\x00") {
+		return fmt.Errorf("prefix %q contains invalid characters", prefix)
+	}
+
+	for seg := range strings.SplitSeq(prefix, "/") {
+		if seg == ".." {
+			return fmt.Errorf("prefix %q must not contain '..'", prefix)
+		}
+	}
+
+	return nil
+}
+
+// staticPrefixDir returns the leading part of a directory prefix that does not
+// depend on placeholders, e.g. "/app/%LANG_ISO%/" -> "app".
+func staticPrefixDir(prefix string) string {
+	var parts []string
+	for seg := range strings.SplitSeq(prefix, "/") {
+		if strings.Contains(seg, "%") {
+			break
+		}
+		if seg != "" && seg != "." {
+			parts = append(parts, seg)
+		}
+	}
+
+	return filepath.Join(parts...)
+}
+
+// validateLocalBundle makes sure the archive exists before anything is extracted.
+func validateLocalBundle(raw string) error {
+	path, err := localBundlePath(raw)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("cannot read LOCAL_BUNDLE: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("LOCAL_BUNDLE %q is not a regular file", raw)
+	}
+
+	return nil
+}
+
+// validateLockfilePath keeps the lockfile next to the translations it describes,
+// so the commit step can stage both together.
+func validateLockfilePath(config DownloadConfig) error {
+	lockfile, err := parsers.EnsureRepoRelativePath(config.LockfilePath)
+	if err != nil {
+		return fmt.Errorf("invalid LOCKFILE_PATH: %w", err)
+	}
+
+	scope := config.TranslationScope
+	if len(scope.Paths) == 0 {
+		return fmt.Errorf("TRANSLATIONS_PATH is required when LOCKFILE_PATH is set")
+	}
+	if len(scope.FileExts) == 0 {
+		return fmt.Errorf("FILE_EXT or FILE_FORMAT is required when LOCKFILE_PATH is set")
+	}
+
+	inside := false
+	for _, root := range scope.Paths {
+		if filepath.Clean(root) != lockfile && isWithinRoot(root, lockfile) {
+			inside = true
+			break
+		}
+	}
+	if !inside {
+		return fmt.Errorf(
+			"LOCKFILE_PATH %q must be located inside one of the translation paths (%s)",
+			config.LockfilePath, strings.Join(scope.Paths, ", "),
+		)
+	}
+
+	// A lockfile with a translation extension would be picked up as a changed
+	// translation file on every run.
+	if ext, ok := managedExtension(lockfile, scope.FileExts); ok {
+		return fmt.Errorf("LOCKFILE_PATH %q must not use a translation file extension (%s)", config.LockfilePath, ext)
+	}
 
 	return nil
 }
+
+// managedExtension returns the extension of path when it is one of fileExts.
+func managedExtension(path string, fileExts []string) (string, bool) {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	if ext == "" {
+		return "", false
+	}
+	for _, managed := range fileExts {
+		if strings.EqualFold(strings.TrimPrefix(strings.TrimSpace(managed), "."), ext) {
+			return ext, true
+		}
+	}
+	return "", false
+}
+
+// isWithinRoot reports whether path is root itself or lies below it.
+// Both values are expected to be cleaned repo-relative paths.
+func isWithinRoot(root, path string) bool {
+	rel, err := filepath.Rel(filepath.Clean(root), filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+
+	rel = filepath.ToSlash(rel)
+	return rel != ".." && !strings.HasPrefix(rel, "../")
+}
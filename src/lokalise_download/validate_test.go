@@ -1,8 +1,10 @@
-package main
+package lokalisedownload
 
 import (
 	"strings"
 	"testing"
+
+	"github.com/bodrovis/lokalise-actions-common/v2/managedpaths"
 )
 
 func TestValidateDownloadConfig_ReturnsError_OnMissingRequiredFields(t *testing.T) {
@@ -16,30 +18,30 @@ func TestValidateDownloadConfig_ReturnsError_OnMissingRequiredFields(t *testing.
 		{
 			name: "missing project id",
 			config: DownloadConfig{
-				ProjectID:     "",
-				Token:         "t",
-				FileFormat:    "json",
-				GitHubRefName: "ref",
+				ProjectID:  "",
+				Token:      "t",
+				FileFormat: "json",
+				RefName:    "ref",
 			},
 			wantErr: "LOKALISE_PROJECT_ID is required and cannot be empty",
 		},
 		{
 			name: "missing token",
 			config: DownloadConfig{
-				ProjectID:     "p",
-				Token:         "",
-				FileFormat:    "json",
-				GitHubRefName: "ref",
+				ProjectID:  "p",
+				Token:      "",
+				FileFormat: "json",
+				RefName:    "ref",
 			},
 			wantErr: "LOKALISE_API_KEY is required and cannot be empty",
 		},
 		{
 			name: "missing file format",
 			config: DownloadConfig{
-				ProjectID:     "p",
-				Token:         "t",
-				FileFormat:    "",
-				GitHubRefName: "ref",
+				ProjectID:  "p",
+				Token:      "t",
+				FileFormat: "",
+				RefName:    "ref",
 			},
 			wantErr: "FILE_FORMAT environment variable is required",
 		},
@@ -49,7 +51,7 @@ func TestValidateDownloadConfig_ReturnsError_OnMissingRequiredFields(t *testing.
 				ProjectID:       "p",
 				Token:           "t",
 				FileFormat:      "json",
-				GitHubRefName:   "",
+				RefName:         "",
 				SkipIncludeTags: false,
 			},
 			wantErr: "GITHUB_REF_NAME or GITHUB_HEAD_REF is required when include_tags are enabled",
@@ -79,7 +81,7 @@ func TestValidateDownloadConfig_Success_WhenSkipIncludeTagsEnabled(t *testing.T)
 		ProjectID:       "p",
 		Token:           "t",
 		FileFormat:      "json",
-		GitHubRefName:   "",
+		RefName:         "",
 		SkipIncludeTags: true,
 	})
 	if err != nil {
@@ -94,10 +96,247 @@ func TestValidateDownloadConfig_Success_WhenAllRequiredFieldsPresent(t *testing.
 		ProjectID:       "p",
 		Token:           "t",
 		FileFormat:      "json",
-		GitHubRefName:   "feature-branch",
+		RefName:         "feature-branch",
 		SkipIncludeTags: false,
 	})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
 }
+
+func TestValidateDownloadConfig_Lockfile(t *testing.T) {
+	t.Parallel()
+
+	base := func() DownloadConfig {
+		return DownloadConfig{
+			ProjectID:       "p",
+			Token:           "t",
+			FileFormat:      "json",
+			SkipIncludeTags: true,
+			LockfilePath:    "locales/.lokalise.lock",
+			TranslationScope: managedpaths.TranslationScope{
+				Paths:    []string{"locales"},
+				FileExts: []string{"json"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(*DownloadConfig)
+		wantErr string
+	}{
+		{name: "valid", mutate: func(*DownloadConfig) {}},
+		{
+			name:    "outside translation paths",
+			mutate:  func(c *DownloadConfig) { c.LockfilePath = "lokalise.lock" },
+			wantErr: "must be located inside one of the translation paths",
+		},
+		{
+			name:    "same as translation root",
+			mutate:  func(c *DownloadConfig) { c.LockfilePath = "locales" },
+			wantErr: "must be located inside one of the translation paths",
+		},
+		{
+			name:    "escapes repo",
+			mutate:  func(c *DownloadConfig) { c.LockfilePath = "../locales/.lokalise.lock" },
+			wantErr: "invalid LOCKFILE_PATH",
+		},
+		{
+			name:    "missing translation paths",
+			mutate:  func(c *DownloadConfig) { c.TranslationScope.Paths = nil },
+			wantErr: "TRANSLATIONS_PATH is required when LOCKFILE_PATH is set",
+		},
+		{
+			name:    "translation extension",
+			mutate:  func(c *DownloadConfig) { c.LockfilePath = "locales/lokalise.lock.JSON" },
+			wantErr: "must not use a translation file extension",
+		},
+		{
+			name:    "missing file extensions",
+			mutate:  func(c *DownloadConfig) { c.TranslationScope.FileExts = nil },
+			wantErr: "FILE_EXT or FILE_FORMAT is required when LOCKFILE_PATH is set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := base()
+			tt.mutate(&cfg)
+
+			err := validateDownloadConfig(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateDownloadConfig_SkipUnchangedRequiresLockfile(t *testing.T) {
+	t.Parallel()
+
+	err := validateDownloadConfig(DownloadConfig{
+		ProjectID:       "p",
+		Token:           "t",
+		FileFormat:      "json",
+		SkipIncludeTags: true,
+		SkipUnchanged:   true,
+	})
+	if err == nil || !strings.Contains(err.Error(), "SKIP_UNCHANGED requires LOCKFILE_PATH") {
+		t.Fatalf("expected lockfile requirement error, got %v", err)
+	}
+}
+
+func TestValidateDownloadConfig_CacheDirOutsideTranslations(t *testing.T) {
+	t.Parallel()
+
+	cfg := DownloadConfig{
+		ProjectID:        "p",
+		Token:            "t",
+		FileFormat:       "json",
+		SkipIncludeTags:  true,
+		TranslationScope: managedpaths.TranslationScope{Paths: []string{"locales"}},
+	}
+
+	for _, dir := range []string{".cache/lokalise", "/tmp/lokalise"} {
+		cfg.CacheDir = dir
+		if err := validateDownloadConfig(cfg); err != nil {
+			t.Fatalf("cache dir %q: unexpected error: %v", dir, err)
+		}
+	}
+
+	cfg.CacheDir = "locales/.cache"
+	if err := validateDownloadConfig(cfg); err == nil || !strings.Contains(err.Error(), "DOWNLOAD_CACHE_DIR") {
+		t.Fatalf("expected cache dir error, got %v", err)
+	}
+}
+
+func TestValidateDownloadConfig_DownloadLayout(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		dest    string
+		prefix  string
+		skipOF  bool
+		paths   []string
+		wantErr string
+	}{
+		{name: "defaults", paths: []string{"locales"}},
+		{name: "dest contains translations", dest: "packages/web", paths: []string{"packages/web/locales"}},
+		{name: "translations cover dest", dest: "packages/web", paths: []string{"."}},
+		{name: "prefix with placeholder", dest: "web", prefix: "/i18n/%LANG_ISO%/", paths: []string{"web/i18n"}},
+		{name: "prefix ignored without original filenames", dest: "web", prefix: "/i18n/", skipOF: true, paths: []string{"web/locales"}},
+		{name: "translations elsewhere", dest: "packages/web", paths: []string{"locales"}, wantErr: "outside of the download destination"},
+		{name: "prefix moves files away", prefix: "/i18n/", paths: []string{"locales"}, wantErr: "outside of the download destination"},
+		{name: "absolute dest", dest: "/tmp/out", wantErr: "invalid DOWNLOAD_DEST"},
+		{name: "escaping dest", dest: "../out", wantErr: "invalid DOWNLOAD_DEST"},
+		{name: "escaping prefix", prefix: "/../", wantErr: "invalid DIRECTORY_PREFIX"},
+		{name: "backslash prefix", prefix: `\app\`, wantErr: "invalid DIRECTORY_PREFIX"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateDownloadConfig(DownloadConfig{
+				ProjectID:             "p",
+				Token:                 "t",
+				FileFormat:            "json",
+				SkipIncludeTags:       true,
+				SkipOriginalFilenames: tt.skipOF,
+				DownloadDest:          tt.dest,
+				DirectoryPrefix:       tt.prefix,
+				TranslationScope:      managedpaths.TranslationScope{Paths: tt.paths},
+			})
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateDownloadConfig_InputProblems(t *testing.T) {
+	t.Parallel()
+
+	cfg := DownloadConfig{
+		ProjectID:       "p",
+		Token:           "t",
+		FileFormat:      "json",
+		SkipIncludeTags: true,
+		InputProblems: []string{
+			`ASYNC_MODE has incorrect value "yes", expected true or false`,
+			`MAX_RETRIES has incorrect value "x", expected a positive integer (using 3)`,
+		},
+	}
+
+	if err := validateDownloadConfig(cfg); err != nil {
+		t.Fatalf("lenient mode should only warn, got %v", err)
+	}
+
+	cfg.StrictConfig = true
+	err := validateDownloadConfig(cfg)
+	if err == nil {
+		t.Fatal("expected error in strict mode")
+	}
+	for _, name := range []string{"STRICT_CONFIG", "ASYNC_MODE", "MAX_RETRIES"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Fatalf("error should mention %s, got %v", name, err)
+		}
+	}
+}
+
+func TestValidateDownloadConfig_AsyncResume(t *testing.T) {
+	t.Parallel()
+
+	base := DownloadConfig{
+		ProjectID:        "p",
+		Token:            "t",
+		FileFormat:       "json",
+		SkipIncludeTags:  true,
+		AsyncMode:        true,
+		AsyncStateFile:   ".lokalise/async.json",
+		TranslationScope: managedpaths.TranslationScope{Paths: []string{"locales"}},
+	}
+	if err := validateDownloadConfig(base); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*DownloadConfig)
+		want   string
+	}{
+		{"requires async mode", func(c *DownloadConfig) { c.AsyncMode = false }, "require ASYNC_MODE"},
+		{"state file inside translations", func(c *DownloadConfig) { c.AsyncStateFile = "locales/async.json" }, "ASYNC_STATE_FILE"},
+		{"process ID with project list", func(c *DownloadConfig) {
+			c.AsyncProcessID = "pid"
+			c.Projects = "- project_id: p1"
+		}, "ASYNC_PROCESS_ID cannot be combined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			tt.mutate(&cfg)
+			if err := validateDownloadConfig(cfg); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
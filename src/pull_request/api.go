@@ -0,0 +1,123 @@
+package pullrequest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// APIError is a non-2xx response of a forge API.
+type APIError struct {
+	Service string // GitHub, GitLab or Bitbucket
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s API error: HTTP %d", e.Service, e.Status)
+	}
+	return fmt.Sprintf("%s API error: HTTP %d: %s", e.Service, e.Status, e.Message)
+}
+
+// restClient sends JSON requests to one forge API.
+type restClient struct {
+	http    *http.Client
+	service string      // used in errors
+	header  http.Header // authentication and API headers sent with every request
+}
+
+// do sends in as JSON to endpoint and decodes the response into out.
+func (c *restClient) do(ctx context.Context, method, endpoint string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &APIError{Service: c.service, Status: resp.StatusCode, Message: errorMessage(data)}
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// errorMessage extracts the message of an error response. GitHub sends
+// {"message": "..."}, GitLab {"message": ...} or {"error": "..."}, where the
+// message may be a list or an object of field errors, and Bitbucket
+// {"error": {"message": "..."}}.
+func errorMessage(data []byte) string {
+	var resp struct {
+		Message json.RawMessage `json:"message"`
+		Error   json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(data, &resp) != nil {
+		return ""
+	}
+
+	for _, raw := range []json.RawMessage{resp.Message, resp.Error} {
+		if msg := rawMessage(raw); msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
+
+func rawMessage(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return text
+	}
+
+	var nested struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(raw, &nested) == nil && nested.Message != "" {
+		return nested.Message
+	}
+
+	// Lists and field error objects are kept as compact JSON.
+	var compact bytes.Buffer
+	if json.Compact(&compact, raw) == nil {
+		return compact.String()
+	}
+	return ""
+}
@@ -0,0 +1,23 @@
+package pullrequest
+
+import "testing"
+
+func TestErrorMessage(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		`{"message":"Bad credentials"}`:                             "Bad credentials",
+		`{"message":["Another open merge request already exists"]}`: `["Another open merge request already exists"]`,
+		`{"message":{"title":["is too long"]}}`:                     `{"title":["is too long"]}`,
+		`{"error":"insufficient_scope"}`:                            "insufficient_scope",
+		`{"type":"error","error":{"message":"Access denied"}}`:      "Access denied",
+		`oops`: "",
+		`{}`:   "",
+	}
+
+	for body, want := range tests {
+		if got := errorMessage([]byte(body)); got != want {
+			t.Errorf("errorMessage(%s) = %q, want %q", body, got, want)
+		}
+	}
+}
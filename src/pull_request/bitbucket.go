@@ -0,0 +1,185 @@
+package pullrequest
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// BitbucketClient talks to the Bitbucket Cloud REST API (2.0) of one repository.
+type BitbucketClient struct {
+	rest     restClient
+	APIURL   string
+	Repo     string // workspace/repo_slug of the destination repository
+	HeadRepo string // workspace/repo_slug of the fork the branch was pushed to, "" for the destination
+	Branch   string // source branch
+}
+
+// bitbucketPR is the pull request object of the REST API.
+type bitbucketPR struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	Draft bool   `json:"draft"`
+	Links struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+	Reviewers []bitbucketUser `json:"reviewers"`
+}
+
+type bitbucketUser struct {
+	UUID      string `json:"uuid"`
+	AccountID string `json:"account_id"`
+}
+
+func (p bitbucketPR) pullRequest() *PullRequest {
+	return &PullRequest{Number: p.ID, ID: int64(p.ID), HTMLURL: p.Links.HTML.Href, Title: p.Title, Draft: p.Draft}
+}
+
+// NewBitbucketClient returns a client for the destination repository of cfg.
+// The token is a repository or workspace access token, or "username:app_password"
+// for an app password, which Bitbucket only accepts with basic authentication.
+func NewBitbucketClient(cfg *Config) *BitbucketClient {
+	auth := "Bearer " + cfg.Token
+	if strings.Contains(cfg.Token, ":") {
+		auth = "Basic " + base64.StdEncoding.EncodeToString([]byte(cfg.Token))
+	}
+
+	c := &BitbucketClient{
+		rest: restClient{
+			http:    newHTTPClient(),
+			service: "Bitbucket",
+			header:  http.Header{"Accept": {"application/json"}, "Authorization": {auth}},
+		},
+		APIURL: strings.TrimRight(cfg.APIURL, "/"),
+		Repo:   cfg.Repository,
+		Branch: cfg.BranchName,
+	}
+	if !cfg.sameRepo() {
+		c.HeadRepo = cfg.HeadRepository
+	}
+	return c
+}
+
+// DefaultBranch returns the main branch of the repository.
+func (c *BitbucketClient) DefaultBranch(ctx context.Context) (string, error) {
+	var repo struct {
+		MainBranch struct {
+			Name string `json:"name"`
+		} `json:"mainbranch"`
+	}
+	if err := c.rest.do(ctx, http.MethodGet, c.repoPath(""), nil, &repo); err != nil {
+		return "", err
+	}
+	return repo.MainBranch.Name, nil
+}
+
+// FindOpen returns the open pull request from the source branch into base, or nil.
+func (c *BitbucketClient) FindOpen(ctx context.Context, base string) (*PullRequest, error) {
+	filter := fmt.Sprintf(`state = "OPEN" AND source.branch.name = %q AND destination.branch.name = %q`, c.Branch, base)
+	if c.HeadRepo != "" {
+		filter += fmt.Sprintf(` AND source.repository.full_name = %q`, c.HeadRepo)
+	}
+	query := url.Values{"q": {filter}, "pagelen": {"1"}}
+
+	var page struct {
+		Values []bitbucketPR `json:"values"`
+	}
+	if err := c.rest.do(ctx, http.MethodGet, c.repoPath("/pullrequests?"+query.Encode()), nil, &page); err != nil {
+		return nil, err
+	}
+	if len(page.Values) == 0 {
+		return nil, nil
+	}
+	return page.Values[0].pullRequest(), nil
+}
+
+// Create opens a pull request.
+func (c *BitbucketClient) Create(ctx context.Context, base, title, body string, draft bool) (*PullRequest, error) {
+	source := map[string]any{"branch": map[string]any{"name": c.Branch}}
+	if c.HeadRepo != "" {
+		source["repository"] = map[string]any{"full_name": c.HeadRepo}
+	}
+	req := map[string]any{
+		"title":       title,
+		"description": body,
+		"source":      source,
+		"destination": map[string]any{"branch": map[string]any{"name": base}},
+		"draft":       draft,
+	}
+
+	var pr bitbucketPR
+	if err := c.rest.do(ctx, http.MethodPost, c.repoPath("/pullrequests"), req, &pr); err != nil {
+		return nil, err
+	}
+	return pr.pullRequest(), nil
+}
+
+// Update replaces the title and description.
+func (c *BitbucketClient) Update(ctx context.Context, pr *PullRequest, title, body string) error {
+	return c.updatePR(ctx, pr, map[string]any{"title": title, "description": body})
+}
+
+// MarkDraft turns an open pull request into a draft.
+func (c *BitbucketClient) MarkDraft(ctx context.Context, pr *PullRequest) error {
+	return c.updatePR(ctx, pr, map[string]any{"title": pr.Title, "draft": true})
+}
+
+// AddLabels fails: Bitbucket pull requests have no labels.
+func (c *BitbucketClient) AddLabels(context.Context, *PullRequest, []string) error {
+	return fmt.Errorf("labels on Bitbucket: %w", errUnsupported)
+}
+
+// RequestReviewers adds the users to the reviewers. Bitbucket no longer looks users up by
+// name, so each reviewer is an account ID or a {UUID}; teams are rejected.
+func (c *BitbucketClient) RequestReviewers(ctx context.Context, pr *PullRequest, users, teams []string) error {
+	if len(users) > 0 {
+		// The reviewers list is replaced on update, so the current reviewers are sent along.
+		var current bitbucketPR
+		if err := c.rest.do(ctx, http.MethodGet, c.repoPath(fmt.Sprintf("/pullrequests/%d", pr.Number)), nil, &current); err != nil {
+			return err
+		}
+
+		reviewers := make([]map[string]string, 0, len(current.Reviewers)+len(users))
+		for _, r := range current.Reviewers {
+			reviewers = append(reviewers, map[string]string{"uuid": r.UUID})
+		}
+		for _, user := range users {
+			if slices.ContainsFunc(current.Reviewers, func(r bitbucketUser) bool { return r.UUID == user || r.AccountID == user }) {
+				continue
+			}
+			if strings.HasPrefix(user, "{") {
+				reviewers = append(reviewers, map[string]string{"uuid": user})
+			} else {
+				reviewers = append(reviewers, map[string]string{"account_id": user})
+			}
+		}
+		if err := c.updatePR(ctx, pr, map[string]any{"title": pr.Title, "reviewers": reviewers}); err != nil {
+			return err
+		}
+	}
+	if len(teams) > 0 {
+		return fmt.Errorf("team reviewers on Bitbucket: %w", errUnsupported)
+	}
+	return nil
+}
+
+// AddAssignees fails: Bitbucket pull requests have no assignees.
+func (c *BitbucketClient) AddAssignees(context.Context, *PullRequest, []string) error {
+	return fmt.Errorf("assignees on Bitbucket: %w", errUnsupported)
+}
+
+// updatePR sends a partial update. The title is required by the API even when unchanged.
+func (c *BitbucketClient) updatePR(ctx context.Context, pr *PullRequest, req map[string]any) error {
+	return c.rest.do(ctx, http.MethodPut, c.repoPath(fmt.Sprintf("/pullrequests/%d", pr.Number)), req, nil)
+}
+
+func (c *BitbucketClient) repoPath(suffix string) string {
+	workspace, slug, _ := strings.Cut(c.Repo, "/")
+	return c.APIURL + "/repositories/" + url.PathEscape(workspace) + "/" + url.PathEscape(slug) + suffix
+}
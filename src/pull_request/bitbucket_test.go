@@ -0,0 +1,146 @@
+package pullrequest
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lokalise/lokalise-pull-action/src/errkind"
+)
+
+func bitbucketConfig(srv *httptest.Server) *Config {
+	cfg := testConfig(srv)
+	cfg.Provider = providerBitbucket
+	cfg.APIURL = srv.URL + "/2.0"
+	cfg.GraphQLURL = ""
+	cfg.Repository = "team/app"
+	return cfg
+}
+
+func TestRunWith_BitbucketCreatesDraftPullRequest(t *testing.T) {
+	t.Parallel()
+
+	f, srv := newFakeAPI(t, "Authorization", "Bearer tok", map[string]func(http.ResponseWriter, *http.Request){
+		"GET /2.0/repositories/team/app": reply(200, `{"mainbranch":{"name":"develop"}}`),
+		"GET /2.0/repositories/team/app/pullrequests": func(w http.ResponseWriter, r *http.Request) {
+			want := `state = "OPEN" AND source.branch.name = "lok_main_1" AND destination.branch.name = "develop"`
+			if got := r.URL.Query().Get("q"); got != want {
+				t.Errorf("unexpected filter %q", got)
+			}
+			_, _ = io.WriteString(w, `{"values":[]}`)
+		},
+		"POST /2.0/repositories/team/app/pullrequests":  reply(201, `{"id":4,"title":"Lokalise: sync translations","draft":true,"links":{"html":{"href":"https://bitbucket.org/team/app/pull-requests/4"}}}`),
+		"PUT /2.0/repositories/team/app/pullrequests/4": reply(200, `{}`),
+		"GET /2.0/repositories/team/app/pullrequests/4": reply(200, `{"id":4,"reviewers":[{"uuid":"{00000000-0000-4000-8000-000000000007}","account_id":"557058:old"},{"uuid":"{00000000-0000-4000-8000-000000000008}","account_id":"557058:abc"}]}`),
+	})
+
+	cfg := bitbucketConfig(srv)
+	cfg.BaseRef = ""
+	cfg.Draft = true
+	cfg.Labels = []string{"i18n"}
+	cfg.Reviewers = []string{"{b7e5c1d2-0000-4000-8000-000000000001}", "557058:abc"}
+	cfg.Assignees = []string{"jdoe"}
+
+	outputs := map[string]string{}
+	write := func(name, value string) bool { outputs[name] = value; return true }
+
+	if err := runWith(func() (*Config, error) { return cfg, nil }, NewProvider, write); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	create := f.bodies["POST /2.0/repositories/team/app/pullrequests"]
+	wantCreate := map[string]any{
+		"title":       "Lokalise: sync translations",
+		"description": "body",
+		"source":      map[string]any{"branch": map[string]any{"name": "lok_main_1"}},
+		"destination": map[string]any{"branch": map[string]any{"name": "develop"}},
+		"draft":       true,
+	}
+	if !reflect.DeepEqual(create, wantCreate) {
+		t.Fatalf("unexpected create body:\ngot  %v\nwant %v", create, wantCreate)
+	}
+
+	update := f.bodies["PUT /2.0/repositories/team/app/pullrequests/4"]
+	// Current reviewers are kept; 557058:abc is already one of them.
+	wantReviewers := []any{
+		map[string]any{"uuid": "{00000000-0000-4000-8000-000000000007}"},
+		map[string]any{"uuid": "{00000000-0000-4000-8000-000000000008}"},
+		map[string]any{"uuid": "{b7e5c1d2-0000-4000-8000-000000000001}"},
+	}
+	if update["title"] != "Lokalise: sync translations" || !reflect.DeepEqual(update["reviewers"], wantReviewers) {
+		t.Fatalf("unexpected reviewers update: %v", update)
+	}
+
+	// Labels and assignees do not exist on Bitbucket: no request, no failure.
+	if got := strings.Count(strings.Join(f.requests, ","), "PUT "); got != 1 {
+		t.Fatalf("expected only the reviewers update, got %v", f.requests)
+	}
+	if outputs["pr_action"] != "created" || outputs["pr_number"] != "4" || outputs["pr_url"] != "https://bitbucket.org/team/app/pull-requests/4" {
+		t.Fatalf("unexpected outputs: %v", outputs)
+	}
+}
+
+func TestRunWith_BitbucketUpdatesForkPullRequest(t *testing.T) {
+	t.Parallel()
+
+	f, srv := newFakeAPI(t, "Authorization", "Bearer tok", map[string]func(http.ResponseWriter, *http.Request){
+		"GET /2.0/repositories/team/app/pullrequests": func(w http.ResponseWriter, r *http.Request) {
+			if got := r.URL.Query().Get("q"); !strings.HasSuffix(got, `AND source.repository.full_name = "bot/app"`) {
+				t.Errorf("unexpected filter %q", got)
+			}
+			_, _ = io.WriteString(w, `{"values":[{"id":2,"title":"Old","draft":false,"links":{"html":{"href":"https://bitbucket.org/team/app/pull-requests/2"}}}]}`)
+		},
+		"PUT /2.0/repositories/team/app/pullrequests/2": reply(200, `{}`),
+	})
+
+	cfg := bitbucketConfig(srv)
+	cfg.HeadRepository = "bot/app"
+	cfg.Title = "New title"
+	cfg.Draft = true
+
+	if err := runWith(func() (*Config, error) { return cfg, nil }, NewProvider, func(string, string) bool { return true }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The draft update comes last and carries the new title.
+	update := f.bodies["PUT /2.0/repositories/team/app/pullrequests/2"]
+	if update["title"] != "New title" || update["draft"] != true {
+		t.Fatalf("unexpected update body: %v", update)
+	}
+}
+
+func TestBitbucketClient_AppPasswordAndUnsupported(t *testing.T) {
+	t.Parallel()
+
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte("bot:secret"))
+	_, srv := newFakeAPI(t, "Authorization", basic, map[string]func(http.ResponseWriter, *http.Request){
+		"GET /2.0/repositories/team/app": reply(403, `{"type":"error","error":{"message":"Access denied"}}`),
+	})
+
+	cfg := bitbucketConfig(srv)
+	cfg.Token = "bot:secret"
+	client := NewBitbucketClient(cfg)
+	ctx := context.Background()
+
+	_, err := client.DefaultBranch(ctx)
+	if err == nil || err.Error() != "Bitbucket API error: HTTP 403: Access denied" || classifyError(err) != errkind.Auth {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pr := &PullRequest{Number: 1}
+	for _, err := range []error{
+		client.AddLabels(ctx, pr, []string{"i18n"}),
+		client.AddAssignees(ctx, pr, []string{"jdoe"}),
+		client.RequestReviewers(ctx, pr, nil, []string{"translators"}),
+	} {
+		if !errors.Is(err, errUnsupported) {
+			t.Errorf("expected errUnsupported, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package pullrequest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/lokalise/lokalise-pull-action/src/errkind"
+)
+
+// classifyError returns the kind of err: an explicit kind first, then timeouts,
+// then the HTTP status of a forge API error.
+func classifyError(err error) errkind.Kind {
+	if kind := errkind.Of(err); kind != errkind.Unknown {
+		return kind
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return errkind.Timeout
+	}
+
+	if apiErr, ok := errors.AsType[*APIError](err); ok {
+		switch apiErr.Status {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return errkind.Auth
+		case http.StatusTooManyRequests:
+			return errkind.RateLimited
+		default:
+			return errkind.API
+		}
+	}
+
+	return errkind.Unknown
+}
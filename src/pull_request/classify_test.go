@@ -0,0 +1,45 @@
+package pullrequest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lokalise/lokalise-pull-action/src/errkind"
+)
+
+func TestClassifyError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err  error
+		want errkind.Kind
+	}{
+		{errkind.With(errkind.Config, errors.New("missing token")), errkind.Config},
+		{fmt.Errorf("list: %w", &APIError{Status: 403}), errkind.Auth},
+		{&APIError{Status: 429}, errkind.RateLimited},
+		{&APIError{Status: 422}, errkind.API},
+		{fmt.Errorf("create: %w", context.DeadlineExceeded), errkind.Timeout},
+		{errors.New("boom"), errkind.Unknown},
+	}
+
+	for _, tt := range tests {
+		if got := classifyError(tt.err); got != tt.want {
+			t.Errorf("classifyError(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestReportError(t *testing.T) {
+	t.Parallel()
+
+	var name, value string
+	write := func(n, v string) bool { name, value = n, v; return true }
+
+	err := &APIError{Status: 401, Message: "Bad credentials"}
+	code := errkind.Report(err, classifyError(err), write)
+	if code != 3 || name != errkind.Output || value != string(errkind.Auth) {
+		t.Fatalf("unexpected report: code=%d %s=%s", code, name, value)
+	}
+}
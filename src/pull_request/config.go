@@ -0,0 +1,239 @@
+package pullrequest
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/bodrovis/lokalise-actions-common/v2/parsers"
+	"github.com/lokalise/lokalise-pull-action/src/cienv"
+)
+
+const (
+	defaultTitle        = "Lokalise: sync translations"
+	defaultAPIURL       = "https://api.github.com"
+	defaultGraphQLURL   = "https://api.github.com/graphql"
+	defaultGitLabAPIURL = "https://gitlab.com/api/v4"
+	defaultBitbucketURL = "https://api.bitbucket.org/2.0"
+)
+
+// tokenVars names the token variable of each provider.
+var tokenVars = map[string]string{
+	providerGitHub:    "GITHUB_TOKEN",
+	providerGitLab:    "GITLAB_TOKEN",
+	providerBitbucket: "BITBUCKET_TOKEN",
+}
+
+// Config holds the inputs of the pull request step.
+type Config struct {
+	Provider       string // github, gitlab or bitbucket
+	Token          string // API token allowed to open pull/merge requests
+	APIURL         string // REST API root, e.g. https://api.github.com or https://gitlab.example.com/api/v4
+	GraphQLURL     string // GitHub GraphQL endpoint, used to convert a PR to draft
+	Repository     string // base repository: owner/repo, group/subgroup/project on GitLab
+	HeadRepository string // repository the branch was pushed to, when it differs (forks)
+	BranchName     string // head branch created by the commit step
+	BaseRef        string // target branch; synthetic refs are replaced with the default branch
+	Title          string
+	Body           string
+	Draft          bool
+	Labels         []string
+	Reviewers      []string
+	Teams          []string
+	Assignees      []string
+}
+
+// sameRepo reports whether the head branch lives in the base repository.
+func (c *Config) sameRepo() bool {
+	return c.HeadRepository == "" || strings.EqualFold(c.HeadRepository, c.Repository)
+}
+
+// envVarsToConfig reads the step inputs:
+//   - PR_PROVIDER selects github, gitlab or bitbucket; it defaults to the CI
+//     service running the step (see cienv), GitHub when there is none.
+//   - The token of the provider (GITHUB_TOKEN, GITLAB_TOKEN or BITBUCKET_TOKEN),
+//     the repository and BRANCH_NAME are required. The repository and BASE_REF
+//     default to the values of the CI run.
+//   - GITHUB_API_URL, GITLAB_API_URL (then CI_API_V4_URL) and BITBUCKET_API_URL
+//     point at self-hosted instances; GITHUB_GRAPHQL_URL goes with GITHUB_API_URL.
+//   - HEAD_REPOSITORY defaults, on GitHub, to the PR head repository of the
+//     triggering event (GITHUB_EVENT_PATH).
+//   - PR_LABELS, PR_REVIEWERS, PR_TEAMS_REVIEWERS and PR_ASSIGNEES are comma-separated lists.
+func envVarsToConfig() (*Config, error) {
+	ci := cienv.Detect()
+
+	provider, err := resolveProvider(ci)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{
+		Provider:       provider,
+		Token:          strings.TrimSpace(os.Getenv(tokenVars[provider])),
+		Repository:     ci.Repository,
+		HeadRepository: strings.TrimSpace(os.Getenv("HEAD_REPOSITORY")),
+		BranchName:     strings.TrimSpace(os.Getenv("BRANCH_NAME")),
+		BaseRef:        strings.TrimPrefix(envOr("BASE_REF", firstNonEmpty(ci.BaseRef, ci.RefName)), "refs/heads/"),
+		Title:          envOr("PR_TITLE", defaultTitle),
+		Body:           os.Getenv("PR_BODY"),
+		Labels:         parseCommaList(os.Getenv("PR_LABELS")),
+		Reviewers:      parseCommaList(os.Getenv("PR_REVIEWERS")),
+		Teams:          parseCommaList(os.Getenv("PR_TEAMS_REVIEWERS")),
+		Assignees:      parseCommaList(os.Getenv("PR_ASSIGNEES")),
+	}
+
+	switch provider {
+	case providerGitHub:
+		cfg.APIURL = envOr("GITHUB_API_URL", defaultAPIURL)
+		cfg.GraphQLURL = envOr("GITHUB_GRAPHQL_URL", defaultGraphQLURL)
+		if cfg.HeadRepository == "" {
+			cfg.HeadRepository = eventHeadRepository(os.Getenv("GITHUB_EVENT_PATH"))
+		}
+	case providerGitLab:
+		cfg.APIURL = envOr("GITLAB_API_URL", envOr("CI_API_V4_URL", defaultGitLabAPIURL))
+	case providerBitbucket:
+		cfg.APIURL = envOr("BITBUCKET_API_URL", defaultBitbucketURL)
+	}
+
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("environment variable %s is required", tokenVars[provider])
+	}
+	if cfg.BranchName == "" {
+		return nil, fmt.Errorf("environment variable BRANCH_NAME is required")
+	}
+	if err := validateRepository(provider, "GITHUB_REPOSITORY", cfg.Repository); err != nil {
+		return nil, err
+	}
+	if cfg.HeadRepository != "" {
+		if err := validateRepository(provider, "HEAD_REPOSITORY", cfg.HeadRepository); err != nil {
+			return nil, err
+		}
+	}
+
+	// PR_DRAFT is optional: a malformed value keeps the PR ready for review.
+	cfg.Draft, err = parsers.ParseBoolEnv("PR_DRAFT")
+	if err != nil {
+		slog.Warn("PR_DRAFT has incorrect value, expected true or false (using false)", "value", os.Getenv("PR_DRAFT"))
+	}
+
+	return cfg, nil
+}
+
+// resolveProvider returns PR_PROVIDER, or the forge of the CI service.
+func resolveProvider(ci cienv.Env) (string, error) {
+	raw := strings.ToLower(strings.TrimSpace(os.Getenv("PR_PROVIDER")))
+	switch raw {
+	case providerGitHub, providerGitLab, providerBitbucket:
+		return raw, nil
+	case "":
+	default:
+		return "", fmt.Errorf("environment variable PR_PROVIDER has incorrect value %q, expected github, gitlab or bitbucket", raw)
+	}
+
+	switch ci.Provider {
+	case cienv.GitLabCI:
+		return providerGitLab, nil
+	case cienv.BitbucketPipelines:
+		return providerBitbucket, nil
+	default:
+		return providerGitHub, nil
+	}
+}
+
+// TokenVar returns the token variable of the provider the step would use, so
+// callers can tell in advance whether it can run.
+func TokenVar() string {
+	provider, err := resolveProvider(cienv.Detect())
+	if err != nil {
+		return tokenVars[providerGitHub]
+	}
+	return tokenVars[provider]
+}
+
+func envOr(key, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// validateRepository checks a repository path: owner/repo on GitHub and
+// Bitbucket (workspace/repo_slug), GitLab also allows nested groups.
+func validateRepository(provider, key, raw string) error {
+	parts := strings.Split(raw, "/")
+	valid := len(parts) == 2 || (provider == providerGitLab && len(parts) > 2)
+	for _, part := range parts {
+		valid = valid && strings.TrimSpace(part) != ""
+	}
+
+	if !valid {
+		if provider == providerGitLab {
+			return fmt.Errorf("environment variable %s must be a project path like group/project, got %q", key, raw)
+		}
+		return fmt.Errorf("environment variable %s must be owner/repo, got %q", key, raw)
+	}
+	return nil
+}
+
+func eventHeadRepository(eventPath string) string {
+	if eventPath == "" {
+		return ""
+	}
+
+	data, err := os.ReadFile(eventPath)
+	if err != nil {
+		slog.Warn("cannot read the event payload", "path", eventPath, "error", err)
+		return ""
+	}
+
+	var event struct {
+		PullRequest struct {
+			Head struct {
+				Repo struct {
+					FullName string `json:"full_name"`
+				} `json:"repo"`
+			} `json:"head"`
+		} `json:"pull_request"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		slog.Warn("cannot parse the event payload", "path", eventPath, "error", err)
+		return ""
+	}
+
+	return event.PullRequest.Head.Repo.FullName
+}
+
+func parseCommaList(value string) []string {
+	var res []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// isSyntheticRef reports refs that cannot be a PR base: empty values and the
+// merge/head refs GitHub creates for pull requests.
+func isSyntheticRef(ref string) bool {
+	value := strings.ToLower(strings.TrimSpace(ref))
+
+	if value == "" || value == "merge" || value == "head" ||
+		strings.HasPrefix(value, "refs/pull/") || strings.HasPrefix(value, "pull/") ||
+		strings.HasSuffix(value, "/merge") || strings.HasSuffix(value, "/head") {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,242 @@
+package pullrequest
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func setPREnv(t *testing.T, env map[string]string) {
+	t.Helper()
+
+	for _, key := range []string{
+		"GITHUB_TOKEN", "GITHUB_REPOSITORY", "GITHUB_API_URL", "GITHUB_GRAPHQL_URL", "GITHUB_EVENT_PATH",
+		"HEAD_REPOSITORY", "BRANCH_NAME", "BASE_REF", "PR_TITLE", "PR_BODY", "PR_DRAFT",
+		"PR_LABELS", "PR_REVIEWERS", "PR_TEAMS_REVIEWERS", "PR_ASSIGNEES",
+		"GITHUB_REF_NAME", "GITHUB_BASE_REF", "PR_PROVIDER",
+		"GITLAB_TOKEN", "GITLAB_API_URL", "CI_API_V4_URL", "BITBUCKET_TOKEN", "BITBUCKET_API_URL",
+	} {
+		t.Setenv(key, env[key])
+	}
+	t.Setenv("CI_PROVIDER", "generic")
+}
+
+func TestEnvVarsToConfig(t *testing.T) {
+	event := filepath.Join(t.TempDir(), "event.json")
+	if err := os.WriteFile(event, []byte(`{"pull_request":{"head":{"repo":{"full_name":"bot/app"}}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	setPREnv(t, map[string]string{
+		"GITHUB_TOKEN":       "tok",
+		"GITHUB_REPOSITORY":  "acme/app",
+		"GITHUB_EVENT_PATH":  event,
+		"BRANCH_NAME":        " lok_main_1 ",
+		"BASE_REF":           "refs/heads/main",
+		"PR_DRAFT":           "true",
+		"PR_LABELS":          "i18n, ,bot",
+		"PR_TEAMS_REVIEWERS": "translators",
+	})
+
+	cfg, err := envVarsToConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := &Config{
+		Provider:       providerGitHub,
+		Token:          "tok",
+		APIURL:         defaultAPIURL,
+		GraphQLURL:     defaultGraphQLURL,
+		Repository:     "acme/app",
+		HeadRepository: "bot/app",
+		BranchName:     "lok_main_1",
+		BaseRef:        "main",
+		Title:          defaultTitle,
+		Draft:          true,
+		Labels:         []string{"i18n", "bot"},
+		Teams:          []string{"translators"},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Fatalf("config mismatch:\ngot  %+v\nwant %+v", cfg, want)
+	}
+	if cfg.sameRepo() {
+		t.Fatal("expected a fork head")
+	}
+}
+
+func TestEnvVarsToConfig_BaseRefFromCI(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{name: "pull request target", env: map[string]string{"GITHUB_BASE_REF": "develop", "GITHUB_REF_NAME": "42/merge"}, want: "develop"},
+		{name: "built ref", env: map[string]string{"GITHUB_REF_NAME": "release"}, want: "release"},
+		{name: "explicit BASE_REF wins", env: map[string]string{"BASE_REF": "main", "GITHUB_BASE_REF": "develop"}, want: "main"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := map[string]string{"GITHUB_TOKEN": "tok", "GITHUB_REPOSITORY": "acme/app", "BRANCH_NAME": "lok_1"}
+			maps.Copy(env, tt.env)
+			setPREnv(t, env)
+
+			cfg, err := envVarsToConfig()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.BaseRef != tt.want {
+				t.Fatalf("BaseRef = %q, want %q", cfg.BaseRef, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvVarsToConfig_HeadRepositoryOverridesEvent(t *testing.T) {
+	setPREnv(t, map[string]string{
+		"GITHUB_TOKEN":      "tok",
+		"GITHUB_REPOSITORY": "acme/app",
+		"HEAD_REPOSITORY":   "ACME/app",
+		"BRANCH_NAME":       "lok",
+		"PR_DRAFT":          "maybe",
+	})
+
+	cfg, err := envVarsToConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.sameRepo() || cfg.Draft {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+}
+
+func TestEnvVarsToConfig_GitLab(t *testing.T) {
+	setPREnv(t, map[string]string{
+		"BRANCH_NAME":  "lok_main_1",
+		"PR_REVIEWERS": "jdoe",
+	})
+	t.Setenv("CI_PROVIDER", "gitlab")
+	t.Setenv("GITLAB_TOKEN", "glpat")
+	t.Setenv("CI_API_V4_URL", "https://gitlab.example.com/api/v4")
+	t.Setenv("CI_PROJECT_PATH", "acme/web/app")
+	t.Setenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME", "develop")
+
+	cfg, err := envVarsToConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := &Config{
+		Provider:   providerGitLab,
+		Token:      "glpat",
+		APIURL:     "https://gitlab.example.com/api/v4",
+		Repository: "acme/web/app",
+		BranchName: "lok_main_1",
+		BaseRef:    "develop",
+		Title:      defaultTitle,
+		Reviewers:  []string{"jdoe"},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Fatalf("config mismatch:\ngot  %+v\nwant %+v", cfg, want)
+	}
+}
+
+func TestEnvVarsToConfig_Bitbucket(t *testing.T) {
+	setPREnv(t, map[string]string{
+		"PR_PROVIDER":       "Bitbucket",
+		"BITBUCKET_TOKEN":   "bbtok",
+		"GITHUB_REPOSITORY": "team/app",
+		"BRANCH_NAME":       "lok_main_1",
+		"BASE_REF":          "main",
+	})
+
+	cfg, err := envVarsToConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Provider != providerBitbucket || cfg.APIURL != defaultBitbucketURL || cfg.Token != "bbtok" || cfg.GraphQLURL != "" {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+}
+
+func TestTokenVar(t *testing.T) {
+	setPREnv(t, nil)
+	if got := TokenVar(); got != "GITHUB_TOKEN" {
+		t.Fatalf("TokenVar() = %q outside of a CI service", got)
+	}
+
+	t.Setenv("CI_PROVIDER", "bitbucket")
+	if got := TokenVar(); got != "BITBUCKET_TOKEN" {
+		t.Fatalf("TokenVar() = %q on Bitbucket Pipelines", got)
+	}
+
+	t.Setenv("PR_PROVIDER", "gitlab")
+	if got := TokenVar(); got != "GITLAB_TOKEN" {
+		t.Fatalf("TokenVar() = %q with PR_PROVIDER=gitlab", got)
+	}
+}
+
+func TestEnvVarsToConfig_Errors(t *testing.T) {
+	tests := map[string]struct {
+		env  map[string]string
+		want string
+	}{
+		"missing token": {
+			env:  map[string]string{"GITHUB_REPOSITORY": "acme/app", "BRANCH_NAME": "lok"},
+			want: "GITHUB_TOKEN is required",
+		},
+		"missing branch": {
+			env:  map[string]string{"GITHUB_TOKEN": "tok", "GITHUB_REPOSITORY": "acme/app"},
+			want: "BRANCH_NAME is required",
+		},
+		"malformed repository": {
+			env:  map[string]string{"GITHUB_TOKEN": "tok", "GITHUB_REPOSITORY": "acme", "BRANCH_NAME": "lok"},
+			want: "GITHUB_REPOSITORY must be owner/repo",
+		},
+		"nested repository outside GitLab": {
+			env:  map[string]string{"GITHUB_TOKEN": "tok", "GITHUB_REPOSITORY": "acme/web/app", "BRANCH_NAME": "lok"},
+			want: "GITHUB_REPOSITORY must be owner/repo",
+		},
+		"missing GitLab token": {
+			env:  map[string]string{"PR_PROVIDER": "gitlab", "GITHUB_TOKEN": "tok", "GITHUB_REPOSITORY": "acme/app", "BRANCH_NAME": "lok"},
+			want: "GITLAB_TOKEN is required",
+		},
+		"unknown provider": {
+			env:  map[string]string{"PR_PROVIDER": "gitea", "GITHUB_TOKEN": "tok", "GITHUB_REPOSITORY": "acme/app", "BRANCH_NAME": "lok"},
+			want: `PR_PROVIDER has incorrect value "gitea"`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			setPREnv(t, tt.env)
+
+			_, err := envVarsToConfig()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestIsSyntheticRef(t *testing.T) {
+	t.Parallel()
+
+	for ref, want := range map[string]bool{
+		"":                  true,
+		"merge":             true,
+		"12/merge":          true,
+		"refs/pull/12/head": true,
+		"pull/3/merge":      true,
+		"main":              false,
+		"release/2.0":       false,
+	} {
+		if got := isSyntheticRef(ref); got != want {
+			t.Errorf("isSyntheticRef(%q) = %v, want %v", ref, got, want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package pullrequest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GitHubClient talks to the REST and GraphQL APIs of one repository.
+type GitHubClient struct {
+	rest       restClient
+	APIURL     string
+	GraphQLURL string
+	Owner      string
+	Repo       string
+	Branch     string // head branch
+	HeadOwner  string // owner of the fork the branch was pushed to, "" for the base repository
+}
+
+// githubPR is the pull request object of the REST API.
+type githubPR struct {
+	Number  int    `json:"number"`
+	ID      int64  `json:"id"`
+	NodeID  string `json:"node_id"`
+	HTMLURL string `json:"html_url"`
+	Title   string `json:"title"`
+	Draft   bool   `json:"draft"`
+}
+
+func (p githubPR) pullRequest() *PullRequest {
+	return &PullRequest{Number: p.Number, ID: p.ID, NodeID: p.NodeID, HTMLURL: p.HTMLURL, Title: p.Title, Draft: p.Draft}
+}
+
+// NewGitHubClient returns a client for the base repository of cfg.
+func NewGitHubClient(cfg *Config) *GitHubClient {
+	owner, repo, _ := strings.Cut(cfg.Repository, "/")
+
+	c := &GitHubClient{
+		rest: restClient{
+			http:    newHTTPClient(),
+			service: "GitHub",
+			header: http.Header{
+				"Accept":               {"application/vnd.github+json"},
+				"Authorization":        {"Bearer " + cfg.Token},
+				"X-Github-Api-Version": {"2022-11-28"},
+			},
+		},
+		APIURL:     strings.TrimRight(cfg.APIURL, "/"),
+		GraphQLURL: cfg.GraphQLURL,
+		Owner:      owner,
+		Repo:       repo,
+		Branch:     cfg.BranchName,
+	}
+	if !cfg.sameRepo() {
+		c.HeadOwner, _, _ = strings.Cut(cfg.HeadRepository, "/")
+	}
+	return c
+}
+
+// DefaultBranch returns the default branch of the repository.
+func (c *GitHubClient) DefaultBranch(ctx context.Context) (string, error) {
+	var repo struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := c.rest.do(ctx, http.MethodGet, c.repoPath(""), nil, &repo); err != nil {
+		return "", err
+	}
+	return repo.DefaultBranch, nil
+}
+
+// FindOpen returns the open pull request from the head branch into base, or nil.
+// GitHub filters by "owner:branch", so forks are matched too.
+func (c *GitHubClient) FindOpen(ctx context.Context, base string) (*PullRequest, error) {
+	head := c.Owner + ":" + c.Branch
+	if c.HeadOwner != "" {
+		head = c.HeadOwner + ":" + c.Branch
+	}
+	query := url.Values{"state": {"open"}, "head": {head}, "base": {base}, "per_page": {"1"}}
+
+	var prs []githubPR
+	if err := c.rest.do(ctx, http.MethodGet, c.repoPath("/pulls?"+query.Encode()), nil, &prs); err != nil {
+		return nil, err
+	}
+	if len(prs) == 0 {
+		return nil, nil
+	}
+	return prs[0].pullRequest(), nil
+}
+
+// Create opens a pull request.
+func (c *GitHubClient) Create(ctx context.Context, base, title, body string, draft bool) (*PullRequest, error) {
+	head := c.Branch
+	if c.HeadOwner != "" {
+		head = c.HeadOwner + ":" + c.Branch
+	}
+	req := map[string]any{
+		"title":                 title,
+		"head":                  head,
+		"base":                  base,
+		"body":                  body,
+		"draft":                 draft,
+		"maintainer_can_modify": true,
+	}
+
+	var pr githubPR
+	if err := c.rest.do(ctx, http.MethodPost, c.repoPath("/pulls"), req, &pr); err != nil {
+		return nil, err
+	}
+	return pr.pullRequest(), nil
+}
+
+// Update replaces the title and body of a pull request.
+func (c *GitHubClient) Update(ctx context.Context, pr *PullRequest, title, body string) error {
+	req := map[string]any{"title": title, "body": body}
+	return c.rest.do(ctx, http.MethodPatch, c.repoPath(fmt.Sprintf("/pulls/%d", pr.Number)), req, nil)
+}
+
+// MarkDraft turns an open pull request into a draft. Only GraphQL supports it.
+func (c *GitHubClient) MarkDraft(ctx context.Context, pr *PullRequest) error {
+	req := map[string]any{
+		"query": `mutation($pullRequestId: ID!) {
+  convertPullRequestToDraft(input: { pullRequestId: $pullRequestId }) { pullRequest { id isDraft } }
+}`,
+		"variables": map[string]any{"pullRequestId": pr.NodeID},
+	}
+
+	var resp struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := c.rest.do(ctx, http.MethodPost, c.GraphQLURL, req, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("GraphQL error: %s", resp.Errors[0].Message)
+	}
+	return nil
+}
+
+// AddLabels adds labels to a pull request.
+func (c *GitHubClient) AddLabels(ctx context.Context, pr *PullRequest, labels []string) error {
+	return c.rest.do(ctx, http.MethodPost, c.repoPath(fmt.Sprintf("/issues/%d/labels", pr.Number)), map[string]any{"labels": labels}, nil)
+}
+
+// RequestReviewers requests reviews from users and teams.
+func (c *GitHubClient) RequestReviewers(ctx context.Context, pr *PullRequest, users, teams []string) error {
+	req := map[string]any{"reviewers": nonNil(users), "team_reviewers": nonNil(teams)}
+	return c.rest.do(ctx, http.MethodPost, c.repoPath(fmt.Sprintf("/pulls/%d/requested_reviewers", pr.Number)), req, nil)
+}
+
+// AddAssignees assigns users to a pull request.
+func (c *GitHubClient) AddAssignees(ctx context.Context, pr *PullRequest, users []string) error {
+	return c.rest.do(ctx, http.MethodPost, c.repoPath(fmt.Sprintf("/issues/%d/assignees", pr.Number)), map[string]any{"assignees": users}, nil)
+}
+
+func (c *GitHubClient) repoPath(suffix string) string {
+	return c.APIURL + "/repos/" + url.PathEscape(c.Owner) + "/" + url.PathEscape(c.Repo) + suffix
+}
+
+// nonNil keeps empty lists as [] in JSON: GitHub rejects null reviewer lists.
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
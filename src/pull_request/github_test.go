@@ -0,0 +1,42 @@
+package pullrequest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestGitHubClient_APIError(t *testing.T) {
+	t.Parallel()
+
+	_, srv := newFakeGitHub(t, map[string]func(http.ResponseWriter, *http.Request){
+		"GET /repos/acme/app":                  reply(404, `{"message":"Not Found"}`),
+		"POST /repos/acme/app/issues/1/labels": reply(500, `oops`),
+	})
+	client := NewGitHubClient(testConfig(srv))
+
+	_, err := client.DefaultBranch(context.Background())
+	apiErr, ok := errors.AsType[*APIError](err)
+	if !ok || apiErr.Status != 404 || err.Error() != "GitHub API error: HTTP 404: Not Found" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = client.AddLabels(context.Background(), &PullRequest{Number: 1}, []string{"i18n"})
+	if err == nil || err.Error() != "GitHub API error: HTTP 500" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestGitHubClient_GraphQLError(t *testing.T) {
+	t.Parallel()
+
+	_, srv := newFakeGitHub(t, map[string]func(http.ResponseWriter, *http.Request){
+		"POST /graphql": reply(200, `{"errors":[{"message":"Pull request is already a draft"}]}`),
+	})
+
+	err := NewGitHubClient(testConfig(srv)).MarkDraft(context.Background(), &PullRequest{NodeID: "PR_1"})
+	if err == nil || err.Error() != "GraphQL error: Pull request is already a draft" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
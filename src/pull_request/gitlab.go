@@ -0,0 +1,233 @@
+package pullrequest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// draftPrefix marks a GitLab merge request as a draft; GitLab has no separate flag to set.
+const draftPrefix = "Draft: "
+
+// draftTitlePattern matches the prefixes GitLab treats as draft markers.
+var draftTitlePattern = regexp.MustCompile(`(?i)^\s*(\[draft\]|\(draft\)|draft:|draft\s-|\[wip\]|wip:)\s*`)
+
+// GitLabClient talks to the REST API (v4) of a GitLab instance, gitlab.com or self-hosted.
+type GitLabClient struct {
+	rest        restClient
+	APIURL      string
+	Project     string // path of the target project, e.g. group/subgroup/app
+	HeadProject string // path of the fork the branch was pushed to, "" for the target project
+	Branch      string // source branch
+}
+
+// gitlabMR is the merge request object of the REST API.
+type gitlabMR struct {
+	IID       int          `json:"iid"`
+	ID        int64        `json:"id"`
+	WebURL    string       `json:"web_url"`
+	Title     string       `json:"title"`
+	Draft     bool         `json:"draft"`
+	Reviewers []gitlabUser `json:"reviewers"`
+	Assignees []gitlabUser `json:"assignees"`
+}
+
+type gitlabUser struct {
+	ID int64 `json:"id"`
+}
+
+func (m gitlabMR) pullRequest() *PullRequest {
+	return &PullRequest{Number: m.IID, ID: m.ID, HTMLURL: m.WebURL, Title: m.Title, Draft: m.Draft}
+}
+
+// NewGitLabClient returns a client for the target project of cfg. The token
+// is a personal, project or group access token with the api scope.
+func NewGitLabClient(cfg *Config) *GitLabClient {
+	c := &GitLabClient{
+		rest: restClient{
+			http:    newHTTPClient(),
+			service: "GitLab",
+			header:  http.Header{"Private-Token": {cfg.Token}},
+		},
+		APIURL:  strings.TrimRight(cfg.APIURL, "/"),
+		Project: cfg.Repository,
+		Branch:  cfg.BranchName,
+	}
+	if !cfg.sameRepo() {
+		c.HeadProject = cfg.HeadRepository
+	}
+	return c
+}
+
+// DefaultBranch returns the default branch of the target project.
+func (c *GitLabClient) DefaultBranch(ctx context.Context) (string, error) {
+	var project struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := c.rest.do(ctx, http.MethodGet, c.projectPath(c.Project, ""), nil, &project); err != nil {
+		return "", err
+	}
+	return project.DefaultBranch, nil
+}
+
+// FindOpen returns the open merge request from the source branch into base, or
+// nil. Merge requests from forks are listed in the target project as well.
+func (c *GitLabClient) FindOpen(ctx context.Context, base string) (*PullRequest, error) {
+	query := url.Values{"state": {"opened"}, "source_branch": {c.Branch}, "target_branch": {base}, "per_page": {"1"}}
+
+	var mrs []gitlabMR
+	if err := c.rest.do(ctx, http.MethodGet, c.projectPath(c.Project, "/merge_requests?"+query.Encode()), nil, &mrs); err != nil {
+		return nil, err
+	}
+	if len(mrs) == 0 {
+		return nil, nil
+	}
+	return mrs[0].pullRequest(), nil
+}
+
+// Create opens a merge request. A merge request from a fork is created in the
+// fork and points at the numeric ID of the target project.
+func (c *GitLabClient) Create(ctx context.Context, base, title, body string, draft bool) (*PullRequest, error) {
+	if draft {
+		title = draftTitle(title)
+	}
+	req := map[string]any{
+		"source_branch": c.Branch,
+		"target_branch": base,
+		"title":         title,
+		"description":   body,
+	}
+
+	project := c.Project
+	if c.HeadProject != "" {
+		var target struct {
+			ID int64 `json:"id"`
+		}
+		if err := c.rest.do(ctx, http.MethodGet, c.projectPath(c.Project, ""), nil, &target); err != nil {
+			return nil, fmt.Errorf("resolve target project: %w", err)
+		}
+		req["target_project_id"] = target.ID
+		project = c.HeadProject
+	}
+
+	var mr gitlabMR
+	if err := c.rest.do(ctx, http.MethodPost, c.projectPath(project, "/merge_requests"), req, &mr); err != nil {
+		return nil, err
+	}
+	return mr.pullRequest(), nil
+}
+
+// Update replaces the title and description. The draft prefix is kept, since
+// a title without it would mark the merge request as ready.
+func (c *GitLabClient) Update(ctx context.Context, pr *PullRequest, title, body string) error {
+	if pr.Draft {
+		title = draftTitle(title)
+	}
+	return c.updateMR(ctx, pr, map[string]any{"title": title, "description": body})
+}
+
+// MarkDraft prefixes the title with "Draft: ".
+func (c *GitLabClient) MarkDraft(ctx context.Context, pr *PullRequest) error {
+	return c.updateMR(ctx, pr, map[string]any{"title": draftTitle(pr.Title)})
+}
+
+// AddLabels adds labels, creating the ones the project does not have yet.
+func (c *GitLabClient) AddLabels(ctx context.Context, pr *PullRequest, labels []string) error {
+	return c.updateMR(ctx, pr, map[string]any{"add_labels": strings.Join(labels, ",")})
+}
+
+// RequestReviewers adds the users to the reviewers of the merge request.
+// GitLab has no group reviewers, so teams are rejected; the users are still requested.
+func (c *GitLabClient) RequestReviewers(ctx context.Context, pr *PullRequest, users, teams []string) error {
+	if len(users) > 0 {
+		ids, err := c.userIDs(ctx, users)
+		if err != nil {
+			return err
+		}
+		mr, err := c.getMR(ctx, pr)
+		if err != nil {
+			return err
+		}
+		if err := c.updateMR(ctx, pr, map[string]any{"reviewer_ids": unionIDs(mr.Reviewers, ids)}); err != nil {
+			return err
+		}
+	}
+	if len(teams) > 0 {
+		return fmt.Errorf("team reviewers on GitLab: %w", errUnsupported)
+	}
+	return nil
+}
+
+// AddAssignees adds the users to the assignees of the merge request.
+func (c *GitLabClient) AddAssignees(ctx context.Context, pr *PullRequest, users []string) error {
+	ids, err := c.userIDs(ctx, users)
+	if err != nil {
+		return err
+	}
+	mr, err := c.getMR(ctx, pr)
+	if err != nil {
+		return err
+	}
+	return c.updateMR(ctx, pr, map[string]any{"assignee_ids": unionIDs(mr.Assignees, ids)})
+}
+
+// getMR reads the merge request. reviewer_ids and assignee_ids replace the
+// whole list on update, so the current users are read first and sent along.
+func (c *GitLabClient) getMR(ctx context.Context, pr *PullRequest) (gitlabMR, error) {
+	var mr gitlabMR
+	err := c.rest.do(ctx, http.MethodGet, c.projectPath(c.Project, fmt.Sprintf("/merge_requests/%d", pr.Number)), nil, &mr)
+	return mr, err
+}
+
+func (c *GitLabClient) updateMR(ctx context.Context, pr *PullRequest, req map[string]any) error {
+	return c.rest.do(ctx, http.MethodPut, c.projectPath(c.Project, fmt.Sprintf("/merge_requests/%d", pr.Number)), req, nil)
+}
+
+// userIDs resolves usernames to the numeric IDs the merge request API expects.
+func (c *GitLabClient) userIDs(ctx context.Context, usernames []string) ([]int64, error) {
+	ids := make([]int64, 0, len(usernames))
+	for _, name := range usernames {
+		var users []struct {
+			ID int64 `json:"id"`
+		}
+		endpoint := c.APIURL + "/users?" + url.Values{"username": {strings.TrimPrefix(name, "@")}}.Encode()
+		if err := c.rest.do(ctx, http.MethodGet, endpoint, nil, &users); err != nil {
+			return nil, fmt.Errorf("look up user %s: %w", name, err)
+		}
+		if len(users) == 0 {
+			return nil, fmt.Errorf("user %s not found", name)
+		}
+		ids = append(ids, users[0].ID)
+	}
+	return ids, nil
+}
+
+// unionIDs returns the IDs of current followed by the new ids, without duplicates.
+func unionIDs(current []gitlabUser, ids []int64) []int64 {
+	out := make([]int64, 0, len(current)+len(ids))
+	for _, u := range current {
+		if !slices.Contains(out, u.ID) {
+			out = append(out, u.ID)
+		}
+	}
+	for _, id := range ids {
+		if !slices.Contains(out, id) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// projectPath returns the API URL of a project; the path is URL-encoded into one segment.
+func (c *GitLabClient) projectPath(project, suffix string) string {
+	return c.APIURL + "/projects/" + url.PathEscape(project) + suffix
+}
+
+// draftTitle returns title with exactly one "Draft: " prefix.
+func draftTitle(title string) string {
+	return draftPrefix + draftTitlePattern.ReplaceAllString(title, "")
+}
@@ -0,0 +1,186 @@
+package pullrequest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newFakeGitLab(t *testing.T, handlers map[string]func(http.ResponseWriter, *http.Request)) (*fakeAPI, *httptest.Server) {
+	t.Helper()
+	return newFakeAPI(t, "Private-Token", "tok", handlers)
+}
+
+func gitLabConfig(srv *httptest.Server) *Config {
+	cfg := testConfig(srv)
+	cfg.Provider = providerGitLab
+	cfg.APIURL = srv.URL + "/api/v4"
+	cfg.GraphQLURL = ""
+	cfg.Repository = "acme/web/app"
+	return cfg
+}
+
+func TestRunWith_GitLabCreatesDraftMergeRequest(t *testing.T) {
+	t.Parallel()
+
+	f, srv := newFakeGitLab(t, map[string]func(http.ResponseWriter, *http.Request){
+		"GET /api/v4/projects/acme%2Fweb%2Fapp": reply(200, `{"id":42,"default_branch":"develop"}`),
+		"GET /api/v4/projects/acme%2Fweb%2Fapp/merge_requests": func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			if q.Get("state") != "opened" || q.Get("source_branch") != "lok_main_1" || q.Get("target_branch") != "develop" {
+				t.Errorf("unexpected list query: %s", r.URL.RawQuery)
+			}
+			_, _ = io.WriteString(w, `[]`)
+		},
+		"POST /api/v4/projects/acme%2Fweb%2Fapp/merge_requests":  reply(201, `{"iid":5,"id":500,"web_url":"https://gitlab.example.com/acme/web/app/-/merge_requests/5","title":"Draft: Lokalise: sync translations","draft":true}`),
+		"PUT /api/v4/projects/acme%2Fweb%2Fapp/merge_requests/5": reply(200, `{}`),
+		"GET /api/v4/projects/acme%2Fweb%2Fapp/merge_requests/5": reply(200, `{"iid":5,"reviewers":[{"id":7},{"id":11}]}`),
+		"GET /api/v4/users": func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("username") {
+			case "jdoe":
+				_, _ = io.WriteString(w, `[{"id":11}]`)
+			default:
+				_, _ = io.WriteString(w, `[]`)
+			}
+		},
+	})
+
+	cfg := gitLabConfig(srv)
+	cfg.BaseRef = "refs/merge-requests/5/head"
+	cfg.Draft = true
+	cfg.Labels = []string{"i18n", "bot"}
+	cfg.Reviewers = []string{"@jdoe"}
+	cfg.Teams = []string{"translators"}
+	cfg.Assignees = []string{"ghost"}
+
+	outputs := map[string]string{}
+	write := func(name, value string) bool { outputs[name] = value; return true }
+
+	if err := runWith(func() (*Config, error) { return cfg, nil }, NewProvider, write); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	create := f.bodies["POST /api/v4/projects/acme%2Fweb%2Fapp/merge_requests"]
+	if create["title"] != "Draft: Lokalise: sync translations" || create["source_branch"] != "lok_main_1" ||
+		create["target_branch"] != "develop" || create["description"] != "body" {
+		t.Fatalf("unexpected create body: %v", create)
+	}
+	if _, ok := create["target_project_id"]; ok {
+		t.Fatalf("target_project_id is only for forks: %v", create)
+	}
+
+	// Labels and reviewers are separate updates; the last body recorded is the reviewers one,
+	// since the unknown assignee fails before its update. Reviewers already on
+	// the merge request are kept, without duplicates.
+	if update := f.bodies["PUT /api/v4/projects/acme%2Fweb%2Fapp/merge_requests/5"]; !reflect.DeepEqual(update, map[string]any{"reviewer_ids": []any{float64(7), float64(11)}}) {
+		t.Fatalf("unexpected last update: %v", update)
+	}
+	if got := strings.Count(strings.Join(f.requests, ","), "PUT /api/v4/projects/acme%2Fweb%2Fapp/merge_requests/5"); got != 2 {
+		t.Fatalf("expected label and reviewer updates, got %d: %v", got, f.requests)
+	}
+
+	want := map[string]string{
+		"pr_created": "true",
+		"pr_updated": "false",
+		"pr_exists":  "true",
+		"pr_number":  "5",
+		"pr_id":      "500",
+		"pr_url":     "https://gitlab.example.com/acme/web/app/-/merge_requests/5",
+		"pr_action":  "created",
+	}
+	if !reflect.DeepEqual(outputs, want) {
+		t.Fatalf("outputs mismatch:\ngot  %v\nwant %v", outputs, want)
+	}
+}
+
+func TestRunWith_GitLabUpdatesKeepDraftPrefix(t *testing.T) {
+	t.Parallel()
+
+	f, srv := newFakeGitLab(t, map[string]func(http.ResponseWriter, *http.Request){
+		"GET /api/v4/projects/acme%2Fweb%2Fapp/merge_requests":   reply(200, `[{"iid":3,"id":300,"web_url":"https://gitlab.example.com/mr/3","title":"Draft: Old","draft":true}]`),
+		"PUT /api/v4/projects/acme%2Fweb%2Fapp/merge_requests/3": reply(200, `{}`),
+	})
+
+	cfg := gitLabConfig(srv)
+	cfg.Title = "New title"
+
+	if err := runWith(func() (*Config, error) { return cfg, nil }, NewProvider, func(string, string) bool { return true }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	update := f.bodies["PUT /api/v4/projects/acme%2Fweb%2Fapp/merge_requests/3"]
+	if update["title"] != "Draft: New title" || update["description"] != "body" {
+		t.Fatalf("unexpected update body: %v", update)
+	}
+}
+
+func TestGitLabClient_MarkDraftAndForkCreate(t *testing.T) {
+	t.Parallel()
+
+	f, srv := newFakeGitLab(t, map[string]func(http.ResponseWriter, *http.Request){
+		"GET /api/v4/projects/acme%2Fweb%2Fapp":                  reply(200, `{"id":42}`),
+		"POST /api/v4/projects/bot%2Fapp/merge_requests":         reply(201, `{"iid":9,"id":900,"title":"T"}`),
+		"PUT /api/v4/projects/acme%2Fweb%2Fapp/merge_requests/9": reply(200, `{}`),
+	})
+
+	cfg := gitLabConfig(srv)
+	cfg.HeadRepository = "bot/app"
+	client := NewGitLabClient(cfg)
+	ctx := context.Background()
+
+	pr, err := client.Create(ctx, "main", "T", "", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := f.bodies["POST /api/v4/projects/bot%2Fapp/merge_requests"]["target_project_id"]; got != float64(42) {
+		t.Fatalf("unexpected target_project_id: %v", got)
+	}
+
+	if err := client.MarkDraft(ctx, pr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := f.bodies["PUT /api/v4/projects/acme%2Fweb%2Fapp/merge_requests/9"]["title"]; got != "Draft: T" {
+		t.Fatalf("unexpected draft title: %v", got)
+	}
+}
+
+func TestGitLabClient_AddAssigneesKeepsCurrentOnes(t *testing.T) {
+	t.Parallel()
+
+	f, srv := newFakeGitLab(t, map[string]func(http.ResponseWriter, *http.Request){
+		"GET /api/v4/users": reply(200, `[{"id":11}]`),
+		"GET /api/v4/projects/acme%2Fweb%2Fapp/merge_requests/5": reply(200, `{"iid":5,"assignees":[{"id":3}]}`),
+		"PUT /api/v4/projects/acme%2Fweb%2Fapp/merge_requests/5": reply(200, `{}`),
+	})
+
+	client := NewGitLabClient(gitLabConfig(srv))
+	if err := client.AddAssignees(context.Background(), &PullRequest{Number: 5}, []string{"jdoe"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	update := f.bodies["PUT /api/v4/projects/acme%2Fweb%2Fapp/merge_requests/5"]
+	if !reflect.DeepEqual(update, map[string]any{"assignee_ids": []any{float64(3), float64(11)}}) {
+		t.Fatalf("unexpected assignees update: %v", update)
+	}
+}
+
+func TestDraftTitle(t *testing.T) {
+	t.Parallel()
+
+	for title, want := range map[string]string{
+		"Sync":          "Draft: Sync",
+		"Draft: Sync":   "Draft: Sync",
+		"draft:Sync":    "Draft: Sync",
+		"[Draft] Sync":  "Draft: Sync",
+		"WIP: Sync":     "Draft: Sync",
+		"Drafting docs": "Draft: Drafting docs",
+	} {
+		if got := draftTitle(title); got != want {
+			t.Errorf("draftTitle(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+module github.com/lokalise/lokalise-pull-action/src/pull_request
+
+go 1.26
+
+toolchain go1.26.4
+
+require (
+	github.com/bodrovis/lokalise-actions-common/v2 v2.15.0
+	github.com/lokalise/lokalise-pull-action/src/cienv v0.0.0
+	github.com/lokalise/lokalise-pull-action/src/errkind v0.0.0
+)
+
+require go.yaml.in/yaml/v4 v4.0.0-rc.6 // indirect
+
+replace github.com/lokalise/lokalise-pull-action/src/cienv => ../cienv
+
+replace github.com/lokalise/lokalise-pull-action/src/errkind => ../errkind
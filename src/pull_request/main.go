@@ -0,0 +1,185 @@
+// Package pullrequest opens a pull request for the branch pushed by the commit
+// step, or updates the one that is already open. It is the Go counterpart of
+// js/create-update-pr.js for runs outside actions/github-script, and also
+// opens merge requests on GitLab (including self-hosted instances) and pull
+// requests on Bitbucket Cloud through the same Provider interface.
+//
+// Design notes:
+//   - Fork-aware when listing/creating PRs, assuming the commit step has already pushed the head branch.
+//   - Synthetic BASE_REF (PR merge/head refs) are replaced with the repo default branch.
+//   - Missing permissions for reviewers/assignees/labels are tolerated (warn, don't fail),
+//     and so is metadata a forge does not support, e.g. labels on Bitbucket.
+//   - Failures of the PR lookup or creation fail the step.
+package pullrequest
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/lokalise/lokalise-pull-action/src/errkind"
+)
+
+// runTimeout bounds all API calls of one run.
+const runTimeout = 2 * time.Minute
+
+// Result describes what happened to the pull request.
+type Result struct {
+	Created bool
+	Updated bool
+	PR      *PullRequest
+}
+
+// action is "created", "updated" or "none".
+func (r Result) action() string {
+	switch {
+	case r.Created:
+		return "created"
+	case r.Updated:
+		return "updated"
+	default:
+		return "none"
+	}
+}
+
+// Main runs the pull request step configured by the environment and returns the
+// process exit code. Outputs, including the error_kind of a failure, go to write.
+func Main(write func(string, string) bool) int {
+	if err := runWith(envVarsToConfig, NewProvider, write); err != nil {
+		return errkind.Report(err, classifyError(err), write)
+	}
+	return 0
+}
+
+func runWith(
+	prepare func() (*Config, error),
+	newProvider func(*Config) (Provider, error),
+	write func(string, string) bool,
+) error {
+	cfg, err := prepare()
+	if err != nil {
+		return errkind.With(errkind.Config, fmt.Errorf("error preparing configuration: %w", err))
+	}
+
+	provider, err := newProvider(cfg)
+	if err != nil {
+		return errkind.With(errkind.Config, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), runTimeout)
+	defer cancel()
+
+	res, err := createOrUpdate(ctx, provider, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create or update pull request: %w", err)
+	}
+
+	return writeOutputs(res, write)
+}
+
+// createOrUpdate updates the open PR from the branch into the base, or creates one.
+func createOrUpdate(ctx context.Context, provider Provider, cfg *Config) (Result, error) {
+	slog.Info("Creating or updating PR...", "provider", cfg.Provider)
+
+	base, err := resolveBaseRef(ctx, provider, cfg.BaseRef)
+	if err != nil {
+		return Result{}, err
+	}
+
+	head := cfg.BranchName
+	if !cfg.sameRepo() {
+		head = cfg.HeadRepository + ":" + cfg.BranchName
+	}
+	slog.Info("Resolved PR refs", "base", base, "head", head)
+
+	existing, err := provider.FindOpen(ctx, base)
+	if err != nil {
+		return Result{}, fmt.Errorf("list pull requests: %w", err)
+	}
+
+	if existing != nil {
+		slog.Info("PR already exists", "url", existing.HTMLURL)
+
+		if err := provider.Update(ctx, existing, cfg.Title, cfg.Body); err != nil {
+			slog.Warn("cannot update PR title/body", "error", err)
+		} else {
+			existing.Title = cfg.Title
+		}
+		if cfg.Draft && !existing.Draft {
+			if err := provider.MarkDraft(ctx, existing); err != nil {
+				slog.Warn("cannot convert PR to draft", "error", err)
+			}
+		}
+		applyMetadata(ctx, provider, existing, cfg)
+
+		return Result{Updated: true, PR: existing}, nil
+	}
+
+	pr, err := provider.Create(ctx, base, cfg.Title, cfg.Body, cfg.Draft)
+	if err != nil {
+		return Result{}, fmt.Errorf("create pull request: %w", err)
+	}
+	applyMetadata(ctx, provider, pr, cfg)
+	slog.Info("Created new PR", "url", pr.HTMLURL)
+
+	return Result{Created: true, PR: pr}, nil
+}
+
+func resolveBaseRef(ctx context.Context, provider Provider, raw string) (string, error) {
+	if !isSyntheticRef(raw) {
+		return raw, nil
+	}
+
+	base, err := provider.DefaultBranch(ctx)
+	if err != nil {
+		return "", fmt.Errorf("resolve default branch: %w", err)
+	}
+	slog.Info("BASE_REF was invalid/synthetic, using default branch", "base", base)
+
+	return base, nil
+}
+
+// applyMetadata adds labels, reviewers and assignees. Each is best-effort:
+// the token may lack the permission, or the forge the feature, which should
+// not fail the run.
+func applyMetadata(ctx context.Context, provider Provider, pr *PullRequest, cfg *Config) {
+	if len(cfg.Labels) > 0 {
+		if err := provider.AddLabels(ctx, pr, cfg.Labels); err != nil {
+			slog.Warn("cannot add labels", "error", err)
+		}
+	}
+	if len(cfg.Reviewers) > 0 || len(cfg.Teams) > 0 {
+		if err := provider.RequestReviewers(ctx, pr, cfg.Reviewers, cfg.Teams); err != nil {
+			slog.Warn("cannot add reviewers", "error", err)
+		}
+	}
+	if len(cfg.Assignees) > 0 {
+		if err := provider.AddAssignees(ctx, pr, cfg.Assignees); err != nil {
+			slog.Warn("cannot add assignees", "error", err)
+		}
+	}
+}
+
+// writeOutputs writes the same outputs as the github-script step of the action.
+func writeOutputs(res Result, write func(string, string) bool) error {
+	number, id, url := "", "", ""
+	if res.PR != nil {
+		number = strconv.Itoa(res.PR.Number)
+		id = strconv.FormatInt(res.PR.ID, 10)
+		url = res.PR.HTMLURL
+	}
+
+	if !write("pr_created", strconv.FormatBool(res.Created)) ||
+		!write("pr_updated", strconv.FormatBool(res.Updated)) ||
+		!write("pr_exists", strconv.FormatBool(res.PR != nil)) ||
+		!write("pr_number", number) ||
+		!write("pr_id", id) ||
+		!write("pr_url", url) ||
+		!write("pr_action", res.action()) {
+		return fmt.Errorf("failed to write to GitHub output")
+	}
+
+	return nil
+}
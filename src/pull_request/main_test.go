@@ -0,0 +1,240 @@
+package pullrequest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lokalise/lokalise-pull-action/src/errkind"
+)
+
+// fakeAPI records requests and answers them from handlers keyed by "METHOD /path",
+// with the path as sent, so GitLab project paths keep their %2F.
+type fakeAPI struct {
+	mu       sync.Mutex
+	requests []string
+	bodies   map[string]map[string]any
+	handlers map[string]func(w http.ResponseWriter, r *http.Request)
+}
+
+func newFakeGitHub(t *testing.T, handlers map[string]func(http.ResponseWriter, *http.Request)) (*fakeAPI, *httptest.Server) {
+	t.Helper()
+	return newFakeAPI(t, "Authorization", "Bearer tok", handlers)
+}
+
+// newFakeAPI starts a fake forge API that expects the auth header on every request.
+func newFakeAPI(t *testing.T, authHeader, authValue string, handlers map[string]func(http.ResponseWriter, *http.Request)) (*fakeAPI, *httptest.Server) {
+	t.Helper()
+
+	f := &fakeAPI{bodies: map[string]map[string]any{}, handlers: handlers}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Method + " " + r.URL.EscapedPath()
+		if got := r.Header.Get(authHeader); got != authValue {
+			t.Errorf("%s: unexpected %s %q", key, authHeader, got)
+		}
+
+		var body map[string]any
+		if data, _ := io.ReadAll(r.Body); len(data) > 0 {
+			if err := json.Unmarshal(data, &body); err != nil {
+				t.Errorf("%s: invalid JSON body: %v", key, err)
+			}
+		}
+
+		f.mu.Lock()
+		f.requests = append(f.requests, key)
+		f.bodies[key] = body
+		f.mu.Unlock()
+
+		h, ok := f.handlers[key]
+		if !ok {
+			t.Errorf("unexpected request %s", key)
+			http.NotFound(w, r)
+			return
+		}
+		h(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	return f, srv
+}
+
+func reply(status int, body string) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, body)
+	}
+}
+
+func testConfig(srv *httptest.Server) *Config {
+	return &Config{
+		Provider:   providerGitHub,
+		Token:      "tok",
+		APIURL:     srv.URL,
+		GraphQLURL: srv.URL + "/graphql",
+		Repository: "acme/app",
+		BranchName: "lok_main_1",
+		BaseRef:    "main",
+		Title:      "Lokalise: sync translations",
+		Body:       "body",
+	}
+}
+
+func TestRunWith_CreatesPullRequest(t *testing.T) {
+	t.Parallel()
+
+	f, srv := newFakeGitHub(t, map[string]func(http.ResponseWriter, *http.Request){
+		"GET /repos/acme/app": reply(200, `{"default_branch":"develop"}`),
+		"GET /repos/acme/app/pulls": func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			if q.Get("head") != "acme:lok_main_1" || q.Get("base") != "develop" || q.Get("state") != "open" {
+				t.Errorf("unexpected list query: %s", r.URL.RawQuery)
+			}
+			_, _ = io.WriteString(w, `[]`)
+		},
+		"POST /repos/acme/app/pulls":                       reply(201, `{"number":7,"id":700,"node_id":"PR_7","html_url":"https://github.com/acme/app/pull/7"}`),
+		"POST /repos/acme/app/issues/7/labels":             reply(200, `[]`),
+		"POST /repos/acme/app/pulls/7/requested_reviewers": reply(403, `{"message":"Resource not accessible by integration"}`),
+		"POST /repos/acme/app/issues/7/assignees":          reply(201, `{}`),
+	})
+
+	cfg := testConfig(srv)
+	cfg.BaseRef = "refs/pull/12/merge"
+	cfg.Draft = true
+	cfg.Labels = []string{"i18n"}
+	cfg.Teams = []string{"translators"}
+	cfg.Assignees = []string{"octocat"}
+
+	outputs := map[string]string{}
+	write := func(name, value string) bool { outputs[name] = value; return true }
+
+	if err := runWith(func() (*Config, error) { return cfg, nil }, NewProvider, write); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	create := f.bodies["POST /repos/acme/app/pulls"]
+	if create["head"] != "lok_main_1" || create["base"] != "develop" || create["draft"] != true || create["maintainer_can_modify"] != true {
+		t.Fatalf("unexpected create body: %v", create)
+	}
+	reviewers := f.bodies["POST /repos/acme/app/pulls/7/requested_reviewers"]
+	if !reflect.DeepEqual(reviewers["reviewers"], []any{}) || !reflect.DeepEqual(reviewers["team_reviewers"], []any{"translators"}) {
+		t.Fatalf("unexpected reviewers body: %v", reviewers)
+	}
+
+	want := map[string]string{
+		"pr_created": "true",
+		"pr_updated": "false",
+		"pr_exists":  "true",
+		"pr_number":  "7",
+		"pr_id":      "700",
+		"pr_url":     "https://github.com/acme/app/pull/7",
+		"pr_action":  "created",
+	}
+	if !reflect.DeepEqual(outputs, want) {
+		t.Fatalf("outputs mismatch:\ngot  %v\nwant %v", outputs, want)
+	}
+}
+
+func TestRunWith_UpdatesExistingForkPullRequest(t *testing.T) {
+	t.Parallel()
+
+	f, srv := newFakeGitHub(t, map[string]func(http.ResponseWriter, *http.Request){
+		"GET /repos/acme/app/pulls": func(w http.ResponseWriter, r *http.Request) {
+			if got := r.URL.Query().Get("head"); got != "bot:lok_main_1" {
+				t.Errorf("unexpected head %q", got)
+			}
+			_, _ = io.WriteString(w, `[{"number":3,"id":300,"node_id":"PR_3","html_url":"https://github.com/acme/app/pull/3","draft":false}]`)
+		},
+		"PATCH /repos/acme/app/pulls/3": reply(200, `{}`),
+		"POST /graphql": func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, `{"data":{"convertPullRequestToDraft":{"pullRequest":{"id":"PR_3","isDraft":true}}}}`)
+		},
+	})
+
+	cfg := testConfig(srv)
+	cfg.HeadRepository = "bot/app"
+	cfg.Draft = true
+	cfg.Title = "New title"
+
+	outputs := map[string]string{}
+	write := func(name, value string) bool { outputs[name] = value; return true }
+
+	if err := runWith(func() (*Config, error) { return cfg, nil }, NewProvider, write); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := f.bodies["PATCH /repos/acme/app/pulls/3"]["title"]; got != "New title" {
+		t.Fatalf("unexpected updated title: %v", got)
+	}
+	if vars, _ := f.bodies["POST /graphql"]["variables"].(map[string]any); vars["pullRequestId"] != "PR_3" {
+		t.Fatalf("unexpected GraphQL variables: %v", f.bodies["POST /graphql"])
+	}
+	if outputs["pr_action"] != "updated" || outputs["pr_number"] != "3" || outputs["pr_created"] != "false" {
+		t.Fatalf("unexpected outputs: %v", outputs)
+	}
+	if strings.Contains(strings.Join(f.requests, ","), "POST /repos/acme/app/pulls") {
+		t.Fatalf("no PR should be created: %v", f.requests)
+	}
+}
+
+func TestRunWith_LookupFailureIsClassified(t *testing.T) {
+	t.Parallel()
+
+	_, srv := newFakeGitHub(t, map[string]func(http.ResponseWriter, *http.Request){
+		"GET /repos/acme/app/pulls": reply(401, `{"message":"Bad credentials"}`),
+	})
+
+	err := runWith(func() (*Config, error) { return testConfig(srv), nil }, NewProvider, failingWrite(t))
+	if err == nil || !strings.Contains(err.Error(), "Bad credentials") {
+		t.Fatalf("expected API error, got %v", err)
+	}
+	if kind := classifyError(err); kind != errkind.Auth {
+		t.Fatalf("expected %q, got %q", errkind.Auth, kind)
+	}
+}
+
+func TestRunWith_ConfigError(t *testing.T) {
+	t.Parallel()
+
+	prepare := func() (*Config, error) { return nil, io.ErrUnexpectedEOF }
+	newProvider := func(*Config) (Provider, error) {
+		t.Fatal("provider should not be created")
+		return nil, nil
+	}
+
+	err := runWith(prepare, newProvider, failingWrite(t))
+	if kind := classifyError(err); kind != errkind.Config {
+		t.Fatalf("expected %q, got %q (%v)", errkind.Config, kind, err)
+	}
+}
+
+func TestWriteOutputs_NoPullRequest(t *testing.T) {
+	t.Parallel()
+
+	outputs := map[string]string{}
+	write := func(name, value string) bool { outputs[name] = value; return true }
+
+	if err := writeOutputs(Result{}, write); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if outputs["pr_exists"] != "false" || outputs["pr_number"] != "" || outputs["pr_action"] != "none" {
+		t.Fatalf("unexpected outputs: %v", outputs)
+	}
+
+	if err := writeOutputs(Result{}, func(string, string) bool { return false }); err == nil {
+		t.Fatal("expected error when the output cannot be written")
+	}
+}
+
+func failingWrite(t *testing.T) func(string, string) bool {
+	t.Helper()
+
+	return func(name, value string) bool {
+		t.Fatalf("unexpected output write: %s=%s", name, value)
+		return false
+	}
+}
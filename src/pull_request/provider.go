@@ -0,0 +1,72 @@
+package pullrequest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Supported PR_PROVIDER values.
+const (
+	providerGitHub    = "github"
+	providerGitLab    = "gitlab"
+	providerBitbucket = "bitbucket"
+)
+
+// errUnsupported is returned for metadata a forge has no notion of, e.g.
+// labels on Bitbucket pull requests. Like permission errors it only warns.
+var errUnsupported = errors.New("not supported")
+
+// Provider opens and updates change requests (pull requests on GitHub and
+// Bitbucket, merge requests on GitLab) from the pushed branch. The head branch
+// and repository are part of the provider, so only the base is passed in.
+type Provider interface {
+	// DefaultBranch returns the default branch of the base repository.
+	DefaultBranch(ctx context.Context) (string, error)
+	// FindOpen returns the open change request from the head branch into base, or nil.
+	FindOpen(ctx context.Context, base string) (*PullRequest, error)
+	// Create opens a change request from the head branch into base.
+	Create(ctx context.Context, base, title, body string, draft bool) (*PullRequest, error)
+	// Update replaces the title and body, keeping the draft status.
+	Update(ctx context.Context, pr *PullRequest, title, body string) error
+	// MarkDraft turns an open change request into a draft.
+	MarkDraft(ctx context.Context, pr *PullRequest) error
+	// AddLabels adds labels.
+	AddLabels(ctx context.Context, pr *PullRequest, labels []string) error
+	// RequestReviewers requests reviews from users and teams.
+	RequestReviewers(ctx context.Context, pr *PullRequest, users, teams []string) error
+	// AddAssignees assigns users.
+	AddAssignees(ctx context.Context, pr *PullRequest, users []string) error
+}
+
+// PullRequest is the subset of a change request used by the step. Number is
+// what the forge shows in URLs: the PR number on GitHub and Bitbucket, the
+// project-scoped IID on GitLab.
+type PullRequest struct {
+	Number  int
+	ID      int64
+	NodeID  string // GitHub GraphQL ID, used to convert to draft
+	HTMLURL string
+	Title   string
+	Draft   bool
+}
+
+// NewProvider returns the client of the forge selected by cfg.Provider.
+func NewProvider(cfg *Config) (Provider, error) {
+	switch cfg.Provider {
+	case providerGitHub:
+		return NewGitHubClient(cfg), nil
+	case providerGitLab:
+		return NewGitLabClient(cfg), nil
+	case providerBitbucket:
+		return NewBitbucketClient(cfg), nil
+	default:
+		return nil, fmt.Errorf("unsupported provider %q, expected github, gitlab or bitbucket", cfg.Provider)
+	}
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: 30 * time.Second}
+}
@@ -0,0 +1,41 @@
+package pullrequest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lokalise/lokalise-pull-action/src/errkind"
+)
+
+func TestNewProvider(t *testing.T) {
+	t.Parallel()
+
+	for provider, want := range map[string]string{
+		providerGitHub:    "*pullrequest.GitHubClient",
+		providerGitLab:    "*pullrequest.GitLabClient",
+		providerBitbucket: "*pullrequest.BitbucketClient",
+	} {
+		p, err := NewProvider(&Config{Provider: provider, Repository: "acme/app"})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", provider, err)
+		}
+		if got := fmt.Sprintf("%T", p); got != want {
+			t.Errorf("%s: got %s, want %s", provider, got, want)
+		}
+	}
+}
+
+func TestRunWith_UnknownProviderIsConfigError(t *testing.T) {
+	t.Parallel()
+
+	prepare := func() (*Config, error) { return &Config{Provider: "gitea"}, nil }
+
+	err := runWith(prepare, NewProvider, failingWrite(t))
+	if err == nil || !strings.Contains(err.Error(), `unsupported provider "gitea"`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if kind := classifyError(err); kind != errkind.Config {
+		t.Fatalf("expected %q, got %q", errkind.Config, kind)
+	}
+}